@@ -0,0 +1,205 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/charmbracelet/x/term"
+
+	"cli-sql/internal/config"
+	"cli-sql/internal/db"
+	"cli-sql/internal/export"
+	"cli-sql/internal/ui"
+)
+
+// ---------------------------------------------------------------------------
+// Command-line flags – connect directly, skipping the picker
+// ---------------------------------------------------------------------------
+
+// cliFlags holds the parsed command-line flags.
+type cliFlags struct {
+	uri      string
+	name     string
+	host     string
+	port     string
+	user     string
+	database string
+	command  string
+	file     string
+	format   string
+	stdin    bool // stdin is not a terminal; read the SQL from it
+
+	exportConfig  string
+	importConfig  string
+	withPasswords bool
+	readOnly      bool
+}
+
+func parseFlags() cliFlags {
+	var f cliFlags
+	flag.StringVar(&f.uri, "uri", "", "connection URI, e.g. postgres://user@host/db")
+	flag.StringVar(&f.name, "name", "", "name of a saved connection")
+	flag.StringVar(&f.host, "h", "", "database host")
+	flag.StringVar(&f.host, "host", "", "database host")
+	flag.StringVar(&f.port, "p", "", "database port")
+	flag.StringVar(&f.port, "port", "", "database port")
+	flag.StringVar(&f.user, "U", "", "database user")
+	flag.StringVar(&f.user, "username", "", "database user")
+	flag.StringVar(&f.database, "d", "", "database name")
+	flag.StringVar(&f.database, "dbname", "", "database name")
+	flag.StringVar(&f.command, "c", "", "run a single query, print the result and exit")
+	flag.StringVar(&f.file, "f", "", "run the statements in a SQL file, print the results and exit")
+	flag.StringVar(&f.format, "format", "table", "output format for -c and -f: table, csv or json")
+	flag.StringVar(&f.exportConfig, "export-config", "", "write saved connections and scripts to a bundle file and exit")
+	flag.StringVar(&f.importConfig, "import-config", "", "merge the connections and scripts in a bundle file and exit")
+	flag.BoolVar(&f.readOnly, "read-only", false, "refuse edits and writing statements, and open the session read-only on the server")
+	flag.BoolVar(&f.withPasswords, "with-passwords", false, "include passwords in --export-config (stored in plain text)")
+	flag.Parse()
+	f.stdin = !term.IsTerminal(os.Stdin.Fd())
+	return f
+}
+
+// runBundle carries out --export-config or --import-config, reporting to w.
+// It returns false if neither was given.
+func runBundle(f cliFlags, w io.Writer) (bool, error) {
+	switch {
+	case f.exportConfig != "":
+		if err := config.ExportBundle(f.exportConfig, f.withPasswords); err != nil {
+			return true, err
+		}
+		note := "passwords left out"
+		if f.withPasswords {
+			note = "passwords included in plain text"
+		}
+		fmt.Fprintf(w, "Wrote %s (%s)\n", f.exportConfig, note)
+		return true, nil
+	case f.importConfig != "":
+		sum, err := config.ImportBundle(f.importConfig)
+		if err != nil {
+			return true, err
+		}
+		fmt.Fprintf(w, "Imported %d connections and %d scripts; %d already present\n", sum.Connections, sum.Scripts, sum.Skipped)
+		for _, r := range sum.Renamed {
+			fmt.Fprintf(w, "  renamed %s\n", r)
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// direct reports whether the flags name a connection to open without
+// showing the picker or the connection form.
+func (f cliFlags) direct() bool {
+	return f.uri != "" || f.name != "" || f.host != "" || f.port != "" ||
+		f.user != "" || f.database != "" || f.command != "" || f.file != "" || f.stdin
+}
+
+// nonInteractive reports whether SQL should be run and printed without
+// starting the TUI.
+func (f cliFlags) nonInteractive() bool {
+	return f.command != "" || f.file != "" || f.stdin
+}
+
+// connectDirect opens the connection described by the flags. Fields not given
+// on the command line fall back to the PG* environment variables.
+func connectDirect(cfg *config.Config, f cliFlags) (*db.DB, error) {
+	if f.uri != "" {
+		return db.ConnectURI(f.uri, db.Options{ReadOnly: cfg.ReadOnlyFor(config.SavedConnection{})})
+	}
+
+	if f.name != "" {
+		for i, conn := range cfg.Connections {
+			if conn.Name != f.name {
+				continue
+			}
+			opts := connOptions(conn.ConnectTimeout, conn.StatementTimeout)
+			opts.ReadOnly = cfg.ReadOnlyFor(conn)
+			opts.ApplicationName = conn.ApplicationName
+			opts.SearchPath = conn.SearchPath
+			var d *db.DB
+			var err error
+			if conn.URI != "" {
+				d, err = db.ConnectURI(conn.URI, opts)
+			} else {
+				d, err = db.Connect(conn.Host, conn.Port, conn.User, conn.Password, conn.Database, opts)
+			}
+			if err != nil {
+				return nil, err
+			}
+			cfg.TouchLastUsed(i)
+			cfg.Save()
+			return d, nil
+		}
+		return nil, fmt.Errorf("no saved connection named %q", f.name)
+	}
+
+	conn := config.DefaultsFromEnv()
+	if f.host != "" {
+		conn.Host = f.host
+	}
+	if f.port != "" {
+		conn.Port = f.port
+	}
+	if f.user != "" {
+		conn.User = f.user
+	}
+	if f.database != "" {
+		conn.Database = f.database
+	}
+	return db.Connect(conn.Host, conn.Port, conn.User, conn.Password, conn.Database, db.Options{ReadOnly: cfg.ReadOnlyFor(conn)})
+}
+
+// script returns the SQL to run non-interactively, from -c, -f or piped
+// stdin, in that order of preference.
+func (f cliFlags) script() (string, error) {
+	if f.command != "" && f.file != "" {
+		return "", fmt.Errorf("-c and -f cannot be used together")
+	}
+	if f.file != "" {
+		data, err := os.ReadFile(f.file)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+	if f.command == "" && f.stdin {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("read stdin: %w", err)
+		}
+		return string(data), nil
+	}
+	return f.command, nil
+}
+
+// runScript executes each statement in sql and prints its result to w,
+// stopping at the first error. CSV and JSON rows are written as they arrive,
+// so a large result is never held in memory. Server notices go to errw, as
+// psql prints them, so they don't mix with the results.
+func runScript(d *db.DB, sql string, format export.Format, w, errw io.Writer) error {
+	stmts := ui.SplitStatements(sql)
+	if len(stmts) == 0 {
+		return fmt.Errorf("empty query")
+	}
+	for _, stmt := range stmts {
+		sw := export.NewStreamWriter(w, format)
+		er, err := d.StreamQuery(stmt, sw)
+		for _, n := range d.TakeNotices() {
+			fmt.Fprintln(errw, n)
+		}
+		if err != nil {
+			return err
+		}
+		if er != nil {
+			err = export.WriteExec(w, format, er)
+		} else {
+			err = sw.Close()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,16 +2,26 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/atotto/clipboard"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 
 	"cli-sql/internal/config"
 	"cli-sql/internal/db"
 	"cli-sql/internal/editor"
+	"cli-sql/internal/export"
 	"cli-sql/internal/ui"
 )
 
@@ -33,6 +43,20 @@ func tickCmd() tea.Cmd {
 	})
 }
 
+// pingInterval is how often the connection is checked, and its latency
+// taken, in the background.
+const pingInterval = 5 * time.Second
+
+// slowPing is the round trip beyond which the top bar shows the latency in
+// the error color.
+const slowPing = 200 * time.Millisecond
+
+// pingResultMsg carries the result of a background connection check.
+type pingResultMsg struct {
+	ok      bool
+	latency time.Duration // 0 if the connection was busy and not pinged
+}
+
 // spinnerTickMsg drives the background-copy spinner animation.
 type spinnerTickMsg struct{}
 
@@ -42,14 +66,25 @@ func spinnerTickCmd() tea.Cmd {
 	})
 }
 
+// explainResultMsg carries the estimated plan of a statement.
+type explainResultMsg struct {
+	sql  string
+	plan string
+	err  error
+}
+
 // queryResultMsg carries query results back to the app.
 type queryResultMsg struct {
 	result    *db.QueryResult
 	execRes   *db.ExecResult
 	err       error
 	lastSQL   string
-	tableName string   // extracted table name for enabling edits on free-form SELECTs
-	pks       []string // primary keys for the extracted table, if any
+	tableName string          // extracted table name for enabling edits on free-form SELECTs
+	pks       []string        // primary keys for the extracted table, if any
+	columns   []db.ColumnInfo // column metadata for the extracted table, if any
+	readOnly  string          // why a free-form SELECT was left read-only, if it was
+	limited   int             // auto LIMIT the rows were cut off at, if they were
+	notices   []db.Notice     // NOTICE, WARNING etc. the server sent while running it
 }
 
 // tableDataMsg carries table data after selecting a table.
@@ -57,13 +92,25 @@ type tableDataMsg struct {
 	result    *db.QueryResult
 	tableName string
 	pks       []string
+	columns   []db.ColumnInfo
+	reference string // set when loaded by following a foreign key; describes the hop
+	restore   *ui.Position
 	err       error
 }
 
 // commitResultMsg carries commit result.
 type commitResultMsg struct {
-	err   error
-	count int
+	err    error
+	count  int                     // changes committed, each inserted row counted
+	failed *editor.StatementOrigin // staged change whose statement failed, if known
+}
+
+// changeScriptMsg reports where the SQL for the pending changes went: to
+// path if set, else to the clipboard.
+type changeScriptMsg struct {
+	statements int
+	path       string
+	err        error
 }
 
 // reconnectResultMsg carries the result of a reconnect attempt.
@@ -72,6 +119,26 @@ type reconnectResultMsg struct {
 	err    error
 }
 
+// referencingKeysMsg carries the foreign keys that reference a row's table.
+type referencingKeysMsg struct {
+	source ui.ShowReferencingMsg
+	fks    []db.ForeignKey
+	err    error
+}
+
+// tableCommentsMsg carries the comments on the tables in the sidebar.
+type tableCommentsMsg struct {
+	comments map[string]string
+	err      error
+}
+
+// serverInfoMsg carries the server version and effective role.
+type serverInfoMsg struct {
+	version     string
+	currentUser string
+	err         error
+}
+
 // switchDBResultMsg carries the result of a database switch.
 type switchDBResultMsg struct {
 	tables    []string
@@ -80,13 +147,140 @@ type switchDBResultMsg struct {
 	err       error
 }
 
-// copyDBResultMsg carries the result of a database copy.
+// databaseOp tracks a database copy or drop running in the background.
+type databaseOp struct {
+	verb      string // "copy" or "drop", for messages
+	name      string // the database being created or dropped
+	cancel    context.CancelFunc
+	cancelled bool // the user asked for it to stop
+}
+
+// copyDBResultMsg carries the result of a database copy. switchedToDB is
+// set, with its tables, when the connection ended up on another database.
 type copyDBResultMsg struct {
-	databases []string
-	target    string
+	op           *databaseOp
+	databases    []string
+	target       string
+	switchedToDB string
+	tables       []string
+	err          error
+}
+
+// tableImport tracks a CSV import running in the background.
+type tableImport struct {
+	table     string
+	cancel    context.CancelFunc
+	cancelled bool // the user asked for it to stop
+}
+
+// importCSVResultMsg carries the result of loading a CSV file into a table.
+type importCSVResultMsg struct {
+	load  *tableImport
+	table string
+	rows  int64
+	err   error
+}
+
+// tableExport tracks a whole-table export running in the background. The
+// stream updates rows and polls cancelled from its own goroutine.
+type tableExport struct {
+	db        *db.DB // of the session it was started from
+	ctx       context.Context
+	cancel    context.CancelFunc
+	table     string
+	path      string
+	rows      atomic.Int64
+	cancelled atomic.Bool
+}
+
+// tableCount tracks an exact row count running in the background.
+type tableCount struct {
+	db        *db.DB
+	table     string
+	started   time.Time
+	cancelled bool // the user asked for it to stop
+}
+
+// rowCountMsg carries the result of counting the rows of a table.
+type rowCountMsg struct {
+	count *tableCount
+	rows  int64
+	err   error
+}
+
+// exportTickMsg refreshes the progress of a running export.
+type exportTickMsg struct{}
+
+// exportDoneMsg carries the result of a whole-table export.
+type exportDoneMsg struct {
+	export *tableExport
+	err    error
+}
+
+// diffResultMsg carries the comparison of one query's rows on two connections.
+type diffResultMsg struct {
+	title string
+	diff  ui.ResultDiff
+	err   error
+}
+
+// activityMsg carries a reading of pg_stat_activity for the activity overlay.
+type activityMsg struct {
+	rows []db.Activity
+	err  error
+}
+
+// activityInterval is how often the activity overlay refreshes while open.
+const activityInterval = 2 * time.Second
+
+// activityTickMsg refreshes the activity overlay.
+type activityTickMsg struct{}
+
+// backendSignalledMsg carries the result of cancelling or terminating a
+// backend from the activity overlay.
+type backendSignalledMsg struct {
+	pid       int32
+	terminate bool
 	err       error
 }
 
+// readOnlyMsg carries the result of turning read-only mode on or off.
+type readOnlyMsg struct {
+	on  bool
+	err error
+}
+
+// alterTemplateMsg carries the columns of a table for its ALTER TABLE template.
+type alterTemplateMsg struct {
+	table   string
+	columns []db.ColumnInfo
+	err     error
+}
+
+// profileTableMsg carries the column profile of a table.
+type profileTableMsg struct {
+	table   string
+	profile *db.TableProfile
+	err     error
+}
+
+// selectTemplateMsg carries the columns of a table for its SELECT template.
+type selectTemplateMsg struct {
+	table   string
+	columns []db.ColumnInfo
+	err     error
+}
+
+// describeTableMsg carries the columns of a table for the columns overlay.
+type describeTableMsg struct {
+	table    string
+	comment  string
+	columns  []db.ColumnInfo
+	indexes  []db.IndexInfo
+	err      error
+	indexErr error
+}
+
 // ddlRefreshMsg carries the result of a DDL-triggered table list refresh.
 type ddlRefreshMsg struct {
 	tables    []string
@@ -97,6 +291,7 @@ type ddlRefreshMsg struct {
 
 // dropDBResultMsg carries the result of a database drop.
 type dropDBResultMsg struct {
+	op           *databaseOp
 	databases    []string
 	dropped      string
 	switchedToDB string
@@ -104,66 +299,95 @@ type dropDBResultMsg struct {
 	err          error
 }
 
-// Model is the root Bubble Tea model.
+// Model is the root Bubble Tea model. The fields of the active connection
+// are promoted from the embedded session; the others wait in sessions.
 type Model struct {
+	session
+	sessions          []session // every open connection; sessions[current] is stale while active
+	current           int
+	nextSessionID     int
+	cfg               *config.Config
 	activePane        Pane
-	sidebar           ui.SidebarModel
-	editor            ui.EditorModel
-	results           ui.ResultsModel
 	statusbar         ui.StatusBarModel
 	scriptsModal      ui.ScriptsModalModel
-	db                *db.DB
-	changes           *editor.ChangeTracker
 	width             int
 	height            int
-	lastSQL           string
-	lastTable         string
-	pendingDMLMsg     string
 	confirmClearEdits bool
-	currentScript     string
+	confirmQuit       bool
+	confirmRunSQL     string // unfiltered UPDATE or DELETE waiting for y/n
+	confirmCommit     bool   // commit of many row deletions waiting for y/n
+	autoLimit         int    // rows a free-form SELECT is capped at; 0 for no cap
+	chooser           ui.ChooserModel
+	help              ui.HelpModel
+	plan              ui.PlanModel
+	diff              ui.DiffModel
+	columns           ui.ColumnsModel
+	profile           ui.ProfileModel
+	pending           ui.PendingModel
+	activity          ui.ActivityModel
+	activityTicking   bool             // an activityTickMsg is on its way
+	activityLoading   bool             // a reading of pg_stat_activity is on its way
+	messages          ui.MessagesModel // session log, shown in place of the results
+	exporting         *tableExport     // whole-table export in progress, if any
+	importing         *tableImport     // CSV import in progress, if any
+	counting          *tableCount      // row count in progress, if any
+	dbOp              *databaseOp      // database copy or drop in progress, if any
+	pendingConns      []connChoice     // what the connection chooser's options do
+	pendingDiff       []int            // sessions the diff chooser offers to compare against
+	zoomed            bool             // focused pane fills the whole area
 }
 
 // NewModel creates the root app model.
-func NewModel(database *db.DB, tables []string, databases []string) Model {
-	changes := editor.NewChangeTracker()
-
-	sidebar := ui.NewSidebarModel(tables)
-	sidebar.SetFocused(true)
-	sidebar.SetDatabases(databases)
-	sidebar.SetActiveDatabase(database.Database())
+func NewModel(database *db.DB, tables []string, databases []string, cfg *config.Config) Model {
+	s := newSession(database, tables, databases, cfg, database.Database())
+	s.sidebar.SetFocused(true)
 
-	editorModel := ui.NewEditorModel()
-	editorModel.SetTableNames(tables)
-
-	autosaved, _ := config.LoadAutosave()
-	if autosaved != "" {
-		editorModel.SetValue(autosaved)
-	}
-
-	results := ui.NewResultsModel(changes)
 	statusbar := ui.NewStatusBarModel()
 	statusbar.SetActivePane(0)
+	statusbar.SetSlowQuery(cfg.SlowQuery())
 	scriptsModal := ui.NewScriptsModalModel()
 
 	return Model{
-		activePane:   SidebarPane,
-		sidebar:      sidebar,
-		editor:       editorModel,
-		results:      results,
-		statusbar:    statusbar,
-		scriptsModal: scriptsModal,
-		db:           database,
-		changes:      changes,
+		session:       s,
+		sessions:      []session{s},
+		nextSessionID: s.id + 1,
+		cfg:           cfg,
+		activePane:    SidebarPane,
+		statusbar:     statusbar,
+		scriptsModal:  scriptsModal,
+		autoLimit:     cfg.AutoLimitRows(),
 	}
 }
 
+// SetConnectionColor marks the first connection with the named connection
+// color, as the saved connection it was opened from has.
+func (m *Model) SetConnectionColor(color string) {
+	m.color = color
+	m.sessions[0].color = color
+	ui.TintConnection(color)
+}
+
 // Init starts the app.
 func (m Model) Init() tea.Cmd {
-	return tickCmd()
+	return tagCmd(m.id, tea.Batch(tickCmd(), m.loadServerInfo(), m.loadTableComments()))
 }
 
-// Update handles all messages.
+// Update handles all messages. Commands are tagged with the connection
+// that issued them, so a result arriving after a switch is applied to the
+// connection it belongs to rather than the one now shown.
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if sm, ok := msg.(sessionMsg); ok {
+		if sm.id != m.id {
+			return m.updateBackground(sm)
+		}
+		msg = sm.msg
+	}
+	res, cmd := m.update(msg)
+	next := res.(Model)
+	return next, tagCmd(next.id, cmd)
+}
+
+func (m Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
@@ -175,8 +399,24 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tickMsg:
 		m.statusbar.ClearExpiredMessage()
 		m.statusbar.SetPendingChanges(m.changes.PendingCount())
+		if !m.pinging && time.Since(m.lastPing) >= pingInterval {
+			m.pinging = true
+			m.lastPing = time.Now()
+			return m, tea.Batch(tickCmd(), m.ping())
+		}
 		return m, tickCmd()
 
+	case pingResultMsg:
+		m.pinging = false
+		if m.connected && !msg.ok {
+			m.statusbar.SetMessage("Connection lost (Ctrl+R to reconnect)", ui.MsgError)
+		}
+		m.connected = msg.ok
+		if msg.latency > 0 || !msg.ok {
+			m.latency = msg.latency
+		}
+		return m, nil
+
 	case ui.ScriptLoadedMsg:
 		m.editor.SetValue(msg.Content)
 		m.currentScript = msg.Name
@@ -191,12 +431,81 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case ui.ScriptModalClosedMsg:
 		return m, nil
 
+	case tea.MouseMsg:
+		return m.handleMouse(msg)
+
 	case tea.KeyMsg:
 		if m.scriptsModal.Visible() {
 			var cmd tea.Cmd
 			m.scriptsModal, cmd = m.scriptsModal.Update(msg)
 			return m, cmd
 		}
+		if m.chooser.Visible() {
+			var cmd tea.Cmd
+			m.chooser, cmd = m.chooser.Update(msg)
+			return m, cmd
+		}
+		if m.help.Visible() {
+			m.help, _ = m.help.Update(msg)
+			return m, nil
+		}
+		if m.plan.Visible() {
+			m.plan, _ = m.plan.Update(msg)
+			return m, nil
+		}
+		if m.diff.Visible() {
+			m.diff, _ = m.diff.Update(msg)
+			return m, nil
+		}
+		if m.columns.Visible() {
+			var cmd tea.Cmd
+			m.columns, cmd = m.columns.Update(msg)
+			return m, cmd
+		}
+		if m.profile.Visible() {
+			m.profile, _ = m.profile.Update(msg)
+			return m, nil
+		}
+		if m.pending.Visible() {
+			var cmd tea.Cmd
+			m.pending, cmd = m.pending.Update(msg)
+			return m, cmd
+		}
+		if m.activity.Visible() {
+			var cmd tea.Cmd
+			m.activity, cmd = m.activity.Update(msg)
+			return m, cmd
+		}
+
+		if m.confirmQuit {
+			m.confirmQuit = false
+			if msg.String() == "y" || msg.String() == "Y" {
+				m.saveBuffers()
+				return m, tea.Quit
+			}
+			m.statusbar.SetMessage("Cancelled", ui.MsgInfo)
+			return m, nil
+		}
+
+		if m.confirmRunSQL != "" {
+			sql := m.confirmRunSQL
+			m.confirmRunSQL = ""
+			if msg.String() == "y" || msg.String() == "Y" {
+				m.lastSQL = sql
+				return m, m.executeQuery(sql)
+			}
+			m.statusbar.SetMessage("Cancelled", ui.MsgInfo)
+			return m, nil
+		}
+
+		if m.confirmCommit {
+			m.confirmCommit = false
+			if msg.String() == "y" || msg.String() == "Y" {
+				return m, m.commitChanges()
+			}
+			m.statusbar.SetMessage("Commit cancelled; the deletions are still staged", ui.MsgInfo)
+			return m, nil
+		}
 
 		if m.confirmClearEdits {
 			switch msg.String() {
@@ -213,24 +522,33 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
-		// Global shortcuts
-		switch msg.String() {
-		case "ctrl+c":
-			config.SaveAutosave(m.editor.Value())
+		// Global shortcuts. A printable key is left to the pane when it is
+		// taking text, in case one has been bound to a global action.
+		if msg.Type == tea.KeyRunes && m.inputFocused() {
+			break
+		}
+		switch {
+		case ui.KeyMatches(msg, ui.ActionQuit):
+			if n := m.uncommittedCount(); n > 0 {
+				m.confirmQuit = true
+				m.statusbar.SetMessage(fmt.Sprintf("You have %d uncommitted changes — quit anyway? (y/n)", n), ui.MsgInfo)
+				return m, nil
+			}
+			m.saveBuffers()
 			return m, tea.Quit
-		case "tab":
+		case ui.KeyMatches(msg, ui.ActionNextPane):
 			if m.activePane == ResultsPane && (m.results.IsEditing() || m.results.IsSearching() || m.results.IsPreviewing()) {
 				break
 			}
 			if m.activePane == SidebarPane && m.sidebar.IsSearching() {
 				break
 			}
-			if m.activePane == EditorPane && m.editor.HasGhost() {
+			if m.activePane == EditorPane && m.editor.HasGhost() && ui.KeyMatches(msg, ui.ActionAcceptCompletion) {
 				break
 			}
 			m.cycleFocus(true)
 			return m, nil
-		case "shift+tab":
+		case ui.KeyMatches(msg, ui.ActionPrevPane):
 			if m.activePane == ResultsPane && (m.results.IsEditing() || m.results.IsSearching() || m.results.IsPreviewing()) {
 				break
 			}
@@ -239,70 +557,461 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			m.cycleFocus(false)
 			return m, nil
-		case "ctrl+s":
+		case ui.KeyMatches(msg, ui.ActionCommit):
 			if m.activePane == ResultsPane && m.results.IsPreviewing() {
 				break
 			}
 			if m.changes.HasChanges() || m.results.GetInsertedRowValues() != nil {
+				if m.refuseReadOnly("committing changes") {
+					return m, nil
+				}
+				if m.needsCommitConfirm() {
+					return m, nil
+				}
 				return m, m.commitChanges()
 			}
 			return m, nil
-		case "ctrl+r":
+		case ui.KeyMatches(msg, ui.ActionPendingChanges):
+			if m.activePane == ResultsPane && m.results.IsPreviewing() {
+				break
+			}
+			m.pending.Open(m.pendingChanges(), ui.DisplayFormat{MaskColumns: m.cfg.MaskColumns})
+			return m, nil
+		case ui.KeyMatches(msg, ui.ActionChangeScript):
+			if m.activePane == ResultsPane && m.results.IsPreviewing() {
+				break
+			}
+			if !m.changes.HasChanges() && m.results.GetInsertedRowValues() == nil {
+				m.statusbar.SetMessage("No pending changes", ui.MsgInfo)
+				return m, nil
+			}
+			return m, m.copyChangeScript()
+		case ui.KeyMatches(msg, ui.ActionReconnect):
 			m.statusbar.SetMessage("Reconnecting...", ui.MsgInfo)
 			return m, m.reconnect()
-		case "ctrl+x":
+		case ui.KeyMatches(msg, ui.ActionRefreshTable):
+			if m.lastTable == "" {
+				m.statusbar.SetMessage("No table to refresh", ui.MsgInfo)
+				return m, nil
+			}
+			if m.results.GetInsertedRowValues() != nil {
+				m.statusbar.SetMessage("Commit or clear the inserted rows before refreshing", ui.MsgError)
+				return m, nil
+			}
+			m.statusbar.SetMessage(fmt.Sprintf("Refreshing %s...", m.lastTable), ui.MsgInfo)
+			return m, m.reloadTable(m.lastTable)
+		case ui.KeyMatches(msg, ui.ActionRefreshTables):
+			m.statusbar.SetMessage("Refreshing tables...", ui.MsgInfo)
+			return m, m.refreshTables()
+		case ui.KeyMatches(msg, ui.ActionRerunQuery):
+			if m.lastSQL == "" {
+				m.statusbar.SetMessage("No query to re-run", ui.MsgInfo)
+				return m, nil
+			}
+			if m.needsRunConfirm(m.lastSQL) {
+				return m, nil
+			}
+			m.statusbar.SetMessage("Re-running: "+firstLine(m.lastSQL), ui.MsgInfo)
+			return m, m.executeQuery(m.lastSQL)
+		case ui.KeyMatches(msg, ui.ActionRunUnlimited):
+			if m.limitedSQL == "" {
+				m.statusbar.SetMessage("No results were cut off by the automatic LIMIT", ui.MsgInfo)
+				return m, nil
+			}
+			m.statusbar.SetMessage("Fetching all rows: "+firstLine(m.limitedSQL), ui.MsgInfo)
+			return m, m.executeQueryLimit(m.limitedSQL, 0)
+		case ui.KeyMatches(msg, ui.ActionCancel):
+			if m.dbOp != nil {
+				if !m.dbOp.cancelled {
+					m.dbOp.cancelled = true
+					m.dbOp.cancel()
+					m.statusbar.SetMessage(fmt.Sprintf("Cancelling the %s of %s…", m.dbOp.verb, m.dbOp.name), ui.MsgInfo)
+				}
+				return m, nil
+			}
+			if m.importing != nil {
+				if !m.importing.cancelled {
+					m.importing.cancelled = true
+					m.importing.cancel()
+					m.statusbar.SetMessage(fmt.Sprintf("Cancelling the import into %s…", m.importing.table), ui.MsgInfo)
+				}
+				return m, nil
+			}
+			if m.counting != nil && m.exporting == nil {
+				m.counting.cancelled = true
+				m.statusbar.SetMessage(fmt.Sprintf("Cancelling the count of %s…", m.counting.table), ui.MsgInfo)
+				return m, cancelQuery(m.counting.db)
+			}
+			if m.exporting == nil {
+				m.statusbar.SetMessage("Nothing to cancel", ui.MsgInfo)
+				return m, nil
+			}
+			if !m.exporting.cancelled.Swap(true) {
+				m.statusbar.SetMessage(fmt.Sprintf("Cancelling the export of %s…", m.exporting.table), ui.MsgInfo)
+				m.exporting.cancel()
+			}
+			return m, nil
+		case ui.KeyMatches(msg, ui.ActionMessages):
+			if m.messages.Visible() {
+				m.messages.Close()
+				return m, nil
+			}
+			if m.activePane == ResultsPane && (m.results.IsEditing() || m.results.IsPreviewing()) {
+				break
+			}
+			m.messages.Open()
+			m.focusPane(ResultsPane)
+			return m, nil
+		case ui.KeyMatches(msg, ui.ActionRecallQuery):
+			if m.lastSQL == "" {
+				m.statusbar.SetMessage("No query to recall", ui.MsgInfo)
+				return m, nil
+			}
+			m.editor.SetValue(m.lastSQL)
+			m.focusPane(EditorPane)
+			return m, nil
+		case ui.KeyMatches(msg, ui.ActionDiscardChanges):
 			if m.changes.HasChanges() || m.results.GetInsertedRowValues() != nil {
 				m.confirmClearEdits = true
 				m.statusbar.SetMessage("Clear all pending changes? (y/n)", ui.MsgInfo)
 				return m, nil
 			}
-		case "ctrl+o":
+		case ui.KeyMatches(msg, ui.ActionConnections):
+			m.openConnectionChooser()
+			return m, nil
+		case ui.KeyMatches(msg, ui.ActionNextConnection):
+			if len(m.sessions) < 2 {
+				m.statusbar.SetMessage(fmt.Sprintf("Only one connection is open (%s to open another)", ui.KeyLabel(ui.ActionConnections)), ui.MsgInfo)
+				return m, nil
+			}
+			m.switchSession((m.current + 1) % len(m.sessions))
+			return m, nil
+		case ui.KeyMatches(msg, ui.ActionDiffResults):
+			return m, m.startDiff()
+		case ui.KeyMatches(msg, ui.ActionReadOnly):
+			on := !m.db.ReadOnly()
+			if !on && m.readOnlyLocked {
+				m.statusbar.SetMessage("This connection was opened read-only; reconnect without read_only to write", ui.MsgError)
+				return m, nil
+			}
+			return m, m.setReadOnly(on)
+		case ui.KeyMatches(msg, ui.ActionActivity):
+			m.activity.Open()
+			cmds := []tea.Cmd{m.loadActivity()}
+			if !m.activityTicking {
+				m.activityTicking = true
+				cmds = append(cmds, activityTickCmd())
+			}
+			return m, tea.Batch(cmds...)
+		case ui.KeyMatches(msg, ui.ActionScripts):
 			m.scriptsModal.Open(m.editor.Value())
 			return m, nil
+		case ui.KeyMatches(msg, ui.ActionZoom):
+			m.zoomed = !m.zoomed
+			m.recalcLayout()
+			return m, nil
+		case ui.KeyMatches(msg, ui.ActionHelp):
+			m.help.Open()
+			return m, nil
 		}
 
 	case ui.EditBlockedMsg:
 		m.statusbar.SetMessage(msg.Reason, ui.MsgError)
 		return m, nil
 
+	case ui.DiscardChangeMsg:
+		m.discardChange(msg.Change)
+		m.pending.SetChanges(m.pendingChanges())
+		m.statusbar.SetPendingChanges(m.changes.PendingCount())
+		return m, nil
+
+	case ui.SaveAsTableMsg:
+		if m.refuseReadOnly("creating tables") {
+			return m, nil
+		}
+		if m.lastSQL == "" || !isReadOnlyQuery(m.lastSQL) {
+			m.statusbar.SetMessage("Only the output of a single SELECT can be saved as a table", ui.MsgError)
+			return m, nil
+		}
+		// CREATE TABLE fails on an existing name anyway; this says so sooner
+		// and without a round trip.
+		if slices.Contains(m.sidebar.Tables(), msg.Name) {
+			m.statusbar.SetMessage(fmt.Sprintf("Table %s already exists", msg.Name), ui.MsgError)
+			return m, nil
+		}
+		query := strings.TrimRight(strings.TrimSpace(m.lastSQL), "; \t\n")
+		sql := fmt.Sprintf("CREATE TABLE %s AS %s", db.QuoteIdentifier(msg.Name), query)
+		m.statusbar.SetMessage("Saving results as "+msg.Name+"...", ui.MsgInfo)
+		// Its result goes through the DDL refresh, which lists and opens the
+		// new table.
+		return m, m.executeQuery(sql)
+
+	case ui.FollowReferenceMsg:
+		if msg.TableName == "" {
+			m.statusbar.SetMessage("Cannot follow references in free-form query results", ui.MsgError)
+			return m, nil
+		}
+		return m, m.followReference(msg)
+
+	case ui.ShowReferencingMsg:
+		return m, m.findReferencing(msg)
+
+	case referencingKeysMsg:
+		switch {
+		case msg.err != nil:
+			m.statusbar.SetMessage("Cannot find referencing rows: "+msg.err.Error(), ui.MsgError)
+		case len(msg.fks) == 0:
+			m.statusbar.SetMessage(fmt.Sprintf("No foreign keys reference %s.%s", msg.source.TableName, msg.source.Column), ui.MsgInfo)
+		case len(msg.fks) == 1:
+			return m, m.loadReferencing(msg.source, msg.fks[0])
+		default:
+			m.pendingRefSource = msg.source
+			m.pendingRefs = msg.fks
+			m.pendingConns = nil
+			m.pendingDiff = nil
+			options := make([]string, len(msg.fks))
+			for i, fk := range msg.fks {
+				options[i] = fmt.Sprintf("%s (%s)", fk.Table, strings.Join(fk.Columns, ", "))
+			}
+			m.chooser.Open(fmt.Sprintf("Rows referencing %s.%s", msg.source.TableName, msg.source.Column), options)
+		}
+		return m, nil
+
+	case ui.ChooserSelectedMsg:
+		if m.pendingDiff != nil {
+			choices := m.pendingDiff
+			m.pendingDiff = nil
+			if msg.Index < len(choices) {
+				return m, m.diffAgainst(choices[msg.Index])
+			}
+			return m, nil
+		}
+		if m.pendingConns != nil {
+			choices := m.pendingConns
+			m.pendingConns = nil
+			if msg.Index < len(choices) {
+				return m, m.chooseConnection(choices[msg.Index])
+			}
+			return m, nil
+		}
+		if msg.Index < len(m.pendingRefs) {
+			fk := m.pendingRefs[msg.Index]
+			m.pendingRefs = nil
+			return m, m.loadReferencing(m.pendingRefSource, fk)
+		}
+		return m, nil
+
 	case ui.DeleteDatabaseMsg:
-		m.statusbar.SetMessage(fmt.Sprintf("Dropping %s...", msg.Name), ui.MsgInfo)
-		return m, m.dropDatabase(msg.Name)
+		if m.refuseReadOnly("dropping databases") {
+			return m, nil
+		}
+		if m.refuseBusyDatabase() {
+			return m, nil
+		}
+		m.dbOp = &databaseOp{verb: "drop", name: msg.Name}
+		m.statusbar.SetMessage(fmt.Sprintf("Dropping %s... (%s cancel)", msg.Name, ui.KeyLabel(ui.ActionCancel)), ui.MsgInfo)
+		return m, m.dropDatabase(m.dbOp)
 
 	case dropDBResultMsg:
-		if msg.err != nil {
+		m.dbOp = nil
+		var cmd tea.Cmd
+		if msg.switchedToDB != "" {
+			cmd = m.enterDatabase(msg.switchedToDB, msg.tables)
+		}
+		switch {
+		case msg.op.cancelled && msg.err != nil:
+			m.statusbar.SetMessage(fmt.Sprintf("Drop of %s cancelled", msg.op.name), ui.MsgInfo)
+		case msg.err != nil:
 			m.statusbar.SetMessage("Drop failed: "+msg.err.Error(), ui.MsgError)
-		} else {
+		default:
 			m.sidebar.SetDatabases(msg.databases)
-			if msg.switchedToDB != "" {
-				m.sidebar.SetActiveDatabase(msg.switchedToDB)
-				m.sidebar.SetTables(msg.tables)
-				m.editor.SetTableNames(msg.tables)
-				m.changes.Clear()
-				m.lastTable = ""
-				m.results.Clear()
-			}
 			m.statusbar.SetMessage(fmt.Sprintf("Dropped database %s", msg.dropped), ui.MsgSuccess)
 		}
-		return m, nil
+		return m, cmd
 
 	case ui.CopyDatabaseMsg:
+		if m.refuseReadOnly("copying databases") {
+			return m, nil
+		}
+		if m.refuseBusyDatabase() {
+			return m, nil
+		}
+		m.dbOp = &databaseOp{verb: "copy", name: msg.Target}
 		m.statusbar.SetCopyingDB(true, msg.Target)
 		m.statusbar.SetMessage(fmt.Sprintf("Copying %s → %s…", msg.Source, msg.Target), ui.MsgInfo)
-		return m, tea.Batch(m.copyDatabase(msg.Source, msg.Target), spinnerTickCmd())
+		return m, tea.Batch(m.copyDatabase(m.dbOp, msg.Source), spinnerTickCmd())
 
 	case copyDBResultMsg:
+		m.dbOp = nil
 		m.statusbar.SetCopyingDB(false, "")
-		if msg.err != nil {
+		var cmd tea.Cmd
+		if msg.switchedToDB != "" {
+			cmd = m.enterDatabase(msg.switchedToDB, msg.tables)
+		}
+		switch {
+		case msg.op.cancelled && msg.err != nil:
+			m.statusbar.SetMessage(fmt.Sprintf("Copy to %s cancelled", msg.target), ui.MsgInfo)
+		case msg.err != nil:
 			m.statusbar.SetMessage("Copy failed: "+msg.err.Error(), ui.MsgError)
-		} else {
+		default:
 			m.sidebar.SetDatabases(msg.databases)
 			m.statusbar.SetMessage(fmt.Sprintf("Created database %s", msg.target), ui.MsgSuccess)
 		}
+		return m, cmd
+
+	case ui.ImportCSVMsg:
+		if m.refuseReadOnly("importing") {
+			return m, nil
+		}
+		if m.importing != nil {
+			m.statusbar.SetMessage("Already importing into "+m.importing.table, ui.MsgError)
+			return m, nil
+		}
+		m.importing = &tableImport{table: msg.Table}
+		m.statusbar.SetMessage(fmt.Sprintf("Loading %s into %s… (%s cancel)", msg.Path, msg.Table, ui.KeyLabel(ui.ActionCancel)), ui.MsgInfo)
+		return m, m.importCSV(m.importing, msg.Path)
+
+	case importCSVResultMsg:
+		m.importing = nil
+		if msg.load.cancelled && msg.err != nil {
+			m.statusbar.SetMessage(fmt.Sprintf("Import into %s cancelled", msg.table), ui.MsgInfo)
+			return m, nil
+		}
+		if msg.err != nil {
+			m.statusbar.SetMessage("Import failed: "+msg.err.Error(), ui.MsgError)
+			m.messages.Add(ui.LogError, "Import failed: "+msg.err.Error())
+			return m, nil
+		}
+		done := fmt.Sprintf("Loaded %d rows into %s", msg.rows, msg.table)
+		m.statusbar.SetMessage(done, ui.MsgSuccess)
+		m.messages.Add(ui.LogResult, done)
+		if msg.table == m.lastTable && m.results.GetInsertedRowValues() == nil {
+			return m, m.reloadTable(msg.table)
+		}
+		return m, nil
+
+	case ui.AlterTableMsg:
+		return m, m.loadAlterTemplate(msg.Table)
+
+	case alterTemplateMsg:
+		if msg.err != nil {
+			m.statusbar.SetMessage("Cannot read columns: "+msg.err.Error(), ui.MsgError)
+			return m, nil
+		}
+		m.appendToEditor(alterTemplate(msg.table, msg.columns))
+		m.statusbar.SetMessage(fmt.Sprintf("Finish the ALTER TABLE and run it with %s", ui.KeyLabel(ui.ActionExecuteStatement)), ui.MsgInfo)
+		return m, nil
+
+	case ui.SelectTemplateMsg:
+		return m, m.loadSelectTemplate(msg.Table)
+
+	case selectTemplateMsg:
+		if msg.err != nil {
+			m.statusbar.SetMessage("Cannot read columns: "+msg.err.Error(), ui.MsgError)
+			return m, nil
+		}
+		m.appendToEditor(selectTemplate(msg.table, msg.columns))
+		m.statusbar.SetMessage(fmt.Sprintf("Trim the column list and run it with %s", ui.KeyLabel(ui.ActionExecuteStatement)), ui.MsgInfo)
 		return m, nil
 
+	case ui.DescribeTableMsg:
+		return m, m.describeTable(msg.Table)
+
+	case describeTableMsg:
+		if msg.err != nil {
+			m.statusbar.SetMessage("Cannot read columns: "+msg.err.Error(), ui.MsgError)
+			return m, nil
+		}
+		if msg.indexErr != nil {
+			m.statusbar.SetMessage("Cannot read indexes: "+msg.indexErr.Error(), ui.MsgError)
+		}
+		m.columns.Open(msg.table, msg.comment, msg.columns, msg.indexes)
+		return m, nil
+
+	case ui.ProfileTableMsg:
+		m.statusbar.SetMessage("Profiling "+msg.Table+"...", ui.MsgInfo)
+		return m, m.profileTable(msg.Table)
+
+	case profileTableMsg:
+		if msg.err != nil {
+			m.statusbar.SetMessage("Cannot profile "+msg.table+": "+msg.err.Error(), ui.MsgError)
+			return m, nil
+		}
+		m.statusbar.SetMessage(fmt.Sprintf("Profiled %d columns of %s", len(msg.profile.Columns), msg.table), ui.MsgSuccess)
+		m.profile.Open(msg.table, msg.profile)
+		return m, nil
+
+	case ui.GeneratedSQLMsg:
+		m.appendToEditor(msg.SQL)
+		m.statusbar.SetMessage(fmt.Sprintf("Run it with %s", ui.KeyLabel(ui.ActionExecuteStatement)), ui.MsgInfo)
+		return m, nil
+
+	case ui.ExportTableMsg:
+		if m.exporting != nil {
+			m.statusbar.SetMessage("An export is already running", ui.MsgError)
+			return m, nil
+		}
+		m.exporting = &tableExport{db: m.db, table: msg.Table, path: msg.Path}
+		m.exporting.ctx, m.exporting.cancel = context.WithCancel(context.Background())
+		m.statusbar.SetMessage(m.exportProgress(), ui.MsgInfo)
+		return m, tea.Batch(m.exportTable(m.exporting), exportTickCmd())
+
+	case ui.CountRowsMsg:
+		if m.counting != nil {
+			m.statusbar.SetMessage("Already counting the rows of "+m.counting.table, ui.MsgError)
+			return m, nil
+		}
+		m.counting = &tableCount{db: m.db, table: msg.Table, started: time.Now()}
+		m.statusbar.SetCounting(msg.Table)
+		return m, tea.Batch(m.countRows(m.counting), spinnerTickCmd())
+
+	case rowCountMsg:
+		m.counting = nil
+		m.statusbar.SetCounting("")
+		c := msg.count
+		switch {
+		case c.cancelled && msg.err != nil:
+			m.statusbar.SetMessage(fmt.Sprintf("Count of %s cancelled", c.table), ui.MsgInfo)
+		case msg.err != nil:
+			m.statusbar.SetMessage(fmt.Sprintf("Cannot count %s: %v", c.table, msg.err), ui.MsgError)
+		default:
+			m.statusbar.SetMessage(fmt.Sprintf("%s: %d rows (exact, counted in %s)", c.table, msg.rows,
+				time.Since(c.started).Round(time.Millisecond)), ui.MsgSuccess)
+		}
+		return m, nil
+
+	case exportTickMsg:
+		if m.exporting == nil {
+			return m, nil
+		}
+		if !m.exporting.cancelled.Load() {
+			m.statusbar.SetMessage(m.exportProgress(), ui.MsgInfo)
+		}
+		return m, exportTickCmd()
+
+	case exportDoneMsg:
+		m.exporting = nil
+		e := msg.export
+		switch {
+		case e.cancelled.Load():
+			m.statusbar.SetMessage(fmt.Sprintf("Export of %s cancelled", e.table), ui.MsgInfo)
+			m.messages.Add(ui.LogInfo, fmt.Sprintf("Export of %s to %s cancelled after %d rows", e.table, e.path, e.rows.Load()))
+		case msg.err != nil:
+			m.statusbar.SetMessage("Export failed: "+msg.err.Error(), ui.MsgError)
+			m.messages.Add(ui.LogError, "Export failed: "+msg.err.Error())
+		default:
+			done := fmt.Sprintf("Exported %d rows of %s to %s", e.rows.Load(), e.table, e.path)
+			m.statusbar.SetMessage(done, ui.MsgSuccess)
+			m.messages.Add(ui.LogResult, done)
+		}
+		return m, nil
+
+	case sessionOpenedMsg:
+		m.openSession(msg)
+		return m, tea.Batch(m.loadServerInfo(), m.loadTableComments())
+
 	case spinnerTickMsg:
-		if m.statusbar.IsCopyingDB() {
+		if m.statusbar.Spinning() {
 			m.statusbar.AdvanceSpinner()
 			return m, spinnerTickCmd()
 		}
@@ -320,25 +1029,60 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.editor.SetTableNames(msg.tables)
 			m.sidebar.SetDatabases(msg.databases)
 			m.sidebar.SetActiveDatabase(msg.dbName)
+			m.sidebar.SetFavorites(m.cfg.FavoriteTables(connKey(m.db)))
 			m.changes.Clear()
 			m.lastTable = ""
 			m.results.Clear()
 			m.statusbar.SetMessage(fmt.Sprintf("Switched to %s (%d tables)", msg.dbName, len(msg.tables)), ui.MsgSuccess)
+			return m, tea.Batch(m.loadServerInfo(), m.loadTableComments())
 		}
 		return m, nil
 
-	case ui.TableSelectedMsg:
-		m.lastTable = msg.Name
-		return m, m.loadTable(msg.Name)
+	case ui.FavoritesChangedMsg:
+		m.cfg.SetFavoriteTables(connKey(m.db), msg.Tables)
+		if err := m.cfg.Save(); err != nil {
+			m.statusbar.SetMessage("Could not save favorites: "+err.Error(), ui.MsgError)
+		}
+		return m, nil
+
+	case tableCommentsMsg:
+		// Comments are only shown in passing, so a failure keeps the old ones.
+		if msg.err == nil {
+			m.sidebar.SetComments(msg.comments)
+		}
+		return m, nil
+
+	case serverInfoMsg:
+		// A failure here only affects the top bar, so keep the last known values.
+		if msg.err == nil {
+			m.serverVersion = msg.version
+			m.currentUser = msg.currentUser
+		}
+		return m, nil
+
+	case ui.TableSelectedMsg:
+		m.lastTable = msg.Name
+		return m, m.loadTable(msg.Name)
 
 	case tableDataMsg:
-		if msg.err != nil {
+		if msg.err != nil && msg.reference != "" {
+			// Keep the current grid; only the hop failed.
+			m.statusbar.SetMessage(fmt.Sprintf("Cannot follow %s: %v", msg.reference, msg.err), ui.MsgError)
+		} else if msg.err != nil {
 			m.results.SetError(msg.err.Error())
 			m.statusbar.SetMessage("Error: "+msg.err.Error(), ui.MsgError)
 		} else {
-			m.results.SetData(msg.result.Columns, msg.result.ColumnTypes, msg.result.Rows)
-			m.results.SetTableContext(msg.tableName, msg.pks)
-			if m.pendingDMLMsg != "" {
+			m.results.SetData(msg.result.Columns, msg.result.ColumnTypes, msg.result.Rows, msg.result.RawRows, msg.result.Nulls)
+			m.results.SetTableContext(msg.tableName, msg.pks, msg.columns)
+			m.limitedSQL = ""
+			if msg.restore != nil {
+				m.results.RestorePosition(*msg.restore)
+			}
+			if msg.reference != "" {
+				m.lastTable = msg.tableName
+				m.sidebar.SelectTable(msg.tableName)
+				m.statusbar.SetMessage(fmt.Sprintf("Followed %s (%d rows)", msg.reference, msg.result.RowCount), ui.MsgSuccess)
+			} else if m.pendingDMLMsg != "" {
 				m.results.SetBanner(m.pendingDMLMsg)
 				m.statusbar.SetMessage(m.pendingDMLMsg, ui.MsgSuccess)
 				m.pendingDMLMsg = ""
@@ -351,48 +1095,169 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case ui.ExplainQueryMsg:
+		m.statusbar.SetMessage("Explaining: "+firstLine(msg.SQL), ui.MsgInfo)
+		return m, m.explainQuery(msg.SQL)
+
+	case diffResultMsg:
+		if msg.err != nil {
+			m.statusbar.SetMessage("Diff failed: "+msg.err.Error(), ui.MsgError)
+			return m, nil
+		}
+		m.diff.Open(msg.title, msg.diff)
+		m.statusbar.SetMessage("Diff: "+msg.diff.Summary(), ui.MsgInfo)
+		m.messages.Add(ui.LogResult, fmt.Sprintf("%s: %s", msg.title, msg.diff.Summary()))
+		return m, nil
+
+	case ui.RefreshActivityMsg:
+		return m, m.loadActivity()
+
+	case activityTickMsg:
+		if !m.activity.Visible() {
+			m.activityTicking = false
+			return m, nil
+		}
+		return m, tea.Batch(m.loadActivity(), activityTickCmd())
+
+	case activityMsg:
+		m.activityLoading = false
+		m.activity.SetActivity(msg.rows, msg.err)
+		return m, nil
+
+	case ui.SignalBackendMsg:
+		if m.db.ReadOnly() {
+			m.activity.SetStatus("Read-only mode: cancelling and terminating backends is turned off")
+			return m, nil
+		}
+		return m, m.signalBackend(msg.PID, msg.Terminate)
+
+	case readOnlyMsg:
+		if msg.err != nil {
+			m.statusbar.SetMessage("Could not change read-only mode: "+msg.err.Error(), ui.MsgError)
+			return m, nil
+		}
+		m.results.SetReadOnly(msg.on)
+		if msg.on {
+			m.statusbar.SetMessage("Read-only mode on: edits and writing statements are refused", ui.MsgInfo)
+		} else {
+			m.statusbar.SetMessage("Read-only mode off", ui.MsgInfo)
+		}
+		return m, nil
+
+	case backendSignalledMsg:
+		what := fmt.Sprintf("Cancelled the query of backend %d", msg.pid)
+		if msg.terminate {
+			what = fmt.Sprintf("Terminated backend %d", msg.pid)
+		}
+		switch {
+		case msg.err != nil:
+			m.activity.SetStatus("Failed: " + msg.err.Error())
+			m.messages.Add(ui.LogError, fmt.Sprintf("Signalling backend %d failed: %s", msg.pid, msg.err))
+		default:
+			m.activity.SetStatus(what)
+			m.messages.Add(ui.LogInfo, what)
+		}
+		return m, m.loadActivity()
+
+	case explainResultMsg:
+		m.connected = !m.db.IsClosed()
+		if msg.err != nil {
+			m.statusbar.SetMessage("Explain error: "+msg.err.Error(), ui.MsgError)
+			return m, nil
+		}
+		m.plan.Open(msg.sql, msg.plan)
+		if summary := ui.PlanSummary(msg.plan); summary != "" {
+			m.statusbar.SetMessage("Estimated "+summary, ui.MsgInfo)
+		}
+		return m, nil
+
 	case ui.ExecuteQueryMsg:
+		if m.needsRunConfirm(msg.SQL) {
+			return m, nil
+		}
 		m.lastSQL = msg.SQL
 		return m, m.executeQuery(msg.SQL)
 
 	case ddlRefreshMsg:
 		if msg.err != nil {
 			m.statusbar.SetMessage("DDL refresh error: "+msg.err.Error(), ui.MsgError)
+			m.messages.Add(ui.LogError, "DDL refresh error: "+msg.err.Error())
 		} else {
+			existed := slices.Contains(m.sidebar.Tables(), msg.tableName)
+			m.messages.Add(ui.LogInfo, tableListChange(m.sidebar.Tables(), msg.tables))
 			m.sidebar.SetTables(msg.tables)
 			m.editor.SetTableNames(msg.tables)
 			if msg.tableData != nil && msg.tableData.err == nil {
 				m.lastTable = msg.tableName
-				m.results.SetData(msg.tableData.result.Columns, msg.tableData.result.ColumnTypes, msg.tableData.result.Rows)
-				m.results.SetTableContext(msg.tableData.tableName, msg.tableData.pks)
+				m.results.SetData(msg.tableData.result.Columns, msg.tableData.result.ColumnTypes, msg.tableData.result.Rows, msg.tableData.result.RawRows, msg.tableData.result.Nulls)
+				m.results.SetTableContext(msg.tableData.tableName, msg.tableData.pks, msg.tableData.columns)
 				m.statusbar.SetQueryInfo(msg.tableData.result.ExecTime, msg.tableData.result.RowCount)
-				m.statusbar.SetMessage(fmt.Sprintf("Created table %s", msg.tableName), ui.MsgSuccess)
+				if existed {
+					m.statusbar.SetMessage(fmt.Sprintf("Altered table %s", msg.tableName), ui.MsgSuccess)
+				} else {
+					m.statusbar.SetMessage(fmt.Sprintf("Created table %s", msg.tableName), ui.MsgSuccess)
+				}
 			} else {
 				m.statusbar.SetMessage(fmt.Sprintf("Tables refreshed (%d tables)", len(msg.tables)), ui.MsgSuccess)
 			}
+			return m, m.loadTableComments()
 		}
 		return m, nil
 
 	case queryResultMsg:
+		m.connected = !m.db.IsClosed()
+		m.logQuery(msg)
+		m.statusbar.SetNotices(len(msg.notices))
 		if msg.err != nil {
-			m.results.SetError(msg.err.Error())
-			m.statusbar.SetMessage("Query error: "+msg.err.Error(), ui.MsgError)
+			errText := msg.err.Error()
+			// Point the editor at the part of the query the server objected to.
+			var pgErr *pgconn.PgError
+			if errors.As(msg.err, &pgErr) && pgErr.Position > 0 {
+				if line, col, ok := m.editor.MarkError(msg.lastSQL, int(pgErr.Position)); ok {
+					errText += fmt.Sprintf(" (line %d, column %d)", line, col)
+					m.focusPane(EditorPane)
+				}
+			}
+			m.results.SetError(errText)
+			m.statusbar.SetMessage("Query error: "+errText, ui.MsgError)
 		} else if msg.result != nil {
-			m.results.SetData(msg.result.Columns, msg.result.ColumnTypes, msg.result.Rows)
+			m.results.SetData(msg.result.Columns, msg.result.ColumnTypes, msg.result.Rows, msg.result.RawRows, msg.result.Nulls)
 			// Use extracted table context so free-form SELECTs are still editable
-			m.results.SetTableContext(msg.tableName, msg.pks)
+			m.results.SetTableContext(msg.tableName, msg.pks, msg.columns)
+			m.results.SetEditBlockedReason(msg.readOnly)
 			if msg.tableName != "" {
 				m.lastTable = msg.tableName
 			}
 			m.statusbar.SetQueryInfo(msg.result.ExecTime, msg.result.RowCount)
-			m.statusbar.SetMessage(fmt.Sprintf("Query returned %d rows", msg.result.RowCount), ui.MsgSuccess)
+			m.limitedSQL = ""
+			if msg.limited > 0 {
+				m.limitedSQL = msg.lastSQL
+				m.results.SetBanner(fmt.Sprintf("Showing the first %d rows (automatic LIMIT) — %s to fetch them all",
+					msg.limited, ui.KeyLabel(ui.ActionRunUnlimited)))
+			}
+			if msg.readOnly != "" {
+				m.statusbar.SetMessage(fmt.Sprintf("Query returned %d rows (read-only: %s)", msg.result.RowCount, msg.readOnly), ui.MsgInfo)
+			} else {
+				m.statusbar.SetMessage(fmt.Sprintf("Query returned %d rows", msg.result.RowCount), ui.MsgSuccess)
+			}
 		} else if msg.execRes != nil {
 			m.statusbar.SetQueryInfo(msg.execRes.ExecTime, int(msg.execRes.RowsAffected))
-			m.statusbar.SetMessage(fmt.Sprintf("%d rows affected", msg.execRes.RowsAffected), ui.MsgSuccess)
+			summary := execSummary(msg.execRes)
+			m.statusbar.SetMessage(summary, ui.MsgSuccess)
 
+			if isComment(msg.lastSQL) {
+				m.statusbar.SetMessage("Comment set", ui.MsgSuccess)
+				return m, m.loadTableComments()
+			}
+			if index, table := createdIndex(msg.lastSQL); index != "" {
+				m.statusbar.SetMessage(fmt.Sprintf("Created index %s on %s", index, table), ui.MsgSuccess)
+				m.messages.Add(ui.LogInfo, fmt.Sprintf("Created index %s on %s", index, table))
+				return m, nil
+			}
 			if ddlTable := extractDDLTableName(msg.lastSQL); ddlTable != "" {
-				isCreate := isCreateTable(msg.lastSQL)
-				return m, m.refreshAfterDDL(ddlTable, isCreate)
+				// A new table is opened; an altered one reloaded if it is showing.
+				load := isCreateTable(msg.lastSQL) || (ddlTable == m.lastTable && m.results.GetInsertedRowValues() == nil)
+				return m, m.refreshAfterDDL(ddlTable, load)
 			}
 
 			table := m.lastTable
@@ -400,39 +1265,59 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				table = extractTableName(msg.lastSQL)
 			}
 			if table != "" {
-				m.pendingDMLMsg = fmt.Sprintf("✓ %d rows affected", msg.execRes.RowsAffected)
+				m.pendingDMLMsg = "✓ " + summary
 				m.lastTable = table
-				return m, m.loadTable(table)
+				return m, m.reloadTable(table)
 			}
-			m.results.SetInfo(fmt.Sprintf("%d rows affected", msg.execRes.RowsAffected))
+			m.results.SetInfo(summary)
 		}
 		return m, nil
 
 	case commitResultMsg:
 		if msg.err != nil {
 			m.statusbar.SetMessage("Commit failed: "+msg.err.Error(), ui.MsgError)
+			m.messages.Add(ui.LogError, "Commit failed: "+msg.err.Error())
+			if msg.failed != nil && msg.failed.PKValues != nil {
+				m.results.SelectRow(msg.failed.TableName, msg.failed.PKValues, msg.failed.ColumnName)
+			}
 		} else {
 			m.statusbar.SetMessage(fmt.Sprintf("Committed %d changes", msg.count), ui.MsgSuccess)
+			m.messages.Add(ui.LogResult, fmt.Sprintf("Committed %d changes", msg.count))
 			m.changes.Clear()
 			// Refresh the current table if we were browsing one
 			if m.lastTable != "" {
-				return m, m.loadTable(m.lastTable)
+				return m, m.reloadTable(m.lastTable)
 			}
 		}
 		return m, nil
 
+	case changeScriptMsg:
+		switch {
+		case msg.err != nil:
+			m.statusbar.SetMessage("Cannot write the change script: "+msg.err.Error(), ui.MsgError)
+		case msg.path != "":
+			done := fmt.Sprintf("Wrote the SQL for %d statements to %s", msg.statements, msg.path)
+			m.statusbar.SetMessage(done, ui.MsgSuccess)
+			m.messages.Add(ui.LogResult, done)
+		default:
+			m.statusbar.SetMessage(fmt.Sprintf("Copied the SQL for %d statements to the clipboard", msg.statements), ui.MsgSuccess)
+		}
+		return m, nil
+
 	case reconnectResultMsg:
 		if msg.err != nil {
 			m.statusbar.SetMessage("Reconnect failed: "+msg.err.Error(), ui.MsgError)
 		} else {
+			m.connected = true
 			m.sidebar.SetTables(msg.tables)
 			m.editor.SetTableNames(msg.tables)
 			m.changes.Clear()
 			m.statusbar.SetMessage(fmt.Sprintf("Reconnected (%d tables)", len(msg.tables)), ui.MsgSuccess)
 			// Reload active table if one was selected
 			if m.lastTable != "" {
-				return m, m.loadTable(m.lastTable)
+				return m, tea.Batch(m.reloadTable(m.lastTable), m.loadServerInfo(), m.loadTableComments())
 			}
+			return m, tea.Batch(m.loadServerInfo(), m.loadTableComments())
 		}
 		return m, nil
 	}
@@ -446,9 +1331,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case EditorPane:
 		m.editor, cmd = m.editor.Update(msg)
 	case ResultsPane:
+		if m.messages.Visible() {
+			m.messages, cmd = m.messages.Update(msg)
+			break
+		}
 		m.results, cmd = m.results.Update(msg)
 		m.statusbar.SetEditMode(m.results.IsEditing())
-		m.statusbar.SetSearchMode(m.results.IsSearching())
+		m.statusbar.SetSearchMode(m.results.IsSearching() && !m.results.IsJumpingColumn() && !m.results.IsNamingTable())
 	}
 
 	return m, cmd
@@ -461,36 +1350,41 @@ func (m Model) View() string {
 	}
 
 	// Top bar
+	indicator := ui.ConnectedIndicator.Render("●")
+	if !m.connected {
+		indicator = ui.DisconnectedIndicator.Render("● disconnected")
+	} else if m.latency > 0 {
+		indicator += ui.TopBarText.Render(" ") + latencyLabel(m.latency)
+	}
+	info := m.db.ConnInfo()
+	if server := m.serverSummary(); server != "" {
+		info += "  " + server
+	}
+	badge := ""
+	if m.db.ReadOnly() {
+		badge = ui.ReadOnlyBadge.Render(" READ ONLY ") + ui.TopBarText.Render(" ")
+	}
 	topBar := ui.TopBarStyle.Width(m.width - 2).Render(
-		fmt.Sprintf(" %s ", m.db.ConnInfo()),
+		m.connectionTabs() + badge + ui.TopBarText.Render(" ") + indicator + ui.TopBarText.Render(fmt.Sprintf(" %s ", info)),
 	)
 
 	// Layout: sidebar on left, editor+results stacked on right
-	sidebarW := 30
-	rightW := m.width - sidebarW - 1
+	m.recalcLayout()
 
-	availH := m.height - 3 // top bar + status bar + spacing
-	if availH < 6 {
-		availH = 6
-	}
-
-	editorH := availH * 40 / 100
-	if editorH < 5 {
-		editorH = 5
+	var mainArea string
+	if m.zoomed {
+		switch m.activePane {
+		case SidebarPane:
+			mainArea = m.sidebar.View()
+		case EditorPane:
+			mainArea = m.editor.View()
+		case ResultsPane:
+			mainArea = m.resultsView()
+		}
+	} else {
+		rightPane := lipgloss.JoinVertical(lipgloss.Left, m.editor.View(), m.resultsView())
+		mainArea = lipgloss.JoinHorizontal(lipgloss.Top, m.sidebar.View(), rightPane)
 	}
-	resultsH := availH - editorH
-
-	m.sidebar.SetSize(sidebarW, availH)
-	m.editor.SetSize(rightW, editorH)
-	m.results.SetSize(rightW, resultsH)
-	m.statusbar.SetWidth(m.width)
-
-	sidebarView := m.sidebar.View()
-	editorView := m.editor.View()
-	resultsView := m.results.View()
-
-	rightPane := lipgloss.JoinVertical(lipgloss.Left, editorView, resultsView)
-	mainArea := lipgloss.JoinHorizontal(lipgloss.Top, sidebarView, rightPane)
 
 	statusView := m.statusbar.View()
 
@@ -498,34 +1392,290 @@ func (m Model) View() string {
 		m.scriptsModal.SetSize(m.width, m.height)
 		return m.scriptsModal.View()
 	}
+	if m.chooser.Visible() {
+		m.chooser.SetSize(m.width, m.height)
+		return m.chooser.View()
+	}
+	if m.help.Visible() {
+		m.help.SetSize(m.width, m.height)
+		return m.help.View()
+	}
+	if m.plan.Visible() {
+		m.plan.SetSize(m.width, m.height)
+		return m.plan.View()
+	}
+	if m.diff.Visible() {
+		m.diff.SetSize(m.width, m.height)
+		return m.diff.View()
+	}
+	if m.columns.Visible() {
+		m.columns.SetSize(m.width, m.height)
+		return m.columns.View()
+	}
+	if m.profile.Visible() {
+		m.profile.SetSize(m.width, m.height)
+		return m.profile.View()
+	}
+	if m.pending.Visible() {
+		m.pending.SetSize(m.width, m.height)
+		return m.pending.View()
+	}
+	if m.activity.Visible() {
+		m.activity.SetSize(m.width, m.height)
+		return m.activity.View()
+	}
 
 	return lipgloss.JoinVertical(lipgloss.Left, topBar, mainArea, statusView)
 }
 
-func (m *Model) cycleFocus(forward bool) {
-	m.sidebar.SetFocused(false)
-	m.editor.SetFocused(false)
-	m.results.SetFocused(false)
+// resultsView renders the message log when it is open, else the results.
+func (m Model) resultsView() string {
+	if m.messages.Visible() {
+		return m.messages.View()
+	}
+	return m.results.View()
+}
+
+// logQuery adds a finished statement to the message log: the statement,
+// any server notices, then its command tags or error.
+func (m *Model) logQuery(msg queryResultMsg) {
+	m.messages.Add(ui.LogQuery, firstLine(msg.lastSQL))
+	for _, n := range msg.notices {
+		kind := ui.LogNotice
+		if n.Severity == "WARNING" {
+			kind = ui.LogWarning
+		}
+		m.messages.Add(kind, n.String())
+	}
+	switch {
+	case msg.err != nil:
+		m.messages.Add(ui.LogError, "ERROR:  "+msg.err.Error())
+	case msg.result != nil:
+		m.logTimed(fmt.Sprintf("SELECT %d", msg.result.RowCount), msg.result.ExecTime)
+	case msg.execRes != nil:
+		tags := strings.Join(msg.execRes.Tags, "\n")
+		if tags == "" {
+			tags = fmt.Sprintf("%d rows affected", msg.execRes.RowsAffected)
+		}
+		m.logTimed(tags, msg.execRes.ExecTime)
+	}
+}
+
+// logTimed adds the outcome of a statement that took elapsed to the message
+// log, as a warning if that is over the slow query threshold.
+func (m *Model) logTimed(text string, elapsed time.Duration) {
+	kind := ui.LogResult
+	suffix := fmt.Sprintf(" (%s)", elapsed.Round(time.Millisecond))
+	if slow := m.cfg.SlowQuery(); slow > 0 && elapsed > slow {
+		kind = ui.LogWarning
+		suffix = fmt.Sprintf(" (%s, slow: over %s)", elapsed.Round(time.Millisecond), slow)
+	}
+	m.messages.Add(kind, text+suffix)
+}
+
+// execSummary describes a finished non-SELECT by its command tag, as psql
+// does: "INSERT 0 3", "CREATE INDEX". A script of several statements shows
+// the last one's tag and how many ran.
+func execSummary(er *db.ExecResult) string {
+	switch n := len(er.Tags); n {
+	case 0:
+		return fmt.Sprintf("%d rows affected", er.RowsAffected)
+	case 1:
+		return er.Tags[0]
+	default:
+		return fmt.Sprintf("%s (%d statements)", er.Tags[n-1], n)
+	}
+}
 
+// tableListChange describes how the table list went from old to tables.
+func tableListChange(old, tables []string) string {
+	had := make(map[string]bool, len(old))
+	for _, t := range old {
+		had[t] = true
+	}
+	var added, removed []string
+	for _, t := range tables {
+		if !had[t] {
+			added = append(added, t)
+		}
+		delete(had, t)
+	}
+	for _, t := range old {
+		if had[t] {
+			removed = append(removed, t)
+		}
+	}
+
+	var parts []string
+	if len(added) > 0 {
+		parts = append(parts, "added "+strings.Join(added, ", "))
+	}
+	if len(removed) > 0 {
+		parts = append(parts, "dropped "+strings.Join(removed, ", "))
+	}
+	if len(parts) == 0 {
+		parts = append(parts, "no change")
+	}
+	return fmt.Sprintf("Tables refreshed (%d tables): %s", len(tables), strings.Join(parts, "; "))
+}
+
+// needsRunConfirm holds back sql if it updates or deletes every row of a
+// table, or selects from two tables nothing relates, asking for
+// confirmation first. It reports whether it did. Read-only mode refuses the
+// writes anyway.
+func (m *Model) needsRunConfirm(sql string) bool {
+	if table := unfilteredWrite(sql); table != "" && !m.db.ReadOnly() {
+		m.confirmRunSQL = sql
+		m.statusbar.SetMessage(fmt.Sprintf("This will affect ALL rows in %s — continue? (y/n)", table), ui.MsgError)
+		return true
+	}
+	if a, b := crossJoinedTables(sql); a != "" {
+		m.confirmRunSQL = sql
+		m.statusbar.SetMessage(fmt.Sprintf("Nothing joins %s and %s: every combination of their rows — run anyway? (y/n)", a, b), ui.MsgError)
+		return true
+	}
+	return false
+}
+
+// needsCommitConfirm holds back a commit that would delete more rows than
+// the configured threshold, asking for confirmation first. It reports
+// whether it did.
+func (m *Model) needsCommitConfirm() bool {
+	deletes, loaded := len(m.changes.Deletes), m.results.LoadedRows()
+	if !m.cfg.DeleteNeedsConfirm(deletes, loaded) {
+		return false
+	}
+	m.confirmCommit = true
+	m.statusbar.SetMessage(fmt.Sprintf("This commit deletes %d rows (%d loaded) — continue? (y/n)", deletes, loaded), ui.MsgError)
+	return true
+}
+
+// uncommittedCount returns the number of staged edits, deletes and inserted
+// rows, over every open connection, that quitting now would discard.
+func (m Model) uncommittedCount() int {
+	n := 0
+	for i, s := range m.sessions {
+		if i == m.current {
+			s = m.session
+		}
+		n += s.changes.PendingCount() + len(s.results.GetInsertedRowValues())
+	}
+	return n
+}
+
+// inputFocused reports whether the focused pane is taking text: the editor,
+// or a search, edit or preview in the other panes.
+func (m Model) inputFocused() bool {
+	switch m.activePane {
+	case EditorPane:
+		return true
+	case ResultsPane:
+		return m.results.IsEditing() || m.results.IsSearching() || m.results.IsPreviewing()
+	case SidebarPane:
+		return m.sidebar.IsSearching()
+	}
+	return false
+}
+
+// handleMouse focuses the pane under a click and forwards the event to it
+// with coordinates relative to that pane.
+func (m Model) handleMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	if m.scriptsModal.Visible() || m.chooser.Visible() || m.help.Visible() || m.plan.Visible() || m.diff.Visible() || m.columns.Visible() || m.profile.Visible() || m.pending.Visible() || m.activity.Visible() || m.confirmClearEdits || m.confirmQuit || m.confirmRunSQL != "" || m.confirmCommit {
+		return m, nil
+	}
+	pane, x, y, ok := m.paneAt(msg.X, msg.Y)
+	if !ok {
+		return m, nil
+	}
+	if pane != m.activePane {
+		// Only a click moves focus, and not out of a pane that is mid-input.
+		if msg.Button != tea.MouseButtonLeft || msg.Action != tea.MouseActionPress {
+			return m, nil
+		}
+		if m.activePane == ResultsPane && (m.results.IsEditing() || m.results.IsSearching() || m.results.IsPreviewing()) {
+			return m, nil
+		}
+		if m.activePane == SidebarPane && m.sidebar.IsSearching() {
+			return m, nil
+		}
+		m.focusPane(pane)
+	}
+
+	msg.X, msg.Y = x, y
+	var cmd tea.Cmd
+	switch pane {
+	case SidebarPane:
+		m.sidebar, cmd = m.sidebar.Update(msg)
+	case ResultsPane:
+		if m.messages.Visible() {
+			m.messages, cmd = m.messages.Update(msg)
+		} else {
+			m.results, cmd = m.results.Update(msg)
+		}
+	}
+	return m, cmd
+}
+
+// paneAt returns the pane drawn at screen position x, y and the position
+// relative to that pane's top-left corner, following the layout in View.
+func (m Model) paneAt(x, y int) (Pane, int, int, bool) {
+	availH := m.height - 3
+	if availH < 6 {
+		availH = 6
+	}
+	y-- // top bar
+	if y < 0 || y >= availH {
+		return 0, 0, 0, false
+	}
+	if m.zoomed {
+		return m.activePane, x, y, true
+	}
+
+	sidebarW := 30
+	if x < sidebarW {
+		return SidebarPane, x, y, true
+	}
+	editorH := availH * 40 / 100
+	if editorH < 5 {
+		editorH = 5
+	}
+	if y < editorH {
+		return EditorPane, x - sidebarW, y, true
+	}
+	return ResultsPane, x - sidebarW, y - editorH, true
+}
+
+func (m *Model) cycleFocus(forward bool) {
+	next := m.activePane
 	if forward {
 		switch m.activePane {
 		case SidebarPane:
-			m.activePane = EditorPane
+			next = EditorPane
 		case EditorPane:
-			m.activePane = ResultsPane
+			next = ResultsPane
 		case ResultsPane:
-			m.activePane = SidebarPane
+			next = SidebarPane
 		}
 	} else {
 		switch m.activePane {
 		case SidebarPane:
-			m.activePane = ResultsPane
+			next = ResultsPane
 		case EditorPane:
-			m.activePane = SidebarPane
+			next = SidebarPane
 		case ResultsPane:
-			m.activePane = EditorPane
+			next = EditorPane
 		}
 	}
+	m.focusPane(next)
+}
+
+// focusPane moves keyboard focus to pane.
+func (m *Model) focusPane(pane Pane) {
+	m.sidebar.SetFocused(false)
+	m.editor.SetFocused(false)
+	m.results.SetFocused(false)
+	m.messages.SetFocused(false)
+	m.activePane = pane
 
 	switch m.activePane {
 	case SidebarPane:
@@ -536,9 +1686,13 @@ func (m *Model) cycleFocus(forward bool) {
 		m.statusbar.SetActivePane(1)
 	case ResultsPane:
 		m.results.SetFocused(true)
+		m.messages.SetFocused(true)
 		m.statusbar.SetActivePane(2)
 	}
 	m.statusbar.SetEditMode(false)
+	if m.zoomed {
+		m.recalcLayout()
+	}
 }
 
 func (m *Model) recalcLayout() {
@@ -557,28 +1711,139 @@ func (m *Model) recalcLayout() {
 	}
 	resultsH := availH - editorH
 
+	if m.zoomed {
+		// Only the focused pane is shown, so give it the whole area.
+		fullW := sidebarW + rightW
+		switch m.activePane {
+		case SidebarPane:
+			m.sidebar.SetSize(fullW, availH)
+		case EditorPane:
+			m.editor.SetSize(fullW, availH)
+		case ResultsPane:
+			m.results.SetSize(fullW, availH)
+			m.messages.SetSize(fullW, availH)
+		}
+		m.statusbar.SetWidth(m.width)
+		return
+	}
+
 	m.sidebar.SetSize(sidebarW, availH)
 	m.editor.SetSize(rightW, editorH)
 	m.results.SetSize(rightW, resultsH)
+	m.messages.SetSize(rightW, resultsH)
 	m.statusbar.SetWidth(m.width)
 }
 
+// explainQuery fetches the estimated plan of sql without running it.
+func (m *Model) explainQuery(sql string) tea.Cmd {
+	return func() tea.Msg {
+		plan, err := m.db.Explain(sql)
+		return explainResultMsg{sql: sql, plan: plan, err: err}
+	}
+}
+
+// loadActivity reads pg_stat_activity for the activity overlay, unless a
+// reading is already on its way.
+func (m *Model) loadActivity() tea.Cmd {
+	if m.activityLoading {
+		return nil
+	}
+	m.activityLoading = true
+	d := m.db
+	return func() tea.Msg {
+		rows, err := d.ListActivity()
+		return activityMsg{rows: rows, err: err}
+	}
+}
+
+func activityTickCmd() tea.Cmd {
+	return tea.Tick(activityInterval, func(time.Time) tea.Msg {
+		return activityTickMsg{}
+	})
+}
+
+// refuseReadOnly reports whether read-only mode rules out what, telling the
+// user so.
+func (m *Model) refuseReadOnly(what string) bool {
+	if !m.db.ReadOnly() {
+		return false
+	}
+	m.statusbar.SetMessage("Read-only mode: "+what+" is turned off", ui.MsgError)
+	return true
+}
+
+// setReadOnly turns read-only mode on or off for the connection.
+func (m *Model) setReadOnly(on bool) tea.Cmd {
+	return func() tea.Msg {
+		return readOnlyMsg{on: on, err: m.db.SetReadOnly(on)}
+	}
+}
+
+// signalBackend cancels the query of backend pid, or terminates it.
+func (m *Model) signalBackend(pid int32, terminate bool) tea.Cmd {
+	return func() tea.Msg {
+		var err error
+		if terminate {
+			err = m.db.TerminateBackend(pid)
+		} else {
+			err = m.db.CancelBackend(pid)
+		}
+		return backendSignalledMsg{pid: pid, terminate: terminate, err: err}
+	}
+}
+
 func (m *Model) executeQuery(sql string) tea.Cmd {
+	return m.executeQueryLimit(sql, m.autoLimit)
+}
+
+// executeQueryLimit runs sql, capping a SELECT without a LIMIT at limit rows
+// unless limit is 0.
+func (m *Model) executeQueryLimit(sql string, limit int) tea.Cmd {
 	return func() tea.Msg {
-		queryRes, execRes, err := m.db.ExecuteQuery(sql)
+		if m.db.ReadOnly() {
+			if kw := writingStatement(sql); kw != "" {
+				return queryResultMsg{err: fmt.Errorf("read-only mode: %s statements are not allowed", kw), lastSQL: sql}
+			}
+		}
+		run := sql
+		limited := false
+		if limit > 0 {
+			// One extra row tells whether anything was cut off.
+			run, limited = withAutoLimit(sql, limit+1)
+		}
+		m.db.TakeNotices() // left over from earlier statements
+		queryRes, execRes, err := m.db.ExecuteQuery(run, isReadOnlyQuery(run))
 		msg := queryResultMsg{
 			result:  queryRes,
 			execRes: execRes,
 			err:     err,
 			lastSQL: sql,
+			notices: m.db.TakeNotices(),
+		}
+		if limited && queryRes != nil && queryRes.RowCount > limit {
+			queryRes.Rows = queryRes.Rows[:limit]
+			queryRes.RawRows = queryRes.RawRows[:limit]
+			queryRes.Nulls = queryRes.Nulls[:limit]
+			queryRes.RowCount = limit
+			msg.limited = limit
 		}
 		// For SELECT results, try to extract the table name and look up PKs
 		// so that free-form queries like "SELECT * FROM users" are still editable.
 		if queryRes != nil && err == nil {
-			if table := extractTableName(sql); table != "" {
-				msg.tableName = table
-				if pks, pkErr := m.db.GetPrimaryKeys(table); pkErr == nil {
+			if joinsMultipleTables(sql) {
+				msg.readOnly = "joins multiple tables"
+			} else if reason := rowsNotAddressable(sql); reason != "" {
+				msg.readOnly = reason
+			} else if table := extractTableName(sql); table != "" {
+				pks, pkErr := m.db.GetPrimaryKeys(table)
+				if pkErr == nil && !m.selectsKey(queryRes, table, pks) {
+					// Rows without their key can't be told apart to update.
+					msg.readOnly = fmt.Sprintf("select the primary key (%s) to enable edits", strings.Join(pks, ", "))
+				} else {
+					msg.tableName = table
 					msg.pks = pks
+					// Column metadata only refines inserts, so a failure is ignored.
+					msg.columns, _ = m.db.GetColumns(table)
 				}
 			}
 		}
@@ -586,22 +1851,219 @@ func (m *Model) executeQuery(sql string) tea.Cmd {
 	}
 }
 
+// selectsKey reports whether res holds the key columns pks of table as
+// read from it, rather than through columns merely named like them, such
+// as "name AS id". A column the server reports as computed never counts.
+func (m *Model) selectsKey(res *db.QueryResult, table string, pks []string) bool {
+	if len(pks) == 0 {
+		return true
+	}
+	if !hasColumns(res.Columns, pks) {
+		return false
+	}
+	sources, err := m.db.ColumnSources(table, pks)
+	if err != nil {
+		return false
+	}
+	return keyColumnsFrom(res, pks, sources)
+}
+
+// keyColumnsFrom reports whether every column of res named after one of
+// pks was read from that key column, at sources, and each key is there.
+func keyColumnsFrom(res *db.QueryResult, pks []string, sources map[string]db.ColumnSource) bool {
+	found := make(map[string]bool, len(pks))
+	for i, col := range res.Columns {
+		want, ok := sources[col]
+		if !ok || !slices.Contains(pks, col) {
+			continue
+		}
+		if i >= len(res.Sources) || res.Sources[i] != want {
+			return false
+		}
+		found[col] = true
+	}
+	return len(found) == len(pks)
+}
+
+// browseQuery selects the rows of table shown when it is opened.
+func browseQuery(table string) string {
+	return fmt.Sprintf(`SELECT * FROM %s LIMIT 100`, db.QuoteIdentifier(table))
+}
+
 func (m *Model) loadTable(tableName string) tea.Cmd {
 	return func() tea.Msg {
 		pks, err := m.db.GetPrimaryKeys(tableName)
 		if err != nil {
 			return tableDataMsg{err: err}
 		}
-		sql := fmt.Sprintf(`SELECT * FROM %q LIMIT 100`, tableName)
-		qr, _, err := m.db.ExecuteQuery(sql)
+		qr, _, err := m.db.ExecuteQuery(browseQuery(tableName), true)
 		if err != nil {
 			return tableDataMsg{err: err}
 		}
+		columns, _ := m.db.GetColumns(tableName)
 		return tableDataMsg{
 			result:    qr,
 			tableName: tableName,
 			pks:       pks,
+			columns:   columns,
+		}
+	}
+}
+
+// reloadTable is loadTable for refreshing the table on screen: the cursor
+// is put back on the same row afterwards instead of at the top.
+func (m *Model) reloadTable(tableName string) tea.Cmd {
+	pos := m.results.Position()
+	load := m.loadTable(tableName)
+	return func() tea.Msg {
+		msg := load().(tableDataMsg)
+		msg.restore = &pos
+		return msg
+	}
+}
+
+// followReference loads the rows referenced by the foreign key on msg.Column.
+func (m *Model) followReference(msg ui.FollowReferenceMsg) tea.Cmd {
+	ref := fmt.Sprintf("%s.%s", msg.TableName, msg.Column)
+	return func() tea.Msg {
+		fks, err := m.db.GetForeignKeys(msg.TableName)
+		if err != nil {
+			return tableDataMsg{err: fmt.Errorf("foreign keys: %w", err), reference: ref}
+		}
+		var fk *db.ForeignKey
+		for i := range fks {
+			for _, col := range fks[i].Columns {
+				if col == msg.Column {
+					fk = &fks[i]
+				}
+			}
+		}
+		if fk == nil {
+			return tableDataMsg{err: fmt.Errorf("not a foreign key column"), reference: ref}
+		}
+
+		vals := make([]interface{}, len(fk.Columns))
+		for i, col := range fk.Columns {
+			if msg.Row[col] == nil {
+				return tableDataMsg{err: fmt.Errorf("%s is NULL, so it references nothing", col), reference: ref}
+			}
+			vals[i] = msg.Row[col]
 		}
+		return m.loadMatchingRows(fk.RefTable, fk.RefColumns, vals, fmt.Sprintf("%s → %s", ref, fk.RefTable))
+	}
+}
+
+// findReferencing looks up the foreign keys that reference msg.Column of
+// msg.TableName.
+func (m *Model) findReferencing(msg ui.ShowReferencingMsg) tea.Cmd {
+	return func() tea.Msg {
+		fks, err := m.db.GetReferencingKeys(msg.TableName)
+		if err != nil {
+			return referencingKeysMsg{source: msg, err: fmt.Errorf("foreign keys: %w", err)}
+		}
+		var matching []db.ForeignKey
+		for _, fk := range fks {
+			for _, col := range fk.RefColumns {
+				if col == msg.Column {
+					matching = append(matching, fk)
+					break
+				}
+			}
+		}
+		return referencingKeysMsg{source: msg, fks: matching}
+	}
+}
+
+// loadReferencing loads the rows of fk's table that reference the source row.
+func (m *Model) loadReferencing(source ui.ShowReferencingMsg, fk db.ForeignKey) tea.Cmd {
+	return func() tea.Msg {
+		ref := fmt.Sprintf("%s.%s ← %s.%s", source.TableName, source.Column, fk.Table, strings.Join(fk.Columns, ", "))
+		vals := make([]interface{}, len(fk.RefColumns))
+		for i, col := range fk.RefColumns {
+			if source.Row[col] == nil {
+				return tableDataMsg{err: fmt.Errorf("%s is NULL", col), reference: ref}
+			}
+			vals[i] = source.Row[col]
+		}
+		return m.loadMatchingRows(fk.Table, fk.Columns, vals, ref)
+	}
+}
+
+// loadMatchingRows loads the rows of table whose cols equal vals, as the
+// destination of a foreign-key hop described by reference.
+func (m *Model) loadMatchingRows(table string, cols []string, vals []interface{}, reference string) tableDataMsg {
+	conds := make([]string, len(cols))
+	for i, col := range cols {
+		conds[i] = fmt.Sprintf("%q = $%d", col, i+1)
+	}
+	sql := fmt.Sprintf(`SELECT * FROM %s WHERE %s LIMIT 100`,
+		db.QuoteIdentifier(table), strings.Join(conds, " AND "))
+	qr, err := m.db.QueryArgs(sql, vals...)
+	if err != nil {
+		return tableDataMsg{err: err, reference: reference}
+	}
+	pks, err := m.db.GetPrimaryKeys(table)
+	if err != nil {
+		return tableDataMsg{err: err, reference: reference}
+	}
+	columns, _ := m.db.GetColumns(table)
+	return tableDataMsg{
+		result:    qr,
+		tableName: table,
+		pks:       pks,
+		columns:   columns,
+		reference: reference,
+	}
+}
+
+func (m *Model) loadTableComments() tea.Cmd {
+	return func() tea.Msg {
+		comments, err := m.db.ListTableComments()
+		return tableCommentsMsg{comments: comments, err: err}
+	}
+}
+
+func (m *Model) loadServerInfo() tea.Cmd {
+	return func() tea.Msg {
+		version, user, err := m.db.ServerInfo()
+		return serverInfoMsg{version: version, currentUser: user, err: err}
+	}
+}
+
+// serverSummary returns a compact "PG 16.2 as role" form of the server info
+// for the top bar, or "" if it hasn't been fetched yet.
+func (m Model) serverSummary() string {
+	if m.serverVersion == "" {
+		return ""
+	}
+	// version() looks like "PostgreSQL 16.2 (Debian 16.2-1) on x86_64-pc-linux-gnu, ..."
+	version := m.serverVersion
+	if fields := strings.Fields(version); len(fields) >= 2 && fields[0] == "PostgreSQL" {
+		version = "PG " + strings.TrimSuffix(fields[1], ",")
+	}
+	if m.currentUser == "" {
+		return version
+	}
+	return fmt.Sprintf("%s as %s", version, m.currentUser)
+}
+
+// latencyLabel shows the round trip of the last ping, in the error color
+// when it is slow.
+func latencyLabel(d time.Duration) string {
+	label := d.Round(time.Millisecond).String()
+	if d < time.Millisecond {
+		label = "<1ms"
+	}
+	if d > slowPing {
+		return ui.DisconnectedIndicator.Render(label)
+	}
+	return ui.TopBarText.Render(label)
+}
+
+func (m *Model) ping() tea.Cmd {
+	return func() tea.Msg {
+		latency, ok := m.db.Ping()
+		return pingResultMsg{ok: ok, latency: latency}
 	}
 }
 
@@ -618,39 +2080,214 @@ func (m *Model) reconnect() tea.Cmd {
 	}
 }
 
-func (m *Model) dropDatabase(name string) tea.Cmd {
-	wasActive := m.db.Database() == name
+// refuseBusyDatabase reports whether a database copy or drop is still
+// running, saying so; only one runs at a time.
+func (m *Model) refuseBusyDatabase() bool {
+	if m.dbOp == nil {
+		return false
+	}
+	m.statusbar.SetMessage(fmt.Sprintf("Wait for the %s of %s to finish, or cancel it (%s)",
+		m.dbOp.verb, m.dbOp.name, ui.KeyLabel(ui.ActionCancel)), ui.MsgError)
+	return true
+}
+
+// newDatabaseOp gives op the context its statement runs under.
+func newDatabaseOp(op *databaseOp) context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	op.cancel = cancel
+	return ctx
+}
+
+// settledDatabase returns the database the connection is on once an
+// operation that started on before is over, with its tables, or "" when it
+// is still on before.
+func (m *Model) settledDatabase(before string) (string, []string, error) {
+	now := m.db.Database()
+	if now == before || m.db.IsClosed() {
+		return "", nil, nil
+	}
+	tables, err := m.db.ListTables()
+	if err != nil {
+		return "", nil, fmt.Errorf("list tables: %w", err)
+	}
+	return now, tables, nil
+}
+
+// enterDatabase shows dbName, with tables, as the database the connection
+// is on after a copy or drop moved it there.
+func (m *Model) enterDatabase(dbName string, tables []string) tea.Cmd {
+	m.sidebar.SetActiveDatabase(dbName)
+	m.sidebar.SetTables(tables)
+	m.sidebar.SetFavorites(m.cfg.FavoriteTables(connKey(m.db)))
+	m.editor.SetTableNames(tables)
+	m.changes.Clear()
+	m.lastTable = ""
+	m.results.Clear()
+	return tea.Batch(m.loadServerInfo(), m.loadTableComments())
+}
+
+func (m *Model) dropDatabase(op *databaseOp) tea.Cmd {
+	ctx := newDatabaseOp(op)
+	before := m.db.Database()
 	return func() tea.Msg {
-		if err := m.db.DropDatabase(name); err != nil {
-			return dropDBResultMsg{err: fmt.Errorf("drop database: %w", err)}
+		defer op.cancel()
+		result := dropDBResultMsg{op: op, dropped: op.name}
+		if err := m.db.DropDatabase(ctx, op.name); err != nil {
+			result.err = fmt.Errorf("drop database: %w", err)
 		}
-		databases, err := m.db.ListDatabases()
-		if err != nil {
-			return dropDBResultMsg{err: fmt.Errorf("list databases: %w", err)}
+		var err error
+		if result.switchedToDB, result.tables, err = m.settledDatabase(before); err != nil && result.err == nil {
+			result.err = err
 		}
-		result := dropDBResultMsg{databases: databases, dropped: name}
-		if wasActive {
-			result.switchedToDB = m.db.Database()
-			tables, err := m.db.ListTables()
-			if err != nil {
-				return dropDBResultMsg{err: fmt.Errorf("list tables: %w", err)}
-			}
-			result.tables = tables
+		if result.err != nil {
+			return result
+		}
+		if result.databases, err = m.db.ListDatabases(); err != nil {
+			result.err = fmt.Errorf("list databases: %w", err)
 		}
 		return result
 	}
 }
 
-func (m *Model) copyDatabase(source, target string) tea.Cmd {
+func (m *Model) copyDatabase(op *databaseOp, source string) tea.Cmd {
+	ctx := newDatabaseOp(op)
+	before := m.db.Database()
 	return func() tea.Msg {
-		if err := m.db.CopyDatabase(source, target); err != nil {
-			return copyDBResultMsg{err: fmt.Errorf("copy database: %w", err)}
+		defer op.cancel()
+		result := copyDBResultMsg{op: op, target: op.name}
+		if err := m.db.CopyDatabase(ctx, source, op.name); err != nil {
+			result.err = fmt.Errorf("copy database: %w", err)
 		}
-		databases, err := m.db.ListDatabases()
+		var err error
+		if result.switchedToDB, result.tables, err = m.settledDatabase(before); err != nil && result.err == nil {
+			result.err = err
+		}
+		if result.err != nil {
+			return result
+		}
+		if result.databases, err = m.db.ListDatabases(); err != nil {
+			result.err = fmt.Errorf("list databases: %w", err)
+		}
+		return result
+	}
+}
+
+// exportProgress describes the running export for the status bar.
+func (m Model) exportProgress() string {
+	return fmt.Sprintf("Exporting %s to %s: %d rows… (%s cancel)",
+		m.exporting.table, m.exporting.path, m.exporting.rows.Load(), ui.KeyLabel(ui.ActionCancel))
+}
+
+func exportTickCmd() tea.Cmd {
+	return tea.Tick(250*time.Millisecond, func(time.Time) tea.Msg {
+		return exportTickMsg{}
+	})
+}
+
+// exportHandler passes streamed rows to the file writer, counting them, and
+// stops the stream once the export has been cancelled.
+type exportHandler struct {
+	sw     *export.StreamWriter
+	export *tableExport
+}
+
+func (h exportHandler) Columns(columns, columnTypes []string) error {
+	return h.sw.Columns(columns, columnTypes)
+}
+
+func (h exportHandler) Row(row []string, raw []interface{}) error {
+	if h.export.cancelled.Load() {
+		return errExportCancelled
+	}
+	if err := h.sw.Row(row, raw); err != nil {
+		return err
+	}
+	h.export.rows.Add(1)
+	return nil
+}
+
+var errExportCancelled = errors.New("export cancelled")
+
+// exportTable streams every row of e.table into the file at e.path, as
+// JSON if the name ends in .json and as CSV otherwise. It streams on a
+// connection of its own, so the session stays free for other work while it
+// runs, for as long as it takes unless e.cancel is called. The rows go to a
+// temporary file next to e.path that is renamed over it once complete, so
+// an error or cancellation leaves a file already there untouched.
+func (m *Model) exportTable(e *tableExport) tea.Cmd {
+	return func() tea.Msg {
+		defer e.cancel()
+		path := expandHome(e.path)
+		format := export.FormatCSV
+		if strings.EqualFold(filepath.Ext(path), ".json") {
+			format = export.FormatJSON
+		}
+		conn, err := e.db.Clone()
+		if err != nil {
+			return exportDoneMsg{export: e, err: fmt.Errorf("open a connection for the export: %w", err)}
+		}
+		defer conn.Close()
+
+		f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
 		if err != nil {
-			return copyDBResultMsg{err: fmt.Errorf("list databases: %w", err)}
+			return exportDoneMsg{export: e, err: err}
 		}
-		return copyDBResultMsg{databases: databases, target: target}
+		sw := export.NewStreamWriter(f, format)
+		err = conn.StreamAll(e.ctx, "SELECT * FROM "+db.QuoteIdentifier(e.table), exportHandler{sw: sw, export: e})
+		if err == nil {
+			err = sw.Close()
+		}
+		if err == nil {
+			err = f.Chmod(0o644)
+		}
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err == nil && !e.cancelled.Load() {
+			err = os.Rename(f.Name(), path)
+		}
+		if err != nil || e.cancelled.Load() {
+			os.Remove(f.Name())
+		}
+		return exportDoneMsg{export: e, err: err}
+	}
+}
+
+// countRows counts the rows of c.table exactly.
+func (m *Model) countRows(c *tableCount) tea.Cmd {
+	return func() tea.Msg {
+		n, err := c.db.CountRows(c.table)
+		return rowCountMsg{count: c, rows: n, err: err}
+	}
+}
+
+// cancelQuery asks the server to stop the statement running on d.
+func cancelQuery(d *db.DB) tea.Cmd {
+	return func() tea.Msg {
+		d.CancelQuery()
+		return nil
+	}
+}
+
+// expandHome replaces a leading ~/ in path with the home directory.
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return path
+}
+
+// importCSV loads the CSV file at path, where a leading ~ is the home
+// directory, into load.table, until done or load.cancel is called.
+func (m *Model) importCSV(load *tableImport, path string) tea.Cmd {
+	ctx, cancel := context.WithCancel(context.Background())
+	load.cancel = cancel
+	return func() tea.Msg {
+		defer cancel()
+		rows, err := m.db.CopyFromCSV(ctx, load.table, expandHome(path))
+		return importCSVResultMsg{load: load, table: load.table, rows: rows, err: err}
 	}
 }
 
@@ -671,44 +2308,205 @@ func (m *Model) switchDatabase(name string) tea.Cmd {
 	}
 }
 
-func (m *Model) commitChanges() tea.Cmd {
+// pendingChanges lists the staged changes and the rows inserted in the grid,
+// grouped by table in the order each table was first changed: edits, then
+// deletes, then inserts. Inserts are numbered across the ChangeTracker's
+// and then the grid's.
+func (m Model) pendingChanges() []ui.PendingChange {
+	var changes []ui.PendingChange
+	for _, e := range m.changes.Edits {
+		changes = append(changes, ui.PendingChange{Type: editor.OpEdit, Table: e.TableName, PK: e.RowPKValues,
+			Column: e.ColumnName, Old: e.OldValue, New: e.NewValue})
+	}
+	for _, d := range m.changes.Deletes {
+		changes = append(changes, ui.PendingChange{Type: editor.OpDelete, Table: d.TableName, PK: d.RowPKValues})
+	}
+	inserts := append(slices.Clone(m.changes.Inserts), m.results.GetInsertedRowValues()...)
+	for i, ins := range inserts {
+		order := ins.Columns
+		if order == nil {
+			order = slices.Sorted(maps.Keys(ins.Values))
+		}
+		changes = append(changes, ui.PendingChange{Type: editor.OpInsert, Table: ins.TableName, Values: ins.Values,
+			Order: order, Insert: i})
+	}
+
+	var tables []string
+	for _, c := range changes {
+		if !slices.Contains(tables, c.Table) {
+			tables = append(tables, c.Table)
+		}
+	}
+	slices.SortStableFunc(changes, func(a, b ui.PendingChange) int {
+		return slices.Index(tables, a.Table) - slices.Index(tables, b.Table)
+	})
+	return changes
+}
+
+// discardChange drops c from the staged changes, or the grid's inserted
+// rows, without touching the others.
+func (m *Model) discardChange(c ui.PendingChange) {
+	switch c.Type {
+	case editor.OpEdit:
+		m.changes.RemoveEdit(c.Table, c.PK, c.Column)
+	case editor.OpDelete:
+		m.changes.RemoveDelete(c.Table, c.PK)
+	case editor.OpInsert:
+		if n := len(m.changes.Inserts); c.Insert < n {
+			m.changes.RemoveInsert(c.Insert)
+		} else {
+			m.results.RemoveInsertedRow(c.Insert - n)
+		}
+	}
+}
+
+// changeRefs maps each table ct inserts into or deletes from to the others
+// of those tables its foreign keys reference, for GenerateOrderedSQL. A
+// single table needs no order, and no lookups.
+func changeRefs(d *db.DB, ct *editor.ChangeTracker) (map[string][]string, error) {
+	var tables []string
+	for _, ins := range ct.Inserts {
+		if !slices.Contains(tables, ins.TableName) {
+			tables = append(tables, ins.TableName)
+		}
+	}
+	for _, del := range ct.Deletes {
+		if !slices.Contains(tables, del.TableName) {
+			tables = append(tables, del.TableName)
+		}
+	}
+	if len(tables) < 2 {
+		return nil, nil
+	}
+	refs := make(map[string][]string)
+	for _, table := range tables {
+		fks, err := d.GetForeignKeys(table)
+		if err != nil {
+			return nil, fmt.Errorf("foreign keys of %s: %w", table, err)
+		}
+		for _, fk := range fks {
+			if fk.RefTable != table && slices.Contains(tables, fk.RefTable) && !slices.Contains(refs[table], fk.RefTable) {
+				refs[table] = append(refs[table], fk.RefTable)
+			}
+		}
+	}
+	return refs, nil
+}
+
+// stagedCopy copies the staged changes, together with the rows added
+// in the grid, which are only staged as inserts here. The copy is for a
+// command to generate SQL from off the UI goroutine, and leaves m.changes
+// as it is should the statements fail.
+func (m *Model) stagedCopy() *editor.ChangeTracker {
+	return &editor.ChangeTracker{
+		Edits:   slices.Clone(m.changes.Edits),
+		Deletes: slices.Clone(m.changes.Deletes),
+		Inserts: append(slices.Clone(m.changes.Inserts), m.results.GetInsertedRowValues()...),
+	}
+}
+
+// copyChangeScript puts the statements a commit would run, with their values
+// written in, on the clipboard as one transaction. Without a clipboard to
+// use, it writes them to changes-<time>.sql in the working directory. The
+// pending changes stay staged.
+func (m *Model) copyChangeScript() tea.Cmd {
+	pending := m.stagedCopy()
 	return func() tea.Msg {
-		// Stage any inserted rows from the results model
-		inserts := m.results.GetInsertedRowValues()
-		for _, ins := range inserts {
-			m.changes.StageInsert(ins)
+		refs, err := changeRefs(m.db, pending)
+		if err != nil {
+			return changeScriptMsg{err: err}
 		}
+		queries, allArgs, _ := pending.GenerateOrderedSQL(refs)
+		script := editor.Script(queries, allArgs)
+		if err := clipboard.WriteAll(script); err == nil {
+			return changeScriptMsg{statements: len(queries)}
+		}
+		path := fmt.Sprintf("changes-%s.sql", time.Now().Format("20060102-150405"))
+		err = os.WriteFile(path, []byte(script), 0o644)
+		return changeScriptMsg{statements: len(queries), path: path, err: err}
+	}
+}
 
-		queries, allArgs := m.changes.GenerateSQL()
+func (m *Model) commitChanges() tea.Cmd {
+	pending := m.stagedCopy()
+	return func() tea.Msg {
+		refs, err := changeRefs(m.db, pending)
+		if err != nil {
+			return commitResultMsg{err: err}
+		}
+		queries, allArgs, origins := pending.GenerateOrderedSQL(refs)
 		if len(queries) == 0 {
 			return commitResultMsg{count: 0}
 		}
 
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), m.db.StatementTimeout())
 		defer cancel()
 
-		tx, err := m.db.Conn.Begin(ctx)
+		var failed *editor.StatementOrigin
+		err = m.db.WithConn(func(conn *pgx.Conn) error {
+			tx, err := conn.Begin(ctx)
+			if err != nil {
+				return fmt.Errorf("begin transaction: %w", err)
+			}
+			failed, err = applyChanges(ctx, tx, queries, allArgs, origins)
+			return err
+		})
 		if err != nil {
-			return commitResultMsg{err: fmt.Errorf("begin transaction: %w", err)}
+			return commitResultMsg{err: err, failed: failed}
 		}
 
-		for i, q := range queries {
-			var args []interface{}
-			if i < len(allArgs) {
-				args = allArgs[i]
-			}
-			_, err := tx.Exec(ctx, q, args...)
-			if err != nil {
-				tx.Rollback(ctx)
-				return commitResultMsg{err: fmt.Errorf("exec: %w", err)}
-			}
+		return commitResultMsg{count: pending.PendingCount()}
+	}
+}
+
+// applyChanges runs the generated statements in tx as one batch and commits.
+// If any statement fails the whole transaction is rolled back and the origin
+// of the failing statement is returned with the error.
+func applyChanges(ctx context.Context, tx pgx.Tx, queries []string, allArgs [][]interface{}, origins []editor.StatementOrigin) (*editor.StatementOrigin, error) {
+	// Keys declared deferrable are checked once everything is in,
+	// which is what lets rows that reference each other through.
+	if _, err := tx.Exec(ctx, editor.DeferConstraints); err != nil {
+		tx.Rollback(ctx)
+		return nil, fmt.Errorf("defer constraints: %w", err)
+	}
+
+	batch := &pgx.Batch{}
+	for i, q := range queries {
+		var args []interface{}
+		if i < len(allArgs) {
+			args = allArgs[i]
 		}
+		batch.Queue(q, args...)
+	}
 
-		if err := tx.Commit(ctx); err != nil {
-			return commitResultMsg{err: fmt.Errorf("commit: %w", err)}
+	br := tx.SendBatch(ctx, batch)
+	for i := range queries {
+		if _, err := br.Exec(); err != nil {
+			br.Close()
+			tx.Rollback(ctx)
+			origin := origins[i]
+			return &origin, fmt.Errorf("%s: %w", origin, err)
 		}
+	}
+	if err := br.Close(); err != nil {
+		tx.Rollback(ctx)
+		return nil, fmt.Errorf("batch: %w", err)
+	}
 
-		return commitResultMsg{count: len(queries)}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+	return nil, nil
+}
+
+// refreshTables reloads the table list, for tables created outside the app.
+func (m *Model) refreshTables() tea.Cmd {
+	return func() tea.Msg {
+		tables, err := m.db.ListTables()
+		if err != nil {
+			return ddlRefreshMsg{err: fmt.Errorf("list tables: %w", err)}
+		}
+		return ddlRefreshMsg{tables: tables}
 	}
 }
 
@@ -725,73 +2523,113 @@ func (m *Model) refreshAfterDDL(tableName string, loadTable bool) tea.Cmd {
 				result.tableData = &tableDataMsg{err: err}
 				return result
 			}
-			sql := fmt.Sprintf(`SELECT * FROM %q LIMIT 100`, tableName)
-			qr, _, err := m.db.ExecuteQuery(sql)
+			qr, _, err := m.db.ExecuteQuery(browseQuery(tableName), true)
 			if err != nil {
 				result.tableData = &tableDataMsg{err: err}
 				return result
 			}
+			columns, _ := m.db.GetColumns(tableName)
 			result.tableData = &tableDataMsg{
 				result:    qr,
 				tableName: tableName,
 				pks:       pks,
+				columns:   columns,
 			}
 		}
 		return result
 	}
 }
 
-func isCreateTable(sql string) bool {
-	upper := strings.ToUpper(strings.TrimSpace(sql))
-	return strings.HasPrefix(upper, "CREATE TABLE") || strings.HasPrefix(upper, "CREATE UNLOGGED TABLE") || strings.HasPrefix(upper, "CREATE TEMP TABLE") || strings.HasPrefix(upper, "CREATE TEMPORARY TABLE")
+// appendToEditor adds a generated statement after whatever is in the
+// editor, ending that with a semicolon so the two stay separate, and puts
+// the cursor at the end.
+func (m *Model) appendToEditor(stmt string) {
+	text := strings.TrimRight(m.editor.Value(), " \t\n")
+	if text != "" && !strings.HasSuffix(text, ";") {
+		text += ";"
+	}
+	if text != "" {
+		text += "\n\n"
+	}
+	m.editor.SetValue(text + stmt)
+	m.focusPane(EditorPane)
 }
 
-func extractDDLTableName(sql string) string {
-	tokens := strings.Fields(strings.TrimSpace(sql))
-	upper := make([]string, len(tokens))
-	for i, t := range tokens {
-		upper[i] = strings.ToUpper(t)
+// describeTable fetches the columns and indexes of table for the columns
+// overlay.
+func (m *Model) describeTable(table string) tea.Cmd {
+	return func() tea.Msg {
+		columns, err := m.db.GetColumns(table)
+		if err != nil {
+			return describeTableMsg{err: err}
+		}
+		indexes, indexErr := m.db.GetIndexes(table)
+		// Missing the comment is no reason not to describe the table.
+		comment, _ := m.db.GetTableComment(table)
+		return describeTableMsg{table: table, comment: comment, columns: columns, indexes: indexes, indexErr: indexErr}
 	}
-	for i, tok := range upper {
-		if tok == "TABLE" && i > 0 && (upper[i-1] == "CREATE" || upper[i-1] == "DROP" || upper[i-1] == "ALTER") {
-			idx := i + 1
-			if idx < len(upper) && (upper[idx] == "IF" || upper[idx] == "NOT") {
-				for idx < len(upper) && (upper[idx] == "IF" || upper[idx] == "NOT" || upper[idx] == "EXISTS") {
-					idx++
-				}
+}
+
+// loadAlterTemplate fetches the columns of table for alterTemplate.
+func (m *Model) loadAlterTemplate(table string) tea.Cmd {
+	return func() tea.Msg {
+		columns, err := m.db.GetColumns(table)
+		return alterTemplateMsg{table: table, columns: columns, err: err}
+	}
+}
+
+// loadSelectTemplate fetches the columns of table for selectTemplate.
+func (m *Model) loadSelectTemplate(table string) tea.Cmd {
+	return func() tea.Msg {
+		columns, err := m.db.GetColumns(table)
+		return selectTemplateMsg{table: table, columns: columns, err: err}
+	}
+}
+
+// profileTable counts the nulls and distinct values of table's columns for
+// the profile overlay.
+func (m *Model) profileTable(table string) tea.Cmd {
+	return func() tea.Msg {
+		profile, err := m.db.ProfileTable(table)
+		return profileTableMsg{table: table, profile: profile, err: err}
+	}
+}
+
+// alterTemplate starts an ALTER TABLE ... ADD COLUMN for table, with its
+// existing columns listed in a comment above for reference.
+func alterTemplate(table string, columns []db.ColumnInfo) string {
+	var b strings.Builder
+	if len(columns) > 0 {
+		fmt.Fprintf(&b, "-- Columns of %s:\n", table)
+		for _, c := range columns {
+			fmt.Fprintf(&b, "--   %s %s", c.Name, c.DataType)
+			if c.IsNullable == "NO" {
+				b.WriteString(" NOT NULL")
 			}
-			if idx < len(tokens) {
-				name := tokens[idx]
-				name = strings.Trim(name, `"'`+"`")
-				name = strings.TrimRight(name, "(;,")
-				if strings.Contains(name, ".") {
-					parts := strings.SplitN(name, ".", 2)
-					name = strings.Trim(parts[len(parts)-1], `"'`+"`")
-				}
-				if name != "" {
-					return name
-				}
+			if c.ColumnDefault != nil {
+				b.WriteString(" DEFAULT " + *c.ColumnDefault)
 			}
+			b.WriteString("\n")
 		}
 	}
-	return ""
+	fmt.Fprintf(&b, "ALTER TABLE %s ADD COLUMN ", db.QuoteIdentifier(table))
+	return b.String()
 }
 
-func extractTableName(sql string) string {
-	tokens := strings.Fields(strings.TrimSpace(sql))
-	upper := make([]string, len(tokens))
-	for i, t := range tokens {
-		upper[i] = strings.ToUpper(t)
+// selectTemplate lists the columns of table in a SELECT, one to a line so
+// they are easy to drop or move.
+func selectTemplate(table string, columns []db.ColumnInfo) string {
+	if len(columns) == 0 {
+		return fmt.Sprintf("SELECT *\nFROM %s;", db.QuoteIdentifier(table))
 	}
-	for i, tok := range upper {
-		if (tok == "INTO" || tok == "FROM" || tok == "UPDATE") && i+1 < len(tokens) {
-			name := tokens[i+1]
-			name = strings.Trim(name, `"'`)
-			name = strings.TrimRight(name, "(;,")
-			if name != "" {
-				return name
-			}
-		}
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = "    " + pgx.Identifier{c.Name}.Sanitize()
 	}
-	return ""
+	return fmt.Sprintf("SELECT\n%s\nFROM %s;", strings.Join(names, ",\n"), db.QuoteIdentifier(table))
+}
+
+func isCreateTable(sql string) bool {
+	upper := strings.ToUpper(strings.TrimSpace(sql))
+	return strings.HasPrefix(upper, "CREATE TABLE") || strings.HasPrefix(upper, "CREATE UNLOGGED TABLE") || strings.HasPrefix(upper, "CREATE TEMP TABLE") || strings.HasPrefix(upper, "CREATE TEMPORARY TABLE")
 }
@@ -0,0 +1,135 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"cli-sql/internal/editor"
+)
+
+func TestBrowseQuery(t *testing.T) {
+	tests := []struct {
+		table string
+		want  string
+	}{
+		{"users", `SELECT * FROM "users" LIMIT 100`},
+		{"sales.orders", `SELECT * FROM "sales"."orders" LIMIT 100`},
+		{`"My Schema"."a.b"`, `SELECT * FROM "My Schema"."a.b" LIMIT 100`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.table, func(t *testing.T) {
+			if got := browseQuery(tt.table); got != tt.want {
+				t.Errorf("browseQuery(%s) = %s, want %s", tt.table, got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeTx is a transaction whose batched statements fail as errs says. Only
+// the methods applyChanges uses are implemented.
+type fakeTx struct {
+	pgx.Tx
+	errs       []error // per queued statement; missing entries succeed
+	queued     []string
+	executed   int
+	committed  bool
+	rolledBack bool
+}
+
+func (tx *fakeTx) Exec(context.Context, string, ...any) (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, nil
+}
+
+func (tx *fakeTx) SendBatch(_ context.Context, b *pgx.Batch) pgx.BatchResults {
+	for _, q := range b.QueuedQueries {
+		tx.queued = append(tx.queued, q.SQL)
+	}
+	return &fakeBatchResults{tx: tx}
+}
+
+func (tx *fakeTx) Commit(context.Context) error {
+	tx.committed = true
+	return nil
+}
+
+func (tx *fakeTx) Rollback(context.Context) error {
+	tx.rolledBack = true
+	return nil
+}
+
+type fakeBatchResults struct {
+	pgx.BatchResults
+	tx *fakeTx
+}
+
+func (br *fakeBatchResults) Exec() (pgconn.CommandTag, error) {
+	i := br.tx.executed
+	br.tx.executed++
+	if i < len(br.tx.errs) && br.tx.errs[i] != nil {
+		return pgconn.CommandTag{}, br.tx.errs[i]
+	}
+	return pgconn.CommandTag{}, nil
+}
+
+func (br *fakeBatchResults) Close() error { return nil }
+
+func TestApplyChanges(t *testing.T) {
+	queries := []string{"INSERT 1", "UPDATE 2", "DELETE 3"}
+	args := [][]interface{}{{"a"}, {"b"}, {"c"}}
+	origins := []editor.StatementOrigin{
+		{Type: editor.OpInsert, TableName: "users", RowCount: 2},
+		{Type: editor.OpEdit, TableName: "users", PKValues: map[string]string{"id": "42"}, ColumnName: "name"},
+		{Type: editor.OpDelete, TableName: "orders", PKValues: map[string]string{"id": "7"}},
+	}
+	errFK := errors.New("violates foreign key constraint")
+
+	tests := []struct {
+		name       string
+		errs       []error
+		wantFailed *editor.StatementOrigin
+		wantExec   int
+		wantCommit bool
+	}{
+		{name: "all succeed", wantExec: 3, wantCommit: true},
+		{name: "first fails", errs: []error{errFK}, wantFailed: &origins[0], wantExec: 1},
+		{name: "middle fails", errs: []error{nil, errFK}, wantFailed: &origins[1], wantExec: 2},
+		{name: "last fails", errs: []error{nil, nil, errFK}, wantFailed: &origins[2], wantExec: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := &fakeTx{errs: tt.errs}
+			failed, err := applyChanges(context.Background(), tx, queries, args, origins)
+
+			if !reflect.DeepEqual(tx.queued, queries) {
+				t.Errorf("queued = %q, want %q", tx.queued, queries)
+			}
+			if tx.executed != tt.wantExec {
+				t.Errorf("executed %d statements, want %d", tx.executed, tt.wantExec)
+			}
+			if tx.committed != tt.wantCommit || tx.rolledBack == tt.wantCommit {
+				t.Errorf("committed = %v, rolled back = %v, want commit %v", tx.committed, tx.rolledBack, tt.wantCommit)
+			}
+			if !reflect.DeepEqual(failed, tt.wantFailed) {
+				t.Errorf("failed = %+v, want %+v", failed, tt.wantFailed)
+			}
+			if tt.wantFailed == nil {
+				if err != nil {
+					t.Errorf("err = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, errFK) {
+				t.Errorf("err = %v, want it to wrap %v", err, errFK)
+			}
+			if want := tt.wantFailed.String() + ": "; err == nil || !strings.HasPrefix(err.Error(), want) {
+				t.Errorf("err = %v, want prefix %q", err, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,460 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"cli-sql/internal/config"
+	"cli-sql/internal/db"
+	"cli-sql/internal/editor"
+	"cli-sql/internal/ui"
+)
+
+// session is everything that belongs to one open connection: the database
+// handle, the panes showing it and the state of what was last run on it.
+type session struct {
+	id               int
+	label            string
+	color            string // connection color tinting the top bar, or ""
+	autosaveKey      string // names the file the editor buffer is kept in
+	db               *db.DB
+	sidebar          ui.SidebarModel
+	editor           ui.EditorModel
+	results          ui.ResultsModel
+	changes          *editor.ChangeTracker
+	lastSQL          string
+	lastTable        string
+	pendingDMLMsg    string
+	limitedSQL       string // query whose results were cut off by autoLimit
+	currentScript    string
+	connected        bool
+	readOnlyLocked   bool // opened read-only by the config or --read-only, so it stays that way
+	pinging          bool
+	lastPing         time.Time
+	latency          time.Duration // round trip of the last ping, 0 until one is taken
+	serverVersion    string
+	currentUser      string
+	pendingRefSource ui.ShowReferencingMsg // row whose referencing tables the chooser lists
+	pendingRefs      []db.ForeignKey
+}
+
+// newSession builds the panes for a freshly opened connection.
+func newSession(database *db.DB, tables, databases []string, cfg *config.Config, label string) session {
+	changes := editor.NewChangeTracker()
+
+	sidebar := ui.NewSidebarModel(tables)
+	sidebar.SetDatabases(databases)
+	sidebar.SetActiveDatabase(database.Database())
+	sidebar.SetFavorites(cfg.FavoriteTables(connKey(database)))
+	sidebar.SetGroupDelimiter(cfg.SidebarGroupDelimiter)
+
+	editorModel := ui.NewEditorModel()
+	editorModel.SetTableNames(tables)
+	editorModel.SetKeywordCase(ui.ParseKeywordCase(cfg.KeywordCase))
+	// The buffer is kept per server, user and database, whatever the
+	// connection was opened as.
+	key := connKey(database)
+	if autosaved, _ := config.LoadAutosave(key); autosaved != "" {
+		editorModel.SetValue(autosaved)
+	}
+
+	results := ui.NewResultsModel(changes)
+	results.SetReadOnly(database.ReadOnly())
+	results.SetDisplayFormat(ui.DisplayFormat{
+		TimestampLayout:    cfg.TimestampFormat,
+		ThousandsSeparator: cfg.ThousandsSeparator,
+		MaskColumns:        cfg.MaskColumns,
+	})
+
+	return session{
+		label:          label,
+		autosaveKey:    key,
+		db:             database,
+		sidebar:        sidebar,
+		editor:         editorModel,
+		results:        results,
+		changes:        changes,
+		connected:      true,
+		readOnlyLocked: database.ReadOnly(),
+		lastPing:       time.Now(),
+	}
+}
+
+// connKey names the server, user and database d is connected to, as
+// user@host:port/database.
+func connKey(d *db.DB) string {
+	return strings.TrimPrefix(d.ConnInfo(), "postgres://")
+}
+
+// Connect opens a saved connection and lists its tables and databases.
+// conn.ReadOnly is taken as given; callers fold in Config.ReadOnlyFor.
+func Connect(conn config.SavedConnection) (*db.DB, []string, []string, error) {
+	opts := db.Options{
+		ConnectTimeout:   time.Duration(conn.ConnectTimeout) * time.Second,
+		StatementTimeout: time.Duration(conn.StatementTimeout) * time.Second,
+		ReadOnly:         conn.ReadOnly,
+		ApplicationName:  conn.ApplicationName,
+		SearchPath:       conn.SearchPath,
+	}
+	var d *db.DB
+	var err error
+	if conn.URI != "" {
+		d, err = db.ConnectURI(conn.URI, opts)
+	} else {
+		d, err = db.Connect(conn.Host, conn.Port, conn.User, conn.Password, conn.Database, opts)
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	tables, err := d.ListTables()
+	if err != nil {
+		d.Close()
+		return nil, nil, nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	databases, err := d.ListDatabases()
+	if err != nil {
+		d.Close()
+		return nil, nil, nil, fmt.Errorf("failed to list databases: %w", err)
+	}
+	return d, tables, databases, nil
+}
+
+// sessionMsg is the result of a command issued by session id, delivered
+// back to that session whichever one is shown by then.
+type sessionMsg struct {
+	id  int
+	msg tea.Msg
+}
+
+// sessionOpenedMsg carries the result of opening another connection.
+type sessionOpenedMsg struct {
+	name      string
+	db        *db.DB
+	tables    []string
+	databases []string
+	err       error
+}
+
+// tagCmd wraps the messages cmd produces so they reach session id. Timers
+// and messages about the app as a whole are left untagged.
+func tagCmd(id int, cmd tea.Cmd) tea.Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		return tagMsg(id, cmd())
+	}
+}
+
+func tagMsg(id int, msg tea.Msg) tea.Msg {
+	switch msg := msg.(type) {
+	case nil, sessionMsg, tea.QuitMsg, tea.KeyMsg, tea.MouseMsg, tea.WindowSizeMsg,
+		tickMsg, spinnerTickMsg, exportTickMsg, activityTickMsg, sessionOpenedMsg:
+		return msg
+	case tea.BatchMsg:
+		cmds := make(tea.BatchMsg, len(msg))
+		for i, c := range msg {
+			cmds[i] = tagCmd(id, c)
+		}
+		return cmds
+	}
+	return sessionMsg{id: id, msg: msg}
+}
+
+// updateBackground applies sm to the session it belongs to while another
+// is shown. The status bar keeps describing the shown session, apart from
+// the copy and count spinners, which sm may have stopped; a message the
+// background one set is repeated there under its label.
+func (m Model) updateBackground(sm sessionMsg) (tea.Model, tea.Cmd) {
+	i := m.sessionIndex(sm.id)
+	if i < 0 {
+		// The session has been closed since.
+		return m, nil
+	}
+	shown, pane, bar := m.current, m.activePane, m.statusbar
+	m.swapTo(i)
+	res, cmd := m.update(sm.msg)
+	m = res.(Model)
+	cmd = tagCmd(m.id, cmd)
+	text, kind := m.statusbar.Message()
+	label := m.label
+	m.swapTo(shown)
+	m.activePane = pane
+	m.messages.SetFocused(pane == ResultsPane)
+	if old, _ := bar.Message(); text != old {
+		bar.SetMessage(label+": "+text, kind)
+	}
+	bar.KeepProgress(m.statusbar)
+	m.statusbar = bar
+	return m, cmd
+}
+
+// sessionIndex returns the position of session id in m.sessions, or -1.
+func (m Model) sessionIndex(id int) int {
+	for i, s := range m.sessions {
+		if s.id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// swapTo makes sessions[i] the active session, storing the current one.
+func (m *Model) swapTo(i int) {
+	m.sessions[m.current] = m.session
+	m.session = m.sessions[i]
+	m.current = i
+}
+
+// switchSession shows sessions[i], sized and focused as the last one was.
+func (m *Model) switchSession(i int) {
+	if i == m.current {
+		return
+	}
+	m.swapTo(i)
+	ui.TintConnection(m.color)
+	m.recalcLayout()
+	m.focusPane(m.activePane)
+	m.statusbar.SetPendingChanges(m.changes.PendingCount())
+	m.statusbar.SetMessage(fmt.Sprintf("Switched to %s", m.label), ui.MsgInfo)
+}
+
+// connChoice is what picking an option in the connection chooser does:
+// switch to an open session, open a saved connection or close the current one.
+type connChoice struct {
+	session int // index into sessions, or -1
+	conn    *config.SavedConnection
+	close   bool
+}
+
+// openConnectionChooser lists the open sessions, then the saved
+// connections, then closing the current session if it isn't the only one.
+func (m *Model) openConnectionChooser() {
+	m.sessions[m.current] = m.session
+	var options []string
+	m.pendingConns = nil
+	m.pendingDiff = nil
+	for i, s := range m.sessions {
+		label := s.label
+		if i == m.current {
+			label += " (current)"
+		}
+		options = append(options, label)
+		m.pendingConns = append(m.pendingConns, connChoice{session: i})
+	}
+	for i := range m.cfg.Connections {
+		conn := m.cfg.Connections[i]
+		options = append(options, "Open "+conn.Name)
+		m.pendingConns = append(m.pendingConns, connChoice{session: -1, conn: &conn})
+	}
+	if len(m.sessions) > 1 {
+		options = append(options, "Close "+m.label)
+		m.pendingConns = append(m.pendingConns, connChoice{session: -1, close: true})
+	}
+	m.chooser.Open("Connections", options)
+}
+
+// chooseConnection carries out c, picked in the connection chooser.
+func (m *Model) chooseConnection(c connChoice) tea.Cmd {
+	switch {
+	case c.close:
+		return m.closeSession()
+	case c.conn != nil:
+		conn := *c.conn
+		conn.ReadOnly = m.cfg.ReadOnlyFor(conn)
+		m.statusbar.SetMessage(fmt.Sprintf("Connecting to %s...", conn.Name), ui.MsgInfo)
+		return func() tea.Msg {
+			d, tables, databases, err := Connect(conn)
+			return sessionOpenedMsg{name: conn.Name, db: d, tables: tables, databases: databases, err: err}
+		}
+	case c.session >= 0 && c.session < len(m.sessions):
+		m.switchSession(c.session)
+	}
+	return nil
+}
+
+// openSession adds the connection in msg as a new session and shows it.
+func (m *Model) openSession(msg sessionOpenedMsg) {
+	if msg.err != nil {
+		m.statusbar.SetMessage(fmt.Sprintf("Cannot connect to %s: %v", msg.name, msg.err), ui.MsgError)
+		return
+	}
+	for i, conn := range m.cfg.Connections {
+		if conn.Name == msg.name {
+			m.cfg.TouchLastUsed(i)
+			m.cfg.Save()
+			break
+		}
+	}
+
+	taken := make(map[string]bool, len(m.sessions))
+	for _, s := range m.sessions {
+		taken[s.label] = true
+	}
+	label := msg.name
+	for n := 2; taken[label]; n++ {
+		label = fmt.Sprintf("%s (%d)", msg.name, n)
+	}
+
+	s := newSession(msg.db, msg.tables, msg.databases, m.cfg, label)
+	s.id = m.nextSessionID
+	s.color = m.cfg.ConnectionColor(msg.name)
+	m.nextSessionID++
+	m.sessions = append(m.sessions, s)
+	m.switchSession(len(m.sessions) - 1)
+	m.messages.Add(ui.LogInfo, fmt.Sprintf("Connected to %s (%s)", label, msg.db.ConnInfo()))
+	m.statusbar.SetMessage(fmt.Sprintf("Connected to %s (%d tables)", label, len(msg.tables)), ui.MsgSuccess)
+}
+
+// closeSession disconnects the current session and shows the previous one.
+// It refuses while the session has uncommitted changes or an export running.
+func (m *Model) closeSession() tea.Cmd {
+	if len(m.sessions) < 2 {
+		return nil
+	}
+	if n := m.changes.PendingCount() + len(m.results.GetInsertedRowValues()); n > 0 {
+		m.statusbar.SetMessage(fmt.Sprintf("Commit or discard the %d changes on %s before closing it", n, m.label), ui.MsgError)
+		return nil
+	}
+	if m.exporting != nil && m.exporting.db == m.db {
+		m.statusbar.SetMessage("Wait for the export to finish, or cancel it, before closing "+m.label, ui.MsgError)
+		return nil
+	}
+
+	closed := m.session
+	config.SaveAutosave(closed.autosaveKey, closed.editor.Value())
+	i := m.current
+	m.sessions = append(m.sessions[:i], m.sessions[i+1:]...)
+	m.current = max(0, i-1)
+	m.session = m.sessions[m.current]
+	ui.TintConnection(m.color)
+	m.recalcLayout()
+	m.focusPane(m.activePane)
+	m.statusbar.SetPendingChanges(m.changes.PendingCount())
+	m.messages.Add(ui.LogInfo, "Disconnected from "+closed.label)
+	m.statusbar.SetMessage(fmt.Sprintf("Closed %s; now on %s", closed.label, m.label), ui.MsgInfo)
+	return func() tea.Msg {
+		closed.db.Close()
+		return nil
+	}
+}
+
+// connectionTabs renders the open sessions for the top bar, the current
+// one highlighted, or "" when there is only one.
+func (m Model) connectionTabs() string {
+	if len(m.sessions) < 2 {
+		return ""
+	}
+	var tabs string
+	for i, s := range m.sessions {
+		label := fmt.Sprintf(" %d:%s ", i+1, s.label)
+		if i == m.current {
+			tabs += ui.AccentText.Bold(true).Render(label)
+		} else {
+			tabs += ui.TopBarText.Render(label)
+		}
+	}
+	return tabs + ui.TopBarText.Render("│")
+}
+
+// saveBuffers keeps the editor buffer of every open session for next time.
+func (m Model) saveBuffers() {
+	m.sessions[m.current] = m.session
+	for _, s := range m.sessions {
+		config.SaveAutosave(s.autosaveKey, s.editor.Value())
+	}
+}
+
+// Close disconnects every open session.
+func (m Model) Close() {
+	m.sessions[m.current] = m.session
+	for _, s := range m.sessions {
+		s.db.Close()
+	}
+}
+
+// startDiff compares the results of the last query here with another
+// connection's: the only other one, or one picked from a chooser.
+func (m *Model) startDiff() tea.Cmd {
+	switch {
+	case m.lastSQL == "":
+		m.statusbar.SetMessage("No query to compare", ui.MsgInfo)
+		return nil
+	case len(m.sessions) < 2:
+		m.statusbar.SetMessage(fmt.Sprintf("Open a second connection (%s) to compare against", ui.KeyLabel(ui.ActionConnections)), ui.MsgInfo)
+		return nil
+	case !isReadOnlyQuery(m.lastSQL):
+		m.statusbar.SetMessage("Only a single SELECT can be run on both connections to compare", ui.MsgError)
+		return nil
+	}
+	var others []int
+	var options []string
+	for i, s := range m.sessions {
+		if i != m.current {
+			others = append(others, i)
+			options = append(options, s.label)
+		}
+	}
+	if len(others) == 1 {
+		return m.diffAgainst(others[0])
+	}
+	m.pendingDiff = others
+	m.pendingConns = nil
+	m.pendingRefs = nil
+	m.chooser.Open("Compare "+firstLine(m.lastSQL)+" with", options)
+	return nil
+}
+
+// diffAgainst runs the last query on this connection and on sessions[i]
+// and compares the rows, matched on the primary key of the queried table
+// when it has one and every key column is in the results.
+func (m *Model) diffAgainst(i int) tea.Cmd {
+	if i < 0 || i >= len(m.sessions) || i == m.current {
+		return nil
+	}
+	sql := m.lastSQL
+	left, right := m.db, m.sessions[i].db
+	leftLabel, rightLabel := m.label, m.sessions[i].label
+	title := fmt.Sprintf("%s → %s: %s", leftLabel, rightLabel, firstLine(sql))
+	m.statusbar.SetMessage("Comparing: "+title, ui.MsgInfo)
+	return func() tea.Msg {
+		lres, _, err := left.ExecuteQuery(sql, true)
+		if err != nil {
+			return diffResultMsg{err: fmt.Errorf("%s: %w", leftLabel, err)}
+		}
+		rres, _, err := right.ExecuteQuery(sql, true)
+		if err != nil {
+			return diffResultMsg{err: fmt.Errorf("%s: %w", rightLabel, err)}
+		}
+		if lres == nil || rres == nil {
+			return diffResultMsg{err: errors.New("the query returned no rows to compare")}
+		}
+
+		var keys []string
+		if table := extractTableName(sql); table != "" && !joinsMultipleTables(sql) {
+			if pks, err := left.GetPrimaryKeys(table); err == nil && hasColumns(lres.Columns, pks) {
+				keys = pks
+			}
+		}
+		d, err := ui.DiffResults(lres, rres, keys)
+		return diffResultMsg{title: title, diff: d, err: err}
+	}
+}
+
+// hasColumns reports whether every name in want is one of columns.
+func hasColumns(columns, want []string) bool {
+	have := make(map[string]bool, len(columns))
+	for _, c := range columns {
+		have[c] = true
+	}
+	for _, w := range want {
+		if !have[w] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,772 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"cli-sql/internal/ui"
+)
+
+// sqlToken is a lexical token from a SQL statement. Quoted identifiers keep
+// their surrounding double quotes; whitespace and comments are dropped.
+type sqlToken struct {
+	text  string
+	upper string
+}
+
+func (t sqlToken) isIdent() bool {
+	if t.text == "" || strings.ContainsRune(t.text, '\'') {
+		return false // a string literal, which may start E'
+	}
+	c := t.text[0]
+	return c == '"' || c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// tokenizeSQL splits sql into words, quoted identifiers, string literals,
+// dollar-quoted strings and single-character punctuation. Strings are found
+// as the editor's highlighter finds them.
+func tokenizeSQL(sql string) []sqlToken {
+	var tokens []sqlToken
+	emit := func(s string) {
+		tokens = append(tokens, sqlToken{text: s, upper: strings.ToUpper(s)})
+	}
+
+	i := 0
+	for i < len(sql) {
+		if end := ui.DollarQuoteEnd(sql, i); end != -1 {
+			emit(sql[i:end])
+			i = end
+			continue
+		}
+		if end := ui.StringLiteralEnd(sql, i); end != -1 {
+			emit(sql[i:end])
+			i = end
+			continue
+		}
+		c := sql[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '-' && i+1 < len(sql) && sql[i+1] == '-':
+			end := strings.IndexByte(sql[i:], '\n')
+			if end == -1 {
+				i = len(sql)
+			} else {
+				i += end + 1
+			}
+		case c == '/' && i+1 < len(sql) && sql[i+1] == '*':
+			end := strings.Index(sql[i+2:], "*/")
+			if end == -1 {
+				i = len(sql)
+			} else {
+				i += end + 4
+			}
+		case c == '"':
+			end := i + 1
+			for end < len(sql) {
+				if sql[end] == c {
+					if end+1 < len(sql) && sql[end+1] == c {
+						end += 2
+						continue
+					}
+					end++
+					break
+				}
+				end++
+			}
+			emit(sql[i:end])
+			i = end
+		case isWordByte(c):
+			end := i + 1
+			for end < len(sql) && isWordByte(sql[end]) {
+				end++
+			}
+			emit(sql[i:end])
+			i = end
+		default:
+			emit(sql[i : i+1])
+			i++
+		}
+	}
+	return tokens
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// identName converts an identifier token to the name Postgres stores:
+// quoted identifiers are unquoted verbatim, bare ones are folded to lower case.
+func identName(tok string) string {
+	if strings.HasPrefix(tok, `"`) {
+		// Strip only the enclosing pair: a name may itself end in a quote.
+		inner := strings.TrimSuffix(tok[1:], `"`)
+		return strings.ReplaceAll(inner, `""`, `"`)
+	}
+	return strings.ToLower(tok)
+}
+
+// parseQualifiedName reads a possibly schema-qualified name starting at
+// tokens[i]. It returns the name in a form db.QuoteIdentifier understands and
+// the index of the next token.
+func parseQualifiedName(tokens []sqlToken, i int) (string, int) {
+	var parts []string
+	for i < len(tokens) && tokens[i].isIdent() {
+		part := identName(tokens[i].text)
+		if strings.ContainsAny(part, `."`) {
+			part = `"` + strings.ReplaceAll(part, `"`, `""`) + `"`
+		}
+		parts = append(parts, part)
+		i++
+		if i+1 < len(tokens) && tokens[i].text == "." {
+			i++
+			continue
+		}
+		break
+	}
+	return strings.Join(parts, "."), i
+}
+
+// extractTableName returns the table targeted by a SELECT, INSERT, UPDATE or
+// DELETE statement, or "" when no single base table can be determined (for
+// example when selecting from a subquery or a CTE).
+func extractTableName(sql string) string {
+	tokens := tokenizeSQL(sql)
+	if len(tokens) == 0 || tokens[0].upper == "WITH" {
+		return ""
+	}
+
+	depth := 0
+	for i, tok := range tokens {
+		switch tok.text {
+		case "(":
+			depth++
+			continue
+		case ")":
+			depth--
+			continue
+		}
+		if depth != 0 {
+			continue
+		}
+		if tok.upper != "INTO" && tok.upper != "FROM" && tok.upper != "UPDATE" {
+			continue
+		}
+
+		j := i + 1
+		if j < len(tokens) && tokens[j].upper == "ONLY" {
+			j++
+		}
+		if j >= len(tokens) || !tokens[j].isIdent() {
+			return ""
+		}
+		name, _ := parseQualifiedName(tokens, j)
+		return name
+	}
+	return ""
+}
+
+// ddlTableModifiers are the words that may come between CREATE and TABLE.
+var ddlTableModifiers = map[string]bool{
+	"GLOBAL": true, "LOCAL": true, "TEMP": true, "TEMPORARY": true,
+	"UNLOGGED": true, "FOREIGN": true,
+}
+
+// extractDDLTableName returns the table a CREATE, ALTER or DROP TABLE
+// statement acts on, schema-qualified if it was written so, or "" for any
+// other statement.
+func extractDDLTableName(sql string) string {
+	tokens := tokenizeSQL(sql)
+	if len(tokens) == 0 {
+		return ""
+	}
+	switch tokens[0].upper {
+	case "CREATE", "ALTER", "DROP":
+	default:
+		return ""
+	}
+	i := 1
+	for i < len(tokens) && ddlTableModifiers[tokens[i].upper] {
+		i++
+	}
+	if i >= len(tokens) || tokens[i].upper != "TABLE" {
+		return ""
+	}
+	i++
+	for i < len(tokens) {
+		switch tokens[i].upper {
+		case "IF", "NOT", "EXISTS", "ONLY":
+			i++
+			continue
+		}
+		break
+	}
+	if i >= len(tokens) || !tokens[i].isIdent() {
+		return ""
+	}
+	name, _ := parseQualifiedName(tokens, i)
+	return name
+}
+
+// fromClauseEnd lists keywords that terminate a FROM clause.
+var fromClauseEnd = map[string]bool{
+	"WHERE": true, "GROUP": true, "HAVING": true, "WINDOW": true, "ORDER": true,
+	"LIMIT": true, "OFFSET": true, "FETCH": true, "FOR": true, "UNION": true,
+	"INTERSECT": true, "EXCEPT": true, "RETURNING": true,
+}
+
+// joinsMultipleTables reports whether the top-level FROM clause of sql reads
+// from more than one table, either through a JOIN or a comma-separated list.
+func joinsMultipleTables(sql string) bool {
+	depth := 0
+	inFrom := false
+	for _, tok := range tokenizeSQL(sql) {
+		switch tok.text {
+		case "(":
+			depth++
+			continue
+		case ")":
+			depth--
+			continue
+		}
+		if depth != 0 {
+			continue
+		}
+		switch {
+		case tok.upper == "FROM":
+			inFrom = true
+		case !inFrom:
+		case tok.upper == "JOIN" || tok.text == ",":
+			return true
+		case fromClauseEnd[tok.upper]:
+			inFrom = false
+		}
+	}
+	return false
+}
+
+// rowsNotAddressable returns why the rows a SELECT returns are not rows of
+// its table that can be edited in place, or "" if nothing at the top level
+// of sql rules it out. Grouped rows and rows combined from several queries
+// do not stand for one table row each.
+func rowsNotAddressable(sql string) string {
+	depth := 0
+	tokens := tokenizeSQL(sql)
+	for i, tok := range tokens {
+		switch tok.text {
+		case "(":
+			depth++
+			continue
+		case ")":
+			depth--
+			continue
+		}
+		if depth != 0 {
+			continue
+		}
+		switch tok.upper {
+		case "GROUP":
+			if i+1 < len(tokens) && tokens[i+1].upper == "BY" {
+				return "groups rows"
+			}
+		case "UNION", "INTERSECT", "EXCEPT":
+			return "combines several queries"
+		}
+	}
+	return ""
+}
+
+// fromItem is a table listed in a FROM clause, under the names a column
+// reference can qualify it by. Items joined with ON, USING, NATURAL or CROSS
+// share a group, as do subqueries and function calls, which usually refer
+// to the tables before them.
+type fromItem struct {
+	table   string
+	names   map[string]bool
+	group   int
+	checked bool // a plain table, which a cross join with is worth a warning
+}
+
+// crossJoinedTables returns two tables of the first SELECT in sql that are
+// listed in FROM separated by a comma with nothing in the WHERE clause
+// relating them, so the query returns every combination of their rows; or
+// "" if there are none. A comparison between unqualified columns may
+// relate anything and silences the warning.
+func crossJoinedTables(sql string) (string, string) {
+	tokens := tokenizeSQL(sql)
+	if end := indexOfToken(tokens, ";"); end >= 0 {
+		tokens = tokens[:end]
+	}
+	if len(tokens) == 0 || tokens[0].upper != "SELECT" {
+		return "", ""
+	}
+
+	// The FROM clause and the WHERE clause, at the top level.
+	var from, where []sqlToken
+	clause := ""
+	depth := 0
+	for _, tok := range tokens {
+		top := depth == 0
+		switch tok.text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		}
+		switch {
+		case top && tok.upper == "FROM":
+			clause = "FROM"
+			continue
+		case top && tok.upper == "WHERE":
+			clause = "WHERE"
+			continue
+		case top && fromClauseEnd[tok.upper]:
+			clause = ""
+		}
+		switch clause {
+		case "FROM":
+			from = append(from, tok)
+		case "WHERE":
+			where = append(where, tok)
+		}
+	}
+
+	items := parseFromItems(from)
+	groups := 0
+	for _, it := range items {
+		groups = max(groups, it.group+1)
+	}
+	if groups < 2 {
+		return "", ""
+	}
+
+	// Union the groups any one WHERE condition refers to.
+	parent := make([]int, groups)
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(g int) int {
+		if parent[g] != g {
+			parent[g] = find(parent[g])
+		}
+		return parent[g]
+	}
+	for _, cond := range splitConditions(where) {
+		linked := -1
+		for i := 0; i+2 < len(cond); i++ {
+			if !cond[i].isIdent() || cond[i+1].text != "." || !cond[i+2].isIdent() {
+				continue
+			}
+			name := identName(cond[i].text)
+			for _, it := range items {
+				if !it.names[name] {
+					continue
+				}
+				if linked < 0 {
+					linked = find(it.group)
+				} else {
+					parent[find(it.group)] = linked
+				}
+			}
+		}
+		if comparesBareColumns(cond) {
+			return "", ""
+		}
+	}
+
+	for i := range items {
+		for j := i + 1; j < len(items); j++ {
+			a, b := items[i], items[j]
+			if a.checked && b.checked && find(a.group) != find(b.group) {
+				return a.table, b.table
+			}
+		}
+	}
+	return "", ""
+}
+
+// parseFromItems reads the tables of a FROM clause, numbering the groups of
+// them that commas separate.
+func parseFromItems(from []sqlToken) []fromItem {
+	var items []fromItem
+	group := 0
+	expectItem := true
+	for i := 0; i < len(from); i++ {
+		tok := from[i]
+		switch {
+		case tok.text == ",":
+			group++
+			expectItem = true
+			continue
+		case tok.upper == "JOIN":
+			expectItem = true
+			continue
+		case tok.upper == "LATERAL" || tok.upper == "ONLY":
+			continue
+		}
+		if !expectItem {
+			if tok.text == "(" {
+				i = skipParens(from, i)
+			}
+			continue
+		}
+		expectItem = false
+
+		it := fromItem{names: map[string]bool{}, group: group}
+		switch {
+		case tok.text == "(":
+			i = skipParens(from, i)
+		case tok.isIdent():
+			name, next := parseQualifiedName(from, i)
+			if next < len(from) && from[next].text == "(" {
+				// A function call such as unnest(t.tags).
+				i = skipParens(from, next)
+				break
+			}
+			it.table, it.checked = name, true
+			// Columns are qualified by the table's own name, without its
+			// schema.
+			it.names[identName(from[next-1].text)] = true
+			i = next - 1
+		default:
+			continue
+		}
+		// An alias, with or without AS.
+		j := i + 1
+		if j < len(from) && from[j].upper == "AS" {
+			j++
+		}
+		if j < len(from) && from[j].isIdent() && !joinWords[from[j].upper] {
+			it.names = map[string]bool{identName(from[j].text): true}
+			i = j
+		}
+		items = append(items, it)
+	}
+	return items
+}
+
+// joinWords can follow a FROM item where an alias could, so are not taken
+// for one.
+var joinWords = map[string]bool{
+	"JOIN": true, "INNER": true, "LEFT": true, "RIGHT": true, "FULL": true, "CROSS": true,
+	"NATURAL": true, "ON": true, "USING": true, "TABLESAMPLE": true,
+}
+
+// skipParens returns the index of the parenthesis closing the one at i.
+func skipParens(tokens []sqlToken, i int) int {
+	depth := 0
+	for ; i < len(tokens); i++ {
+		switch tokens[i].text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(tokens) - 1
+}
+
+// indexOfToken returns the index of the first token text is, or -1.
+func indexOfToken(tokens []sqlToken, text string) int {
+	for i, tok := range tokens {
+		if tok.text == text {
+			return i
+		}
+	}
+	return -1
+}
+
+// splitConditions splits a WHERE clause at its ANDs and ORs, at any depth.
+func splitConditions(where []sqlToken) [][]sqlToken {
+	var conds [][]sqlToken
+	start := 0
+	for i, tok := range where {
+		if tok.upper == "AND" || tok.upper == "OR" {
+			conds = append(conds, where[start:i])
+			start = i + 1
+		}
+	}
+	return append(conds, where[start:])
+}
+
+// comparesBareColumns reports whether cond compares two unqualified names,
+// as in a_id = id, which could relate any two tables.
+func comparesBareColumns(cond []sqlToken) bool {
+	bare := func(i int) bool {
+		if i < 0 || i >= len(cond) || !cond[i].isIdent() || sqlLiteralWords[cond[i].upper] {
+			return false
+		}
+		return (i == 0 || cond[i-1].text != ".") && (i+1 >= len(cond) || cond[i+1].text != "." && cond[i+1].text != "(")
+	}
+	for i, tok := range cond {
+		if tok.text == "=" && bare(i-1) && bare(i+1) {
+			return true
+		}
+	}
+	return false
+}
+
+// sqlLiteralWords are words that stand for values, not columns.
+var sqlLiteralWords = map[string]bool{
+	"NULL": true, "TRUE": true, "FALSE": true, "CURRENT_DATE": true, "CURRENT_TIMESTAMP": true,
+	"CURRENT_USER": true, "NOW": true,
+}
+
+// firstLine returns the first non-blank line of sql, with "…" appended when
+// anything follows, for quoting a statement in the status bar.
+func firstLine(sql string) string {
+	sql = strings.TrimSpace(sql)
+	if i := strings.IndexByte(sql, '\n'); i >= 0 {
+		return strings.TrimSpace(sql[:i]) + " …"
+	}
+	return sql
+}
+
+// unfilteredWrite returns the table of the first UPDATE or DELETE in sql
+// that has no top-level WHERE clause, and so touches every row, or "" if
+// there is none. sql may hold several statements separated by semicolons.
+// Statements starting with WITH are not inspected.
+func unfilteredWrite(sql string) string {
+	tokens := tokenizeSQL(sql)
+	start := 0
+	for start < len(tokens) {
+		end, depth, hasWhere := start, 0, false
+		for ; end < len(tokens); end++ {
+			tok := tokens[end]
+			if depth == 0 && tok.text == ";" {
+				break
+			}
+			switch {
+			case tok.text == "(":
+				depth++
+			case tok.text == ")":
+				depth--
+			case depth == 0 && tok.upper == "WHERE":
+				hasWhere = true
+			}
+		}
+
+		stmt := tokens[start:end]
+		start = end + 1
+		if hasWhere || len(stmt) == 0 {
+			continue
+		}
+		j := 1
+		switch {
+		case stmt[0].upper == "UPDATE":
+		case stmt[0].upper == "DELETE" && len(stmt) > 1 && stmt[1].upper == "FROM":
+			j = 2
+		default:
+			continue
+		}
+		if j < len(stmt) && stmt[j].upper == "ONLY" {
+			j++
+		}
+		if j < len(stmt) && stmt[j].isIdent() {
+			name, _ := parseQualifiedName(stmt, j)
+			return name
+		}
+	}
+	return ""
+}
+
+// withAutoLimit returns sql with "LIMIT n" appended if it is a single SELECT,
+// possibly after a WITH clause, with no LIMIT or FETCH of its own, reporting
+// whether it changed it. Queries with a top-level FOR or INTO clause are
+// left alone, since LIMIT would have to go before them.
+func withAutoLimit(sql string, n int) (string, bool) {
+	tokens := tokenizeSQL(sql)
+	if len(tokens) == 0 || mainStatement(tokens) != "SELECT" {
+		return sql, false
+	}
+	trimmed := strings.TrimSpace(sql)
+	depth := 0
+	for i, tok := range tokens {
+		switch tok.text {
+		case "(":
+			depth++
+			continue
+		case ")":
+			depth--
+			continue
+		}
+		if depth != 0 {
+			continue
+		}
+		switch {
+		case tok.text == ";":
+			// Only a semicolon ending the text can be stripped; one followed
+			// by another statement or a comment makes the append unsafe.
+			if i != len(tokens)-1 || !strings.HasSuffix(trimmed, ";") {
+				return sql, false
+			}
+		case tok.upper == "LIMIT" || tok.upper == "FETCH" || tok.upper == "FOR" || tok.upper == "INTO":
+			return sql, false
+		}
+	}
+	trimmed = strings.TrimSpace(strings.TrimSuffix(trimmed, ";"))
+	// On its own line, so a trailing line comment can't swallow it.
+	return fmt.Sprintf("%s\nLIMIT %d", trimmed, n), true
+}
+
+// mainStatement returns the keyword the statement in tokens starts with,
+// upper case, looking past a WITH clause to the statement its common table
+// expressions are for.
+func mainStatement(tokens []sqlToken) string {
+	if len(tokens) == 0 {
+		return ""
+	}
+	if tokens[0].upper != "WITH" {
+		return tokens[0].upper
+	}
+	depth := 0
+	for _, tok := range tokens[1:] {
+		switch tok.text {
+		case "(":
+			depth++
+			continue
+		case ")":
+			depth--
+			continue
+		}
+		if depth != 0 {
+			continue
+		}
+		switch tok.upper {
+		case "SELECT", "INSERT", "UPDATE", "DELETE", "MERGE", "VALUES", "TABLE":
+			return tok.upper
+		}
+	}
+	return ""
+}
+
+// writeKeywords start statements that change data or schema.
+var writeKeywords = map[string]bool{
+	"INSERT": true, "UPDATE": true, "DELETE": true, "MERGE": true, "TRUNCATE": true,
+	"CREATE": true, "ALTER": true, "DROP": true, "GRANT": true, "REVOKE": true,
+}
+
+// sideEffectFunctions change state when called, even from a SELECT.
+var sideEffectFunctions = map[string]bool{"NEXTVAL": true, "SETVAL": true}
+
+// isReadOnlyQuery reports whether sql is a single SELECT, VALUES or TABLE
+// statement, or a WITH query none of whose parts write, and so is safe to
+// run a second time, against another connection or after a reconnect.
+func isReadOnlyQuery(sql string) bool {
+	tokens := tokenizeSQL(sql)
+	for len(tokens) > 0 && tokens[len(tokens)-1].text == ";" {
+		tokens = tokens[:len(tokens)-1]
+	}
+	if len(tokens) == 0 {
+		return false
+	}
+	switch tokens[0].upper {
+	case "SELECT", "VALUES", "TABLE", "WITH":
+	default:
+		return false
+	}
+	for _, tok := range tokens {
+		if tok.text == ";" || writeKeywords[tok.upper] || sideEffectFunctions[tok.upper] || tok.upper == "INTO" {
+			return false
+		}
+	}
+	return true
+}
+
+// createdIndex returns the index and table names of a CREATE INDEX
+// statement, or "" for anything else.
+func createdIndex(sql string) (index, table string) {
+	tokens := tokenizeSQL(sql)
+	i := 0
+	next := func(words ...string) bool {
+		for _, w := range words {
+			if i < len(tokens) && tokens[i].upper == w {
+				i++
+				return true
+			}
+		}
+		return false
+	}
+	if !next("CREATE") {
+		return "", ""
+	}
+	next("UNIQUE")
+	if !next("INDEX") {
+		return "", ""
+	}
+	next("CONCURRENTLY")
+	if next("IF") {
+		next("NOT")
+		next("EXISTS")
+	}
+	if i < len(tokens) && tokens[i].upper != "ON" && tokens[i].isIdent() {
+		index, i = parseQualifiedName(tokens, i)
+	}
+	if !next("ON") {
+		return "", ""
+	}
+	next("ONLY")
+	if i >= len(tokens) || !tokens[i].isIdent() {
+		return "", ""
+	}
+	table, _ = parseQualifiedName(tokens, i)
+	if index == "" {
+		index = "(unnamed)"
+	}
+	return index, table
+}
+
+// isComment reports whether sql is a COMMENT ON statement.
+func isComment(sql string) bool {
+	tokens := tokenizeSQL(sql)
+	return len(tokens) > 1 && tokens[0].upper == "COMMENT" && tokens[1].upper == "ON"
+}
+
+// readOnlyStarts begin the statements read-only mode lets through, as long
+// as nothing in them writes.
+var readOnlyStarts = map[string]bool{
+	"SELECT": true, "VALUES": true, "TABLE": true, "WITH": true, "EXPLAIN": true, "SHOW": true,
+	"SET": true, "RESET": true, "BEGIN": true, "START": true, "COMMIT": true, "END": true,
+	"ROLLBACK": true, "ABORT": true, "SAVEPOINT": true, "RELEASE": true,
+	"DECLARE": true, "FETCH": true, "MOVE": true, "CLOSE": true,
+}
+
+// writingStatement returns the leading keyword of the first statement in sql
+// that read-only mode refuses, or "" if every statement is allowed. A
+// statement is refused unless it starts with one of readOnlyStarts and has
+// no write keyword or INTO anywhere in it, which covers data-changing CTEs,
+// EXPLAIN ANALYZE of a write and SELECT INTO. Anything that would lift the
+// server's guard, READ WRITE or the read-only settings by name, is refused
+// as well.
+func writingStatement(sql string) string {
+	tokens := tokenizeSQL(sql)
+	start := 0
+	for start < len(tokens) {
+		end := start
+		for end < len(tokens) && tokens[end].text != ";" {
+			end++
+		}
+		stmt := tokens[start:end]
+		start = end + 1
+		if len(stmt) == 0 {
+			continue
+		}
+		if !readOnlyStarts[stmt[0].upper] {
+			return stmt[0].upper
+		}
+		for i, tok := range stmt {
+			name := strings.Trim(tok.upper, `"'`)
+			switch {
+			case writeKeywords[tok.upper], tok.upper == "INTO",
+				name == "DEFAULT_TRANSACTION_READ_ONLY", name == "TRANSACTION_READ_ONLY",
+				tok.upper == "READ" && i+1 < len(stmt) && stmt[i+1].upper == "WRITE":
+				return stmt[0].upper
+			}
+		}
+	}
+	return ""
+}
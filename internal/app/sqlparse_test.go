@@ -0,0 +1,96 @@
+package app
+
+import "testing"
+
+func TestExtractTableName(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want string
+	}{
+		{"plain select", "SELECT * FROM users", "users"},
+		{"folded to lower case", "select id from Users where id = 1", "users"},
+		{"quoted keeps case", `SELECT * FROM "Users"`, "Users"},
+		{"schema-qualified", "SELECT * FROM sales.orders", "sales.orders"},
+		{"quoted and qualified", `SELECT * FROM "Sales"."Order Items"`, "Sales.Order Items"},
+		{"quoted dot", `SELECT * FROM sales."a.b"`, `sales."a.b"`},
+		{"embedded quote", `SELECT * FROM "say ""hi"""`, `"say ""hi"""`},
+		{"alias", "SELECT u.id FROM users u WHERE u.id = 1", "users"},
+		{"AS alias", "SELECT * FROM sales.orders AS o", "sales.orders"},
+		{"ONLY", "SELECT * FROM ONLY parent", "parent"},
+		{"ONLY qualified", "DELETE FROM ONLY sales.orders WHERE id = 1", "sales.orders"},
+		{"insert", "INSERT INTO logs (msg) VALUES ('from x')", "logs"},
+		{"update", "UPDATE sales.orders SET total = 0", "sales.orders"},
+		{"FROM inside a literal", "SELECT 'FROM fake' FROM real", "real"},
+		{"FROM inside a comment", "SELECT 1 -- FROM fake\nFROM real", "real"},
+		{"subquery in select list", "SELECT (SELECT max(id) FROM other) FROM users", "users"},
+		{"subquery in FROM", "SELECT * FROM (SELECT * FROM users) s", ""},
+		{"CTE", "WITH t AS (SELECT 1) SELECT * FROM t", ""},
+		{"no table", "SELECT 1", ""},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractTableName(tt.sql); got != tt.want {
+				t.Errorf("extractTableName(%q) = %q, want %q", tt.sql, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractDDLTableName(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want string
+	}{
+		{"CREATE TABLE t (id int)", "t"},
+		{"CREATE TABLE s.t (id int)", "s.t"},
+		{"create table Sales.Orders(id int)", "sales.orders"},
+		{"CREATE TABLE sales.x AS SELECT 1", "sales.x"},
+		{"CREATE TABLE IF NOT EXISTS s.t (id int)", "s.t"},
+		{"CREATE UNLOGGED TABLE t (id int)", "t"},
+		{"CREATE TEMP TABLE t AS SELECT 1", "t"},
+		{`ALTER TABLE IF EXISTS "S"."t" ADD COLUMN x int`, "S.t"},
+		{"ALTER TABLE ONLY s.t DROP COLUMN x", "s.t"},
+		{`DROP TABLE "a.b"`, `"a.b"`},
+		{`DROP TABLE IF EXISTS s."a.b";`, `s."a.b"`},
+		{"-- note\nDROP TABLE t", "t"},
+		{"CREATE INDEX ON t (id)", ""},
+		{"SELECT * FROM t", ""},
+		{"CREATE TABLE", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sql, func(t *testing.T) {
+			if got := extractDDLTableName(tt.sql); got != tt.want {
+				t.Errorf("extractDDLTableName(%q) = %q, want %q", tt.sql, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsReadOnlyQuery(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want bool
+	}{
+		{"SELECT * FROM users", true},
+		{"WITH t AS (SELECT 1) SELECT * FROM t;", true},
+		{"VALUES (1), (2)", true},
+		{"SELECT 'delete' FROM t", true},
+		{"WITH d AS (DELETE FROM t RETURNING *) SELECT * FROM d", false},
+		{"EXPLAIN ANALYZE UPDATE t SET x = 1", false},
+		{"SELECT nextval('users_id_seq')", false},
+		{"SELECT setval('s', 1)", false},
+		{"SELECT * INTO copy FROM t", false},
+		{"SELECT 1; SELECT 2", false},
+		{"UPDATE t SET x = 1", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sql, func(t *testing.T) {
+			if got := isReadOnlyQuery(tt.sql); got != tt.want {
+				t.Errorf("isReadOnlyQuery(%q) = %v, want %v", tt.sql, got, tt.want)
+			}
+		})
+	}
+}
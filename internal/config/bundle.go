@@ -0,0 +1,215 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// bundleVersion is written to every bundle; ImportBundle refuses newer ones.
+const bundleVersion = 1
+
+// Bundle is the portable form of the saved connections and scripts, written
+// by ExportBundle and read by ImportBundle.
+type Bundle struct {
+	Version     int               `json:"version"`
+	Connections []SavedConnection `json:"connections"`
+	// Scripts maps file names, e.g. "report.sql", to their contents.
+	Scripts map[string]string `json:"scripts"`
+}
+
+// ImportSummary reports what ImportBundle merged in.
+type ImportSummary struct {
+	Connections int // added, including renamed ones
+	Scripts     int
+	Skipped     int      // identical to what was already there
+	Renamed     []string // "old → new" for each entry that clashed
+}
+
+// ExportBundle writes the saved connections and all scripts to a JSON file
+// at path. Passwords, including any in a URI, are left out unless
+// withPasswords is set, in which case the file holds them in plain text.
+// Last-used times stay behind, as they belong to this machine.
+func ExportBundle(path string, withPasswords bool) error {
+	cfg, err := Load()
+	if err != nil {
+		return err
+	}
+	b := Bundle{Version: bundleVersion, Scripts: map[string]string{}}
+	for _, c := range cfg.Connections {
+		c.LastUsed = time.Time{}
+		if !withPasswords {
+			c.Password = ""
+			c.URI = stripURIPassword(c.URI)
+		}
+		b.Connections = append(b.Connections, c)
+	}
+
+	names, err := ListScripts()
+	if err != nil {
+		return fmt.Errorf("failed to list scripts: %w", err)
+	}
+	for _, name := range names {
+		content, err := LoadScript(name)
+		if err != nil {
+			return fmt.Errorf("failed to read script %s: %w", name, err)
+		}
+		b.Scripts[name] = content
+	}
+
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal bundle: %w", err)
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// ImportBundle merges the bundle at path into the saved connections and
+// scripts. Nothing is overwritten: an entry identical to an existing one is
+// skipped, and one whose name is taken is added under a new name.
+func ImportBundle(path string) (ImportSummary, error) {
+	var sum ImportSummary
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return sum, fmt.Errorf("failed to read bundle: %w", err)
+	}
+	var b Bundle
+	if err := json.Unmarshal(data, &b); err != nil {
+		return sum, fmt.Errorf("failed to parse bundle: %w", err)
+	}
+	if b.Version > bundleVersion {
+		return sum, fmt.Errorf("bundle version %d is newer than this version of cli-sql supports (%d)", b.Version, bundleVersion)
+	}
+	// Check every script name before changing anything.
+	for name := range b.Scripts {
+		if err := checkScriptName(name); err != nil {
+			return sum, err
+		}
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		return sum, err
+	}
+	taken := make(map[string]bool, len(cfg.Connections))
+	for _, c := range cfg.Connections {
+		taken[c.Name] = true
+	}
+	for _, c := range b.Connections {
+		if hasConnection(cfg.Connections, c) {
+			sum.Skipped++
+			continue
+		}
+		if taken[c.Name] {
+			name := uniqueName(taken, func(n int) string { return fmt.Sprintf("%s (%d)", c.Name, n) })
+			sum.Renamed = append(sum.Renamed, c.Name+" → "+name)
+			c.Name = name
+		}
+		taken[c.Name] = true
+		cfg.Connections = append(cfg.Connections, c)
+		sum.Connections++
+	}
+	if sum.Connections > 0 {
+		if err := cfg.Save(); err != nil {
+			return sum, err
+		}
+	}
+
+	existing, err := ListScripts()
+	if err != nil {
+		return sum, fmt.Errorf("failed to list scripts: %w", err)
+	}
+	scripts := make(map[string]bool, len(existing))
+	for _, name := range existing {
+		scripts[name] = true
+	}
+	for _, name := range sortedKeys(b.Scripts) {
+		content := b.Scripts[name]
+		if scripts[name] {
+			if cur, err := LoadScript(name); err == nil && cur == content {
+				sum.Skipped++
+				continue
+			}
+			base := strings.TrimSuffix(name, ".sql")
+			renamed := uniqueName(scripts, func(n int) string { return fmt.Sprintf("%s-%d.sql", base, n) })
+			sum.Renamed = append(sum.Renamed, name+" → "+renamed)
+			name = renamed
+		}
+		if err := SaveScript(name, content); err != nil {
+			return sum, fmt.Errorf("failed to save script %s: %w", name, err)
+		}
+		scripts[name] = true
+		sum.Scripts++
+	}
+	return sum, nil
+}
+
+// hasConnection reports whether conns already holds c under the same name,
+// ignoring when each was last used. A bundle exported without passwords
+// matches the connection it came from.
+func hasConnection(conns []SavedConnection, c SavedConnection) bool {
+	c.LastUsed = time.Time{}
+	for _, e := range conns {
+		e.LastUsed = time.Time{}
+		if c.Password == "" {
+			e.Password = ""
+		}
+		if stripURIPassword(c.URI) == c.URI {
+			e.URI = stripURIPassword(e.URI)
+		}
+		if e == c {
+			return true
+		}
+	}
+	return false
+}
+
+// uniqueName returns the first of candidate(2), candidate(3), … that is
+// not in taken.
+func uniqueName(taken map[string]bool, candidate func(int) string) string {
+	for n := 2; ; n++ {
+		if c := candidate(n); !taken[c] {
+			return c
+		}
+	}
+}
+
+// checkScriptName rejects script names that would be written outside the
+// scripts directory.
+func checkScriptName(name string) error {
+	if name == "" || name != filepath.Base(name) || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return fmt.Errorf("bundle has an invalid script name %q", name)
+	}
+	if filepath.Ext(name) != ".sql" {
+		return fmt.Errorf("bundle script %q is not a .sql file", name)
+	}
+	return nil
+}
+
+// stripURIPassword removes the password from a connection URI, leaving
+// anything unparseable as it is.
+func stripURIPassword(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.User == nil {
+		return uri
+	}
+	if _, ok := u.User.Password(); !ok {
+		return uri
+	}
+	u.User = url.User(u.User.Username())
+	return u.String()
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"time"
 )
 
@@ -18,18 +19,217 @@ type SavedConnection struct {
 	Database string    `json:"database,omitempty"`
 	URI      string    `json:"uri,omitempty"`
 	LastUsed time.Time `json:"last_used,omitempty"`
+	// ConnectTimeout and StatementTimeout are in seconds; zero means the
+	// default (10s and 30s).
+	ConnectTimeout   int `json:"connect_timeout,omitempty"`
+	StatementTimeout int `json:"statement_timeout,omitempty"`
+	// ApplicationName and SearchPath are sent to the server as the
+	// application_name and search_path settings; empty leaves them to the
+	// URI, or application_name to "sqlrat".
+	ApplicationName string `json:"application_name,omitempty"`
+	SearchPath      string `json:"search_path,omitempty"`
+	// ReadOnly opens the connection in read-only mode: edits and writing
+	// statements are refused and the server rejects any that slip through.
+	ReadOnly bool `json:"read_only,omitempty"`
+	// Color names one of the connection colors ("red", "blue" ...) the top
+	// bar and the focused border take while the connection is shown.
+	Color string `json:"color,omitempty"`
 }
 
 type Config struct {
 	Connections []SavedConnection `json:"connections"`
+	// KeywordCase is how the formatter renders keywords: "upper" (default),
+	// "lower" or "preserve".
+	KeywordCase string `json:"keyword_case,omitempty"`
+	// Theme names the color preset: "dark", "light", "solarized" or "auto"
+	// (default), which follows the terminal background. Colors in
+	// theme.json override individual entries.
+	Theme string `json:"theme,omitempty"`
+	// AutoLimit caps the rows fetched by a free-form SELECT that has no
+	// LIMIT of its own; zero means the default (1000) and a negative value
+	// turns the cap off.
+	AutoLimit int `json:"auto_limit,omitempty"`
+	// TimestampFormat is a Go time layout, e.g. "2006-01-02 15:04:05", for
+	// showing timestamp columns in the results grid; empty keeps the full
+	// form with fractional seconds and offset.
+	TimestampFormat string `json:"timestamp_format,omitempty"`
+	// ThousandsSeparator groups the digits of integer and numeric columns
+	// in the results grid.
+	ThousandsSeparator bool `json:"thousands_separator,omitempty"`
+	// MaskColumns lists patterns of column names, such as "password",
+	// "ssn" or "*_token", whose values the results grid shows as dots.
+	// Patterns are shell globs and ignore case.
+	MaskColumns []string `json:"mask_columns,omitempty"`
+	// ReadOnly opens every connection in read-only mode.
+	ReadOnly bool `json:"read_only,omitempty"`
+	// ForceReadOnly is ReadOnly for this run only, as set by --read-only;
+	// it is never saved.
+	ForceReadOnly bool `json:"-"`
+	// SidebarGroupDelimiter separates the prefix tables are grouped by in
+	// the sidebar from the rest of their name; empty means "_".
+	SidebarGroupDelimiter string `json:"sidebar_group_delimiter,omitempty"`
+	// DeleteConfirmRows is how many staged row deletions a commit may carry
+	// before it asks first; zero means the default (25) and a negative value
+	// never asks.
+	DeleteConfirmRows int `json:"delete_confirm_rows,omitempty"`
+	// DeleteConfirmPercent asks too when more than one deletion is staged
+	// and they are more than this share of the rows loaded in the grid;
+	// zero means the default (50) and a negative value never asks.
+	DeleteConfirmPercent int `json:"delete_confirm_percent,omitempty"`
+	// SlowQueryMs is how many milliseconds a query may take before its time
+	// is shown as slow; zero means the default (1000) and a negative value
+	// never flags one.
+	SlowQueryMs int `json:"slow_query_ms,omitempty"`
+	// Favorites lists the starred tables of each database, keyed by
+	// user@host:port/database.
+	Favorites map[string][]string `json:"favorites,omitempty"`
 }
 
+// DefaultAutoLimit is the row cap used when AutoLimit is zero.
+const DefaultAutoLimit = 1000
+
+// AutoLimitRows returns the effective AutoLimit, or 0 when it is disabled.
+func (c *Config) AutoLimitRows() int {
+	switch {
+	case c.AutoLimit < 0:
+		return 0
+	case c.AutoLimit == 0:
+		return DefaultAutoLimit
+	}
+	return c.AutoLimit
+}
+
+// Defaults for DeleteConfirmRows and DeleteConfirmPercent.
+const (
+	DefaultDeleteConfirmRows    = 25
+	DefaultDeleteConfirmPercent = 50
+)
+
+// DeleteNeedsConfirm reports whether committing deletes staged row deletions,
+// out of loaded rows in the grid, is enough to ask first.
+func (c *Config) DeleteNeedsConfirm(deletes, loaded int) bool {
+	if deletes == 0 {
+		return false
+	}
+	rows, percent := c.DeleteConfirmRows, c.DeleteConfirmPercent
+	if rows == 0 {
+		rows = DefaultDeleteConfirmRows
+	}
+	if percent == 0 {
+		percent = DefaultDeleteConfirmPercent
+	}
+	if rows > 0 && deletes > rows {
+		return true
+	}
+	return percent > 0 && deletes > 1 && loaded > 0 && deletes*100 > loaded*percent
+}
+
+// DefaultSlowQuery is the slow query threshold used when SlowQueryMs is zero.
+const DefaultSlowQuery = time.Second
+
+// SlowQuery returns the effective slow query threshold, or 0 when it is
+// disabled.
+func (c *Config) SlowQuery() time.Duration {
+	switch {
+	case c.SlowQueryMs < 0:
+		return 0
+	case c.SlowQueryMs == 0:
+		return DefaultSlowQuery
+	}
+	return time.Duration(c.SlowQueryMs) * time.Millisecond
+}
+
+// ReadOnlyFor reports whether conn is to be opened read-only, by its own
+// setting or for every connection.
+func (c *Config) ReadOnlyFor(conn SavedConnection) bool {
+	return conn.ReadOnly || c.ReadOnly || c.ForceReadOnly
+}
+
+// ConnectionColor returns the color of the saved connection called name, or
+// "" if it has none.
+func (c *Config) ConnectionColor(name string) string {
+	for _, conn := range c.Connections {
+		if conn.Name == name {
+			return conn.Color
+		}
+	}
+	return ""
+}
+
+// FavoriteTables returns the starred tables of the database key.
+func (c *Config) FavoriteTables(key string) []string {
+	return c.Favorites[key]
+}
+
+// SetFavoriteTables records tables as the starred set of the database key,
+// forgetting the database once none are left.
+func (c *Config) SetFavoriteTables(key string, tables []string) {
+	if len(tables) == 0 {
+		delete(c.Favorites, key)
+		return
+	}
+	if c.Favorites == nil {
+		c.Favorites = make(map[string][]string)
+	}
+	c.Favorites[key] = tables
+}
+
+// DefaultsFromEnv returns connection fields taken from the libpq environment
+// variables PGHOST, PGPORT, PGUSER, PGPASSWORD and PGDATABASE, falling back
+// to localhost:5432 for an unset host or port.
+func DefaultsFromEnv() SavedConnection {
+	conn := SavedConnection{
+		Name:     "environment",
+		Host:     os.Getenv("PGHOST"),
+		Port:     os.Getenv("PGPORT"),
+		User:     os.Getenv("PGUSER"),
+		Password: os.Getenv("PGPASSWORD"),
+		Database: os.Getenv("PGDATABASE"),
+	}
+	if conn.Host == "" {
+		conn.Host = "localhost"
+	}
+	if conn.Port == "" {
+		conn.Port = "5432"
+	}
+	return conn
+}
+
+// HasEnvConnection reports whether PGHOST, PGUSER and PGDATABASE are all set,
+// i.e. DefaultsFromEnv describes a usable connection on its own.
+func HasEnvConnection() bool {
+	return os.Getenv("PGHOST") != "" && os.Getenv("PGUSER") != "" && os.Getenv("PGDATABASE") != ""
+}
+
+// ConfigDirEnv names the environment variable that, when set, is the
+// directory config, scripts, history and autosaves are kept in.
+const ConfigDirEnv = "SQLRAT_CONFIG_DIR"
+
+// configDir is $SQLRAT_CONFIG_DIR, else cli-sql under $XDG_CONFIG_HOME, else
+// ~/.config/cli-sql. It is created when something is first saved there.
 func configDir() (string, error) {
+	if dir := os.Getenv(ConfigDirEnv); dir != "" {
+		return filepath.Abs(dir)
+	}
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return "", fmt.Errorf("could not determine home directory: %w", err)
 	}
-	return filepath.Join(home, ".config", "cli-sql"), nil
+	legacy := filepath.Join(home, ".config", "cli-sql")
+	// The XDG spec says a relative path is to be ignored.
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); filepath.IsAbs(xdg) {
+		dir := filepath.Join(xdg, "cli-sql")
+		if dirExists(dir) || !dirExists(legacy) {
+			return dir, nil
+		}
+		// Keep using what was saved before XDG_CONFIG_HOME was set.
+	}
+	return legacy, nil
+}
+
+func dirExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
 }
 
 func configPath() (string, error) {
@@ -117,23 +317,52 @@ func scriptsDir() (string, error) {
 	return filepath.Join(dir, "scripts"), nil
 }
 
-func SaveAutosave(content string) error {
+// autosavePath returns where the editor buffer for the connection named
+// key is kept: autosave/<key>.sql, with characters unsafe in a file name
+// replaced, or the older single autosave.sql when key is empty.
+func autosavePath(key string) (string, error) {
 	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	if key == "" {
+		return filepath.Join(dir, "autosave.sql"), nil
+	}
+	safe := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9',
+			r == '.', r == '-', r == '_', r == '@':
+			return r
+		}
+		return '_'
+	}, key)
+	return filepath.Join(dir, "autosave", safe+".sql"), nil
+}
+
+// SaveAutosave keeps content as the editor buffer of the connection key.
+func SaveAutosave(key, content string) error {
+	path, err := autosavePath(key)
 	if err != nil {
 		return err
 	}
-	if err := os.MkdirAll(dir, 0700); err != nil {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
 		return err
 	}
-	return os.WriteFile(filepath.Join(dir, "autosave.sql"), []byte(content), 0600)
+	return os.WriteFile(path, []byte(content), 0600)
 }
 
-func LoadAutosave() (string, error) {
-	dir, err := configDir()
+// LoadAutosave returns the editor buffer saved for the connection key,
+// falling back to the single autosave.sql of earlier versions when that
+// connection has none yet.
+func LoadAutosave(key string) (string, error) {
+	path, err := autosavePath(key)
 	if err != nil {
 		return "", err
 	}
-	data, err := os.ReadFile(filepath.Join(dir, "autosave.sql"))
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) && key != "" {
+		return LoadAutosave("")
+	}
 	if err != nil {
 		if os.IsNotExist(err) {
 			return "", nil
@@ -143,6 +372,44 @@ func LoadAutosave() (string, error) {
 	return string(data), nil
 }
 
+// LoadThemeOverrides returns the contents of theme.json in the config
+// directory, or nil if there is none.
+func LoadThemeOverrides() ([]byte, error) {
+	dir, err := configDir()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "theme.json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read theme file: %w", err)
+	}
+	return data, nil
+}
+
+// LoadKeymap returns the action-to-keys bindings in keymap.json in the
+// config directory, or nil if there is none.
+func LoadKeymap() (map[string][]string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "keymap.json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read keymap: %w", err)
+	}
+	var km map[string][]string
+	if err := json.Unmarshal(data, &km); err != nil {
+		return nil, fmt.Errorf("failed to parse keymap: %w", err)
+	}
+	return km, nil
+}
+
 func ListScripts() ([]string, error) {
 	dir, err := scriptsDir()
 	if err != nil {
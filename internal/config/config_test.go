@@ -0,0 +1,110 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConfigDir(t *testing.T) {
+	tests := []struct {
+		name   string
+		env    string // SQLRAT_CONFIG_DIR, relative to the temp root
+		xdg    string // XDG_CONFIG_HOME; an absolute one is under the temp root
+		legacy bool   // whether ~/.config/cli-sql already exists
+		xdgDir bool   // whether $XDG_CONFIG_HOME/cli-sql already exists
+		want   string // relative to the temp root
+	}{
+		{name: "default", want: "home/.config/cli-sql"},
+		{name: "override", env: "custom", xdg: "/xdg", want: "custom"},
+		{name: "absolute XDG", xdg: "/xdg", want: "xdg/cli-sql"},
+		{name: "relative XDG is ignored", xdg: "xdg", want: "home/.config/cli-sql"},
+		{name: "legacy dir kept", xdg: "/xdg", legacy: true, want: "home/.config/cli-sql"},
+		{name: "XDG dir wins over legacy", xdg: "/xdg", legacy: true, xdgDir: true, want: "xdg/cli-sql"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			t.Setenv("HOME", filepath.Join(root, "home"))
+			t.Setenv(ConfigDirEnv, "")
+			if tt.env != "" {
+				t.Setenv(ConfigDirEnv, filepath.Join(root, tt.env))
+			}
+			xdg := tt.xdg
+			if filepath.IsAbs(xdg) {
+				xdg = filepath.Join(root, xdg)
+			}
+			t.Setenv("XDG_CONFIG_HOME", xdg)
+			if tt.legacy {
+				mkdir(t, filepath.Join(root, "home", ".config", "cli-sql"))
+			}
+			if tt.xdgDir {
+				mkdir(t, filepath.Join(xdg, "cli-sql"))
+			}
+
+			got, err := configDir()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := filepath.Join(root, tt.want); got != want {
+				t.Errorf("configDir() = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestConfigDirRelativeOverride(t *testing.T) {
+	t.Setenv(ConfigDirEnv, "rel")
+	got, err := configDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(wd, "rel"); got != want {
+		t.Errorf("configDir() = %s, want %s", got, want)
+	}
+}
+
+func TestSaveCreatesConfigDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "a", "b")
+	t.Setenv(ConfigDirEnv, dir)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatalf("Load created the config dir: %v", err)
+	}
+	cfg.Add(SavedConnection{Name: "local"})
+	if err := cfg.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(dir); err != nil || info.Mode().Perm() != 0700 {
+		t.Fatalf("config dir: %v, %v", info, err)
+	}
+	if err := SaveScript("q", "SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "scripts", "q.sql")); err != nil {
+		t.Error(err)
+	}
+
+	got, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Connections) != 1 || got.Connections[0].Name != "local" {
+		t.Errorf("connections = %+v, want local", got.Connections)
+	}
+}
+
+func mkdir(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(path, 0700); err != nil {
+		t.Fatal(err)
+	}
+}
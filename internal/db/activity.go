@@ -0,0 +1,94 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Activity is one client backend from pg_stat_activity.
+type Activity struct {
+	PID         int32
+	User        string
+	Database    string
+	Application string
+	State       string        // active, idle, idle in transaction ...
+	Wait        string        // "Lock: relation" etc. while waiting, or ""
+	BlockedBy   []int32       // backends holding locks this one waits for
+	Query       string        // running, or last run when idle
+	Duration    time.Duration // since the query started; zero if it never ran one
+	Self        bool          // this client's own connection
+}
+
+// errConnBusy is returned by ListActivity while the connection it would use
+// is taken by another statement of ours.
+var errConnBusy = errors.New("the connection is busy running a statement")
+
+// ListActivity returns the client backends of the server, the longest
+// running first.
+func (d *DB) ListActivity() ([]Activity, error) {
+	if !d.mu.TryLock() {
+		return nil, errConnBusy
+	}
+	defer d.unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := d.conn.Query(ctx, `
+		SELECT pid, coalesce(usename, ''), coalesce(datname, ''), coalesce(application_name, ''),
+		       coalesce(state, ''), coalesce(wait_event_type || ': ' || wait_event, ''),
+		       pg_blocking_pids(pid), coalesce(query, ''),
+		       coalesce(extract(epoch FROM now() - query_start), 0)::float8,
+		       pid = pg_backend_pid()
+		FROM pg_stat_activity
+		WHERE backend_type = 'client backend'
+		ORDER BY state = 'active' DESC, query_start NULLS LAST
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []Activity
+	for rows.Next() {
+		var a Activity
+		var secs float64
+		if err := rows.Scan(&a.PID, &a.User, &a.Database, &a.Application, &a.State, &a.Wait,
+			&a.BlockedBy, &a.Query, &secs, &a.Self); err != nil {
+			return nil, err
+		}
+		a.Duration = time.Duration(secs * float64(time.Second))
+		list = append(list, a)
+	}
+	return list, rows.Err()
+}
+
+// CancelBackend asks backend pid to cancel its running query.
+func (d *DB) CancelBackend(pid int32) error {
+	return d.signalBackend("pg_cancel_backend", pid)
+}
+
+// TerminateBackend ends backend pid's session, rolling back any open
+// transaction.
+func (d *DB) TerminateBackend(pid int32) error {
+	return d.signalBackend("pg_terminate_backend", pid)
+}
+
+// signalBackend calls fn, pg_cancel_backend or pg_terminate_backend, on pid.
+// Both return false rather than failing when the process has gone.
+func (d *DB) signalBackend(fn string, pid int32) error {
+	d.mu.Lock()
+	defer d.unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var ok bool
+	if err := d.conn.QueryRow(ctx, "SELECT "+fn+"($1)", pid).Scan(&ok); err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("backend %d is no longer running", pid)
+	}
+	return nil
+}
@@ -2,53 +2,238 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/url"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
+// Default timeouts used when Options leaves a field zero.
+const (
+	DefaultConnectTimeout   = 10 * time.Second
+	DefaultStatementTimeout = 30 * time.Second
+)
+
+// Options holds per-connection settings.
+type Options struct {
+	// ConnectTimeout bounds how long establishing the connection may take.
+	ConnectTimeout time.Duration
+	// StatementTimeout bounds how long a single query may run; zero means
+	// DefaultStatementTimeout. One that is set is also sent to the server
+	// as the statement_timeout setting.
+	StatementTimeout time.Duration
+	// ReadOnly makes every transaction read-only on the server
+	// (default_transaction_read_only), so a write fails even if one gets
+	// past the client.
+	ReadOnly bool
+	// ApplicationName is how the session shows in pg_stat_activity; empty
+	// means DefaultApplicationName.
+	ApplicationName string
+	// SearchPath, if set, is the session's search_path.
+	SearchPath string
+}
+
+// DefaultApplicationName is the application_name sent when neither Options
+// nor the connection string gives one.
+const DefaultApplicationName = "sqlrat"
+
+func (o Options) withDefaults() Options {
+	if o.ConnectTimeout <= 0 {
+		o.ConnectTimeout = DefaultConnectTimeout
+	}
+	if o.StatementTimeout < 0 {
+		o.StatementTimeout = 0
+	}
+	return o
+}
+
 // DB wraps a pgx connection with metadata.
 type DB struct {
-	Conn       *pgx.Conn
+	// mu serializes use of conn. A pgx connection runs one statement at a
+	// time, and queries, the pinger and the activity overlay each run in a
+	// goroutine of their own. It is held until a result has been read, and
+	// while conn is replaced.
+	mu     sync.Mutex
+	conn   *pgx.Conn
+	closed atomic.Bool // conn.IsClosed() as of its last use, for reading without mu
+
+	cancelMu sync.Mutex
+	cancelTo *pgconn.PgConn // conn's, for CancelQuery to reach while mu is held
+
 	connString string
 	host       string
 	port       string
 	user       string
 	password   string
 	database   string
+	opts       Options
+	readOnly   atomic.Bool // opts.ReadOnly, for reading without mu
+
+	typeNamesMu sync.Mutex
+	typeNames   map[uint32]string // catalog names of types pgx doesn't know, by OID
+
+	noticesMu      sync.Mutex
+	notices        []Notice // sent by the server since the last TakeNotices
+	noticesDropped int      // notices beyond maxNotices, counted but not kept
 }
 
-// Connect establishes a PostgreSQL connection with a 10-second timeout.
-func Connect(host, port, user, password, database string) (*DB, error) {
+// Connect establishes a PostgreSQL connection from individual fields.
+func Connect(host, port, user, password, database string, opts Options) (*DB, error) {
+	opts = opts.withDefaults()
 	encodedPassword := url.QueryEscape(password)
 	connStr := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=prefer",
 		user, encodedPassword, host, port, database)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	conn, err := pgx.Connect(ctx, connStr)
-	if err != nil {
-		return nil, err
-	}
-
-	return &DB{
-		Conn:       conn,
+	d := &DB{
 		connString: connStr,
 		host:       host,
 		port:       port,
 		user:       user,
 		password:   password,
 		database:   database,
-	}, nil
+		opts:       opts,
+	}
+	conn, err := dial(connStr, opts, d.onNotice)
+	if err != nil {
+		return nil, err
+	}
+	d.setConn(conn)
+	return d, nil
+}
+
+// setConn makes conn the connection of d. The caller holds mu, unless d is
+// not in use yet.
+func (d *DB) setConn(conn *pgx.Conn) {
+	d.conn = conn
+	d.closed.Store(d.connClosed())
+	d.readOnly.Store(d.opts.ReadOnly)
+	d.cancelMu.Lock()
+	d.cancelTo = nil
+	if conn != nil {
+		d.cancelTo = conn.PgConn()
+	}
+	d.cancelMu.Unlock()
+}
+
+// unlock releases mu, first noting whether the use just made of conn left
+// it closed.
+func (d *DB) unlock() {
+	d.closed.Store(d.connClosed())
+	d.mu.Unlock()
+}
+
+// connClosed is IsClosed for a caller holding mu.
+func (d *DB) connClosed() bool {
+	return d.conn == nil || d.conn.IsClosed()
+}
+
+// dial opens a connection within opts.ConnectTimeout and asks the server to
+// enforce opts.StatementTimeout, if set, and use opts.ApplicationName and
+// opts.SearchPath, unless the connection string (or PGAPPNAME) already sets
+// them itself. Notices the server sends are passed to onNotice.
+func dial(connStr string, opts Options, onNotice pgconn.NoticeHandler) (*pgx.Conn, error) {
+	cfg, err := pgx.ParseConfig(connStr)
+	if err != nil {
+		return nil, err
+	}
+	cfg.OnNotice = onNotice
+	if _, ok := cfg.RuntimeParams["statement_timeout"]; !ok && opts.StatementTimeout > 0 {
+		cfg.RuntimeParams["statement_timeout"] = fmt.Sprintf("%d", opts.StatementTimeout.Milliseconds())
+	}
+	if opts.ReadOnly {
+		cfg.RuntimeParams["default_transaction_read_only"] = "on"
+	}
+	if _, ok := cfg.RuntimeParams["application_name"]; !ok {
+		cfg.RuntimeParams["application_name"] = DefaultApplicationName
+		if opts.ApplicationName != "" {
+			cfg.RuntimeParams["application_name"] = opts.ApplicationName
+		}
+	}
+	if _, ok := cfg.RuntimeParams["search_path"]; !ok && opts.SearchPath != "" {
+		cfg.RuntimeParams["search_path"] = opts.SearchPath
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.ConnectTimeout)
+	defer cancel()
+	return pgx.ConnectConfig(ctx, cfg)
+}
+
+// ValidateURI checks that uri is a PostgreSQL connection URI before anything
+// is dialled: a postgres:// or postgresql:// scheme, and a port from 1 to
+// 65535 for each host that gives one. Hosts may be listed separated by
+// commas, as libpq allows, or left out altogether, for a Unix socket or
+// the PGHOST environment variable.
+func ValidateURI(uri string) error {
+	scheme, _, ok := strings.Cut(uri, "://")
+	if !ok {
+		return errors.New("invalid URI: expected postgres://user@host/database")
+	}
+	if s := strings.ToLower(scheme); s != "postgres" && s != "postgresql" {
+		return fmt.Errorf("invalid URI: scheme %q is not postgres or postgresql", scheme)
+	}
+
+	start, end := hostSpan(uri)
+	for _, hostPort := range strings.Split(uri[start:end], ",") {
+		port := ""
+		if strings.HasPrefix(hostPort, "[") {
+			end := strings.Index(hostPort, "]")
+			if end < 0 {
+				return errors.New("invalid URI: missing ] after IPv6 host")
+			}
+			port = strings.TrimPrefix(hostPort[end+1:], ":")
+		} else if i := strings.LastIndex(hostPort, ":"); i >= 0 {
+			port = hostPort[i+1:]
+		}
+		if port != "" {
+			if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+				return fmt.Errorf("invalid URI: invalid port %q", port)
+			}
+		}
+	}
+	if _, err := url.Parse(firstHostOnly(uri)); err != nil {
+		return fmt.Errorf("invalid URI: %w", err)
+	}
+	return nil
+}
+
+// hostSpan returns where in uri its host[:port] list is, after the scheme
+// and any user info.
+func hostSpan(uri string) (start, end int) {
+	if i := strings.Index(uri, "://"); i >= 0 {
+		start = i + 3
+	}
+	end = len(uri)
+	if i := strings.IndexAny(uri[start:], "/?#"); i >= 0 {
+		end = start + i
+	}
+	if i := strings.LastIndex(uri[start:end], "@"); i >= 0 {
+		start += i + 1
+	}
+	return start, end
+}
+
+// firstHostOnly cuts the host list of uri down to its first host, which is
+// all net/url can parse; pgx is given the whole list.
+func firstHostOnly(uri string) string {
+	start, end := hostSpan(uri)
+	first, _, _ := strings.Cut(uri[start:end], ",")
+	return uri[:start] + first + uri[end:]
 }
 
 // ConnectURI establishes a PostgreSQL connection from a raw URI string.
-func ConnectURI(uri string) (*DB, error) {
-	parsed, err := url.Parse(uri)
+func ConnectURI(uri string, opts Options) (*DB, error) {
+	opts = opts.withDefaults()
+	if err := ValidateURI(uri); err != nil {
+		return nil, err
+	}
+	parsed, err := url.Parse(firstHostOnly(uri))
 	if err != nil {
 		return nil, fmt.Errorf("invalid URI: %w", err)
 	}
@@ -68,43 +253,118 @@ func ConnectURI(uri string) (*DB, error) {
 		q.Set("sslmode", "prefer")
 		parsed.RawQuery = q.Encode()
 	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	conn, err := pgx.Connect(ctx, parsed.String())
-	if err != nil {
-		return nil, err
+	// Carried in opts as well, so SwitchDatabase keeps them.
+	if v := q.Get("application_name"); v != "" {
+		opts.ApplicationName = v
 	}
+	if v := q.Get("search_path"); v != "" {
+		opts.SearchPath = v
+	}
+
+	// Put back any other hosts for pgx to fall back on.
+	start, end := hostSpan(uri)
+	parsed.Host = uri[start:end]
 
-	return &DB{
-		Conn:       conn,
+	d := &DB{
 		connString: parsed.String(),
 		host:       host,
 		port:       port,
 		user:       user,
 		password:   password,
 		database:   database,
-	}, nil
+		opts:       opts,
+	}
+	conn, err := dial(d.connString, opts, d.onNotice)
+	if err != nil {
+		return nil, err
+	}
+	d.setConn(conn)
+	return d, nil
 }
 
 // Reconnect closes the existing connection and re-establishes it using the
 // original connection string. Returns the refreshed table list on success.
 func (d *DB) Reconnect() error {
-	if d.Conn != nil {
+	d.mu.Lock()
+	defer d.unlock()
+	return d.reconnect()
+}
+
+// reconnect is Reconnect for a caller holding mu.
+func (d *DB) reconnect() error {
+	if d.conn != nil {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		d.Conn.Close(ctx)
+		d.conn.Close(ctx)
 		cancel()
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	conn, err := dial(d.connString, d.opts, d.onNotice)
+	if err != nil {
+		return err
+	}
+	d.setConn(conn)
+	return nil
+}
 
-	conn, err := pgx.Connect(ctx, d.connString)
+// Clone opens another connection with the same settings as d, to the same
+// database, for long work such as an export that should not keep the
+// queries run meanwhile waiting. The caller closes it.
+func (d *DB) Clone() (*DB, error) {
+	d.mu.Lock()
+	c := &DB{
+		connString: d.connString,
+		host:       d.host,
+		port:       d.port,
+		user:       d.user,
+		password:   d.password,
+		database:   d.database,
+		opts:       d.opts,
+	}
+	d.mu.Unlock()
+	conn, err := dial(c.connString, c.opts, c.onNotice)
 	if err != nil {
+		return nil, err
+	}
+	c.setConn(conn)
+	return c, nil
+}
+
+// StatementTimeout returns how long a single statement may run.
+func (d *DB) StatementTimeout() time.Duration {
+	if d.opts.StatementTimeout <= 0 {
+		return DefaultStatementTimeout
+	}
+	return d.opts.StatementTimeout
+}
+
+// noStatementTimeout lifts the server's statement_timeout for the rest of
+// the transaction, for maintenance such as a bulk load that is bounded by
+// its caller's context instead.
+const noStatementTimeout = "SET LOCAL statement_timeout = 0"
+
+// ReadOnly reports whether the session only allows read-only transactions.
+// It does not wait for a running statement.
+func (d *DB) ReadOnly() bool {
+	return d.readOnly.Load()
+}
+
+// SetReadOnly turns the server's read-only guard on or off for this session
+// and for the connections Reconnect and SwitchDatabase make later. It takes
+// effect from the next transaction.
+func (d *DB) SetReadOnly(on bool) error {
+	d.mu.Lock()
+	defer d.unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	value := "off"
+	if on {
+		value = "on"
+	}
+	if _, err := d.conn.Exec(ctx, "SET default_transaction_read_only = "+value); err != nil {
 		return err
 	}
-	d.Conn = conn
+	d.opts.ReadOnly = on
+	d.readOnly.Store(on)
 	return nil
 }
 
@@ -113,48 +373,151 @@ func (d *DB) Database() string {
 	return d.database
 }
 
-// SwitchDatabase closes the current connection and opens a new one to a different database.
+// SwitchDatabase closes the current connection and opens a new one to a
+// different database, with the same Options.
 func (d *DB) SwitchDatabase(database string) error {
-	if d.Conn != nil {
+	d.mu.Lock()
+	defer d.unlock()
+	return d.switchDatabase(database)
+}
+
+// switchDatabase is SwitchDatabase for a caller holding mu.
+func (d *DB) switchDatabase(database string) error {
+	if d.conn != nil {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		d.Conn.Close(ctx)
+		d.conn.Close(ctx)
 		cancel()
 	}
 
-	newConnStr := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=prefer",
-		d.user, url.QueryEscape(d.password), d.host, d.port, database)
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	newConnStr := withDatabase(d.connString, database)
 
-	conn, err := pgx.Connect(ctx, newConnStr)
+	conn, err := dial(newConnStr, d.opts, d.onNotice)
 	if err != nil {
 		return err
 	}
 
-	d.Conn = conn
+	d.setConn(conn)
 	d.connString = newConnStr
 	d.database = database
+	// User-defined types have different OIDs in each database.
+	d.typeNamesMu.Lock()
+	d.typeNames = nil
+	d.typeNamesMu.Unlock()
 	return nil
 }
 
+// withDatabase returns the connection URI uri with its database replaced,
+// keeping every host it lists and its parameters, sslmode and host= among
+// them.
+func withDatabase(uri, database string) string {
+	_, end := hostSpan(uri)
+	rest := uri[end:]
+	if strings.HasPrefix(rest, "/") {
+		if i := strings.IndexAny(rest, "?#"); i >= 0 {
+			rest = rest[i:]
+		} else {
+			rest = ""
+		}
+	}
+	return uri[:end] + "/" + url.PathEscape(database) + rest
+}
+
 // Close closes the database connection.
 func (d *DB) Close() {
-	if d.Conn != nil {
+	if !d.mu.TryLock() {
+		// Stop the statement holding the connection rather than wait it out.
+		d.CancelQuery()
+		d.mu.Lock()
+	}
+	defer d.unlock()
+	if d.conn != nil {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
-		d.Conn.Close(ctx)
+		d.conn.Close(ctx)
 	}
 }
 
-// IsConnected checks if the connection is alive.
+// IsConnected checks if the connection is alive. A connection that is busy
+// running another statement counts as alive.
 func (d *DB) IsConnected() bool {
-	if d.Conn == nil {
-		return false
+	_, ok := d.Ping()
+	return ok
+}
+
+// Ping checks the connection as IsConnected does and returns how long the
+// round trip to the server took, or 0 when it was busy and not pinged.
+func (d *DB) Ping() (time.Duration, bool) {
+	if !d.mu.TryLock() {
+		return 0, !d.closed.Load()
+	}
+	defer d.unlock()
+	if d.connClosed() {
+		return 0, false
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
-	return d.Conn.Ping(ctx) == nil
+	start := time.Now()
+	err := d.conn.Ping(ctx)
+	return time.Since(start), err == nil
+}
+
+// CancelQuery asks the server to cancel the statement running on the
+// connection, if any. It is safe to call while another goroutine waits on
+// that statement, which then fails with a query_canceled error.
+func (d *DB) CancelQuery() error {
+	d.cancelMu.Lock()
+	pg := d.cancelTo
+	d.cancelMu.Unlock()
+	if pg == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return pg.CancelRequest(ctx)
+}
+
+// InTransaction reports whether the session is inside a transaction block,
+// e.g. after the user ran BEGIN.
+func (d *DB) InTransaction() bool {
+	d.mu.Lock()
+	defer d.unlock()
+	return d.inTransaction()
+}
+
+// inTransaction is InTransaction for a caller holding mu.
+func (d *DB) inTransaction() bool {
+	return d.conn != nil && d.conn.PgConn().TxStatus() != 'I'
+}
+
+// IsClosed reports whether the underlying connection has been closed, which
+// pgx does after network failures. Unlike IsConnected it does not ping, nor
+// wait for a statement that is running: it tells how the last one left the
+// connection.
+func (d *DB) IsClosed() bool {
+	return d.closed.Load()
+}
+
+// WithConn calls fn with the connection, which nothing else uses until fn
+// returns, for work such as a transaction that takes several calls to it.
+// fn must not call other methods of d.
+func (d *DB) WithConn(fn func(conn *pgx.Conn) error) error {
+	d.mu.Lock()
+	defer d.unlock()
+	return fn(d.conn)
+}
+
+// ServerInfo returns the server's version() string and the effective role
+// (current_user), which may differ from the user in the connection string.
+func (d *DB) ServerInfo() (version string, currentUser string, err error) {
+	d.mu.Lock()
+	defer d.unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err = d.conn.QueryRow(ctx, "SELECT version(), current_user").Scan(&version, &currentUser)
+	if err != nil {
+		return "", "", fmt.Errorf("server info: %w", err)
+	}
+	return version, currentUser, nil
 }
 
 // ConnInfo returns a display-safe connection string (no password).
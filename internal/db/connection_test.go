@@ -0,0 +1,52 @@
+package db
+
+import "testing"
+
+func TestValidateURI(t *testing.T) {
+	tests := []struct {
+		uri     string
+		wantErr bool
+	}{
+		{uri: "postgres://me@localhost:5432/app"},
+		{uri: "postgresql://me:pw@db.example.com/app?sslmode=require"},
+		{uri: "postgres:///app"},
+		{uri: "postgres:///app?host=/var/run/postgresql"},
+		{uri: "postgres://me@/app"},
+		{uri: "postgres://a:5432,b:5433/app"},
+		{uri: "postgres://me:pw@a,b:6432,[::1]:5432/app?target_session_attrs=read-write"},
+		{uri: "postgres://[::1]/app"},
+		{uri: "mysql://localhost/app", wantErr: true},
+		{uri: "localhost/app", wantErr: true},
+		{uri: "postgres://a:5432,b:99999/app", wantErr: true},
+		{uri: "postgres://a:x/app", wantErr: true},
+		{uri: "postgres://[::1/app", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			err := ValidateURI(tt.uri)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateURI(%q) = %v, want error %v", tt.uri, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWithDatabase(t *testing.T) {
+	tests := []struct {
+		uri, database, want string
+	}{
+		{"postgres://me:pw@localhost:5432/app?sslmode=prefer", "other", "postgres://me:pw@localhost:5432/other?sslmode=prefer"},
+		{"postgres://me@a:5432,b:5433/app?sslmode=verify-full&sslrootcert=/ca.pem", "postgres", "postgres://me@a:5432,b:5433/postgres?sslmode=verify-full&sslrootcert=/ca.pem"},
+		{"postgres:///app?host=/var/run/postgresql", "other", "postgres:///other?host=/var/run/postgresql"},
+		{"postgres://localhost?sslmode=disable", "other", "postgres://localhost/other?sslmode=disable"},
+		{"postgres://localhost", "my db", "postgres://localhost/my%20db"},
+		{"postgres://localhost/app#frag", "other", "postgres://localhost/other#frag"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			if got := withDatabase(tt.uri, tt.database); got != tt.want {
+				t.Errorf("withDatabase(%q, %q) = %q, want %q", tt.uri, tt.database, got, tt.want)
+			}
+		})
+	}
+}
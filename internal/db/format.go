@@ -0,0 +1,127 @@
+package db
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// rangeElemTypes maps the built-in range types to their element types.
+var rangeElemTypes = map[string]string{
+	"int4range": "int4", "int8range": "int8", "numrange": "numeric",
+	"tsrange": "timestamp", "tstzrange": "timestamptz", "daterange": "date",
+}
+
+// FormatValue renders a value decoded by pgx in PostgreSQL's own text
+// syntax, so that the displayed form is also valid input when edited:
+// arrays as {1,2,3}, bytea as \x..., ranges as [1,5) and timestamps in ISO
+// form. typeName is the column type as given by oidToTypeName.
+func FormatValue(typeName string, v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "<NULL>"
+	case string:
+		return v
+	case []byte:
+		return `\x` + hex.EncodeToString(v)
+	case [16]byte:
+		return fmt.Sprintf("%x-%x-%x-%x-%x", v[0:4], v[4:6], v[6:8], v[8:10], v[10:16])
+	case time.Time:
+		return formatTime(typeName, v)
+	case pgtype.Range[interface{}]:
+		return formatRange(rangeElemTypes[typeName], v)
+	case []interface{}:
+		if typeName == "json" || typeName == "jsonb" {
+			return formatJSON(v)
+		}
+		return formatArray(strings.TrimSuffix(typeName, "[]"), v)
+	case map[string]interface{}:
+		return formatJSON(v)
+	case driver.Valuer:
+		// numeric, interval, time and the like encode their own text form.
+		if dv, err := v.Value(); err == nil {
+			if s, ok := dv.(string); ok {
+				return s
+			}
+		}
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+func formatTime(typeName string, t time.Time) string {
+	switch typeName {
+	case "date":
+		return t.Format("2006-01-02")
+	case "timestamp":
+		return t.Format("2006-01-02 15:04:05.999999")
+	}
+	return t.Format("2006-01-02 15:04:05.999999-07:00")
+}
+
+func formatJSON(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
+
+// formatArray renders elems as an array literal, quoting elements that
+// would otherwise be misread and writing NULL for nil ones.
+func formatArray(elemType string, elems []interface{}) string {
+	parts := make([]string, len(elems))
+	for i, e := range elems {
+		if e == nil {
+			parts[i] = "NULL"
+			continue
+		}
+		parts[i] = quoteArrayElem(FormatValue(elemType, e))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func quoteArrayElem(s string) string {
+	if s != "" && !strings.EqualFold(s, "NULL") && !strings.ContainsAny(s, "{},\"\\ \t\n") {
+		return s
+	}
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+func formatRange(elemType string, r pgtype.Range[interface{}]) string {
+	if r.LowerType == pgtype.Empty {
+		return "empty"
+	}
+	var b strings.Builder
+	if r.LowerType == pgtype.Inclusive {
+		b.WriteByte('[')
+	} else {
+		b.WriteByte('(')
+	}
+	if r.LowerType != pgtype.Unbounded {
+		b.WriteString(quoteRangeBound(FormatValue(elemType, r.Lower)))
+	}
+	b.WriteByte(',')
+	if r.UpperType != pgtype.Unbounded {
+		b.WriteString(quoteRangeBound(FormatValue(elemType, r.Upper)))
+	}
+	if r.UpperType == pgtype.Inclusive {
+		b.WriteByte(']')
+	} else {
+		b.WriteByte(')')
+	}
+	return b.String()
+}
+
+func quoteRangeBound(s string) string {
+	if !strings.ContainsAny(s, "()[],\"\\ ") {
+		return s
+	}
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
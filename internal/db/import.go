@@ -0,0 +1,104 @@
+package db
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// CopyFromCSV bulk-loads the CSV file at path into tableName with COPY ...
+// FROM STDIN, streaming the file rather than reading it into memory. The
+// first line must be a header naming a table column for each CSV column,
+// matched exactly or else ignoring case; columns left out get their
+// defaults. Unquoted empty fields load as NULL and quoted ones ("") as empty
+// strings, as export.WriteCSV writes them.
+// It returns the number of rows loaded. COPY is a single statement, so a
+// bad row loads nothing. A big file can take long to load, so it runs until
+// ctx ends rather than within the statement timeout.
+func (d *DB) CopyFromCSV(ctx context.Context, tableName string, path string) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	header, err := csv.NewReader(f).Read()
+	if err == io.EOF {
+		return 0, fmt.Errorf("%s is empty", path)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("read header of %s: %w", path, err)
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff") // a byte order mark
+	}
+
+	cols, err := d.GetColumns(tableName)
+	if err != nil {
+		return 0, fmt.Errorf("columns of %s: %w", tableName, err)
+	}
+	targets, err := matchColumns(header, cols)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", path, err)
+	}
+
+	// The server skips the header line itself, so hand it the whole file.
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	sql := fmt.Sprintf("COPY %s (%s) FROM STDIN WITH (FORMAT csv, HEADER true)",
+		QuoteIdentifier(tableName), strings.Join(targets, ", "))
+
+	d.mu.Lock()
+	defer d.unlock()
+	tx, err := d.conn.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+	if _, err := tx.Exec(ctx, noStatementTimeout); err != nil {
+		return 0, err
+	}
+	tag, err := d.conn.PgConn().CopyFrom(ctx, f, sql)
+	if err != nil {
+		return 0, fmt.Errorf("copy into %s: %w", tableName, err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// matchColumns pairs each CSV header name with a column of the table and
+// returns the quoted column names, in header order.
+func matchColumns(header []string, cols []ColumnInfo) ([]string, error) {
+	targets := make([]string, len(header))
+	used := make(map[string]bool, len(header))
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		match := ""
+		for _, c := range cols {
+			if c.Name == name {
+				match = c.Name
+				break
+			}
+			if match == "" && strings.EqualFold(c.Name, name) {
+				match = c.Name
+			}
+		}
+		if match == "" {
+			return nil, fmt.Errorf("CSV column %q matches no column of the table", name)
+		}
+		if used[match] {
+			return nil, fmt.Errorf("CSV column %q appears twice", name)
+		}
+		used[match] = true
+		targets[i] = pgx.Identifier{match}.Sanitize()
+	}
+	return targets, nil
+}
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// maxNotices bounds how many notices are kept between TakeNotices calls, so
+// a RAISE NOTICE in a long loop can't exhaust memory.
+const maxNotices = 1000
+
+// Notice is a NOTICE, WARNING or other message the server sent while running
+// a statement, such as the output of RAISE NOTICE.
+type Notice struct {
+	Severity string
+	Message  string
+	Detail   string
+	Hint     string
+}
+
+// String renders n the way psql does: "NOTICE:  message", followed by
+// DETAIL and HINT lines when present.
+func (n Notice) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s:  %s", n.Severity, n.Message)
+	if n.Detail != "" {
+		fmt.Fprintf(&b, "\nDETAIL:  %s", n.Detail)
+	}
+	if n.Hint != "" {
+		fmt.Fprintf(&b, "\nHINT:  %s", n.Hint)
+	}
+	return b.String()
+}
+
+// onNotice collects a notice from the connection. pgx calls it while a
+// statement is running.
+func (d *DB) onNotice(_ *pgconn.PgConn, n *pgconn.Notice) {
+	d.noticesMu.Lock()
+	defer d.noticesMu.Unlock()
+	if len(d.notices) >= maxNotices {
+		d.noticesDropped++
+		return
+	}
+	d.notices = append(d.notices, Notice{
+		Severity: n.Severity,
+		Message:  n.Message,
+		Detail:   n.Detail,
+		Hint:     n.Hint,
+	})
+}
+
+// TakeNotices returns the notices received since it was last called and
+// forgets them.
+func (d *DB) TakeNotices() []Notice {
+	d.noticesMu.Lock()
+	defer d.noticesMu.Unlock()
+	notices := d.notices
+	if d.noticesDropped > 0 {
+		notices = append(notices, Notice{
+			Severity: "INFO",
+			Message:  fmt.Sprintf("%d further messages were not kept", d.noticesDropped),
+		})
+	}
+	d.notices = nil
+	d.noticesDropped = 0
+	return notices
+}
@@ -0,0 +1,97 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// profileSampleRows is about how many rows ProfileTable reads at most; a
+// table estimated to be bigger is sampled down to it.
+const profileSampleRows = 100000
+
+// ColumnProfile is how complete and how varied one column of a table is.
+type ColumnProfile struct {
+	Name     string
+	DataType string
+	Nulls    int64
+	Distinct int64 // distinct non-null values, compared as text
+}
+
+// TableProfile is the column profiles of a table, over Rows rows: all of
+// them, or a sample when Sampled is set.
+type TableProfile struct {
+	Rows    int64
+	Sampled bool
+	Columns []ColumnProfile
+}
+
+// ProfileTable counts the nulls and distinct values of every column of a
+// table in one pass. Tables estimated at more than profileSampleRows rows
+// are read through TABLESAMPLE SYSTEM, so the counts are approximate.
+func (d *DB) ProfileTable(tableName string) (*TableProfile, error) {
+	cols, err := d.GetColumns(tableName)
+	if err != nil {
+		return nil, err
+	}
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("table %s has no columns", tableName)
+	}
+
+	d.mu.Lock()
+	defer d.unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), d.StatementTimeout())
+	defer cancel()
+
+	// reltuples is -1 for a table never vacuumed or analyzed; read it all.
+	var estimate float64
+	if err := d.conn.QueryRow(ctx, `SELECT coalesce((SELECT reltuples FROM pg_class WHERE oid = to_regclass($1)), -1)::float8`,
+		QuoteIdentifier(tableName)).Scan(&estimate); err != nil {
+		return nil, err
+	}
+	sample := ""
+	if estimate > profileSampleRows {
+		sample = fmt.Sprintf(" TABLESAMPLE SYSTEM (%.4f)", 100*profileSampleRows/estimate)
+	}
+
+	// Casting to text lets json, xml and other types without equality be
+	// counted as well.
+	exprs := []string{"count(*)"}
+	for _, c := range cols {
+		col := pgx.Identifier{c.Name}.Sanitize()
+		exprs = append(exprs,
+			fmt.Sprintf("count(*) FILTER (WHERE %s IS NULL)", col),
+			fmt.Sprintf("count(DISTINCT %s::text)", col))
+	}
+	sql := fmt.Sprintf("SELECT %s FROM %s%s", strings.Join(exprs, ", "), QuoteIdentifier(tableName), sample)
+
+	p := &TableProfile{Sampled: sample != "", Columns: make([]ColumnProfile, len(cols))}
+	dest := []interface{}{&p.Rows}
+	for i, c := range cols {
+		p.Columns[i] = ColumnProfile{Name: c.Name, DataType: c.DataType}
+		dest = append(dest, &p.Columns[i].Nulls, &p.Columns[i].Distinct)
+	}
+	if err := d.conn.QueryRow(ctx, sql).Scan(dest...); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// CountRows counts the rows of a table exactly, however long that takes up
+// to the statement timeout. CancelQuery stops it. It is refused while the
+// connection is taken, rather than queued behind a statement that may run
+// as long.
+func (d *DB) CountRows(tableName string) (int64, error) {
+	if !d.mu.TryLock() {
+		return 0, errConnBusy
+	}
+	defer d.unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), d.StatementTimeout())
+	defer cancel()
+
+	var n int64
+	err := d.conn.QueryRow(ctx, "SELECT count(*) FROM "+QuoteIdentifier(tableName)).Scan(&n)
+	return n, err
+}
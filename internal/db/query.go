@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"strings"
 	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 // QueryResult holds the result of a SELECT-like query.
@@ -12,16 +15,73 @@ type QueryResult struct {
 	Columns     []string
 	ColumnTypes []string
 	Rows        [][]string
+	RawRows     [][]interface{} // values as decoded by pgx, parallel to Rows
+	Nulls       [][]bool        // true where the value is SQL NULL, parallel to Rows
+	Sources     []ColumnSource  // where each column was read from, parallel to Columns
 	RowCount    int
 	ExecTime    time.Duration
 }
 
+// ColumnSource is the table column a result column was read from, as the
+// server reports it; both are zero for a column computed by an expression.
+type ColumnSource struct {
+	Table  uint32 // OID of the table
+	Column uint16 // attribute number of the column in it
+}
+
 // ExecResult holds the result of a DML query.
 type ExecResult struct {
-	RowsAffected int64
+	RowsAffected int64    // as reported for the last statement
+	Tags         []string // the command tag of each statement, e.g. "DELETE 5"
 	ExecTime     time.Duration
 }
 
+// QuoteIdentifier quotes a table name for interpolation into SQL. A
+// schema-qualified name such as public.users is quoted per part
+// ("public"."users"); dots inside double quotes are part of the name.
+func QuoteIdentifier(name string) string {
+	return pgx.Identifier(splitIdentifier(name)).Sanitize()
+}
+
+// QuoteLiteral quotes s as an SQL string literal.
+func QuoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// splitIdentifier splits a possibly schema-qualified name into its unquoted parts.
+func splitIdentifier(name string) []string {
+	var parts []string
+	var cur strings.Builder
+	inQuote := false
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case c == '"' && inQuote && i+1 < len(name) && name[i+1] == '"':
+			cur.WriteByte('"')
+			i++
+		case c == '"':
+			inQuote = !inQuote
+		case c == '.' && !inQuote:
+			parts = append(parts, cur.String())
+			cur.Reset()
+		default:
+			cur.WriteByte(c)
+		}
+	}
+	parts = append(parts, cur.String())
+	return parts
+}
+
+// splitTableName returns the schema and table of a possibly qualified name,
+// defaulting the schema to public.
+func splitTableName(name string) (string, string) {
+	parts := splitIdentifier(name)
+	if len(parts) >= 2 {
+		return parts[len(parts)-2], parts[len(parts)-1]
+	}
+	return "public", parts[0]
+}
+
 // isSelectLike returns true if the query returns rows.
 func isSelectLike(sql string) bool {
 	upper := strings.ToUpper(strings.TrimSpace(sql))
@@ -32,15 +92,41 @@ func isSelectLike(sql string) bool {
 
 // ExecuteQuery runs a SQL query and returns either a QueryResult or ExecResult.
 // The second return value indicates if it was a SELECT-like query.
-func (d *DB) ExecuteQuery(sql string) (*QueryResult, *ExecResult, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
+//
+// If the connection turns out to have dropped, it is re-established and the
+// query retried once, provided that is safe: never inside an open
+// transaction, and only when the statement never reached the server or the
+// caller knows it cannot write, as repeatable says. What the statement
+// starts with is no guide: a SELECT can call nextval and a WITH can delete.
+func (d *DB) ExecuteQuery(sql string, repeatable bool) (*QueryResult, *ExecResult, error) {
 	trimmed := strings.TrimSpace(sql)
 	if trimmed == "" {
 		return nil, nil, fmt.Errorf("empty query")
 	}
 
+	d.mu.Lock()
+	defer d.unlock()
+	inTx := d.inTransaction()
+	qr, er, err := d.executeOnce(trimmed)
+	if err == nil || !d.connClosed() {
+		return qr, er, err
+	}
+	if inTx {
+		return nil, nil, fmt.Errorf("connection lost inside an open transaction, which was rolled back: %w", err)
+	}
+	if !repeatable && !pgconn.SafeToRetry(err) {
+		return nil, nil, err
+	}
+	if rerr := d.reconnect(); rerr != nil {
+		return nil, nil, fmt.Errorf("%w (reconnect failed: %v)", err, rerr)
+	}
+	return d.executeOnce(trimmed)
+}
+
+func (d *DB) executeOnce(trimmed string) (*QueryResult, *ExecResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.StatementTimeout())
+	defer cancel()
+
 	start := time.Now()
 
 	if isSelectLike(trimmed) {
@@ -49,8 +135,42 @@ func (d *DB) ExecuteQuery(sql string) (*QueryResult, *ExecResult, error) {
 	return d.executeDML(ctx, trimmed, start)
 }
 
-func (d *DB) executeSelect(ctx context.Context, sql string, start time.Time) (*QueryResult, *ExecResult, error) {
-	rows, err := d.Conn.Query(ctx, sql)
+// Explain returns the planner's estimated plan for sql, one line per plan
+// node, without executing it: EXPLAIN is run without ANALYZE, so a DELETE
+// or UPDATE changes nothing.
+func (d *DB) Explain(sql string) (string, error) {
+	trimmed := strings.TrimSuffix(strings.TrimSpace(sql), ";")
+	if trimmed == "" {
+		return "", fmt.Errorf("empty query")
+	}
+	if strings.HasPrefix(strings.ToUpper(trimmed), "EXPLAIN") {
+		return "", fmt.Errorf("statement is already an EXPLAIN; run it instead")
+	}
+
+	qr, err := d.QueryArgs("EXPLAIN " + trimmed)
+	if err != nil {
+		return "", fmt.Errorf("explain: %w", err)
+	}
+	lines := make([]string, len(qr.Rows))
+	for i, row := range qr.Rows {
+		lines[i] = strings.Join(row, " ")
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// QueryArgs runs a row-returning query with bound arguments.
+func (d *DB) QueryArgs(sql string, args ...interface{}) (*QueryResult, error) {
+	d.mu.Lock()
+	defer d.unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), d.StatementTimeout())
+	defer cancel()
+
+	qr, _, err := d.executeSelect(ctx, sql, time.Now(), args...)
+	return qr, err
+}
+
+func (d *DB) executeSelect(ctx context.Context, sql string, start time.Time, args ...interface{}) (*QueryResult, *ExecResult, error) {
+	rows, err := d.conn.Query(ctx, sql, args...)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -59,51 +179,246 @@ func (d *DB) executeSelect(ctx context.Context, sql string, start time.Time) (*Q
 	fields := rows.FieldDescriptions()
 	columns := make([]string, len(fields))
 	columnTypes := make([]string, len(fields))
+	sources := make([]ColumnSource, len(fields))
 	for i, f := range fields {
 		columns[i] = f.Name
-		columnTypes[i] = oidToTypeName(f.DataTypeOID)
+		columnTypes[i] = d.typeName(f.DataTypeOID)
+		sources[i] = ColumnSource{Table: f.TableOID, Column: f.TableAttributeNumber}
 	}
 
 	var resultRows [][]string
+	var rawRows [][]interface{}
+	var nulls [][]bool
 	for rows.Next() {
 		values, err := rows.Values()
 		if err != nil {
 			return nil, nil, err
 		}
-		row := make([]string, len(values))
+		resultRows = append(resultRows, displayRow(values, columnTypes))
+		rawRows = append(rawRows, values)
+		nullRow := make([]bool, len(values))
 		for i, v := range values {
-			if v == nil {
-				row[i] = "<NULL>"
-			} else {
-				row[i] = fmt.Sprintf("%v", v)
-			}
+			nullRow[i] = v == nil
 		}
-		resultRows = append(resultRows, row)
+		nulls = append(nulls, nullRow)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, nil, err
 	}
+	rows.Close()
+	// The connection is free again, so look up any types pgx didn't know;
+	// the next query reading them gets the names straight away.
+	if d.lookupTypeNames(ctx, fields) {
+		for i, f := range fields {
+			columnTypes[i] = d.typeName(f.DataTypeOID)
+		}
+	}
 
 	elapsed := time.Since(start)
 	return &QueryResult{
 		Columns:     columns,
 		ColumnTypes: columnTypes,
 		Rows:        resultRows,
+		RawRows:     rawRows,
+		Nulls:       nulls,
+		Sources:     sources,
 		RowCount:    len(resultRows),
 		ExecTime:    elapsed,
 	}, nil, nil
 }
 
+// displayRow renders decoded values as the text shown for them.
+func displayRow(values []interface{}, columnTypes []string) []string {
+	row := make([]string, len(values))
+	for i, v := range values {
+		typeName := ""
+		if i < len(columnTypes) {
+			typeName = columnTypes[i]
+		}
+		row[i] = FormatValue(typeName, v)
+	}
+	return row
+}
+
+// StreamHandler receives a result set as StreamQuery reads it.
+type StreamHandler interface {
+	// Columns is called once, before any row.
+	Columns(columns, columnTypes []string) error
+	// Row is called for each row with its display text and the values as
+	// decoded by pgx, as in QueryResult.Rows and RawRows.
+	Row(row []string, raw []interface{}) error
+}
+
+// StreamQuery runs sql and passes its rows to h one at a time as they arrive,
+// so a large result never has to be held in memory. An error from h stops
+// the query and is returned. A statement that returns no rows is run as by
+// ExecuteQuery and its ExecResult returned instead.
+func (d *DB) StreamQuery(sql string, h StreamHandler) (*ExecResult, error) {
+	trimmed := strings.TrimSpace(sql)
+	if !isSelectLike(trimmed) {
+		_, er, err := d.ExecuteQuery(trimmed, false)
+		return er, err
+	}
+
+	d.mu.Lock()
+	defer d.unlock()
+	inTx := d.inTransaction()
+	started, err := d.streamOnce(trimmed, h)
+	// As in ExecuteQuery, retry a dropped connection, but only while
+	// nothing has been handed to h yet and the statement never reached
+	// the server.
+	if err != nil && !started && !inTx && d.connClosed() && pgconn.SafeToRetry(err) {
+		if rerr := d.reconnect(); rerr != nil {
+			return nil, fmt.Errorf("%w (reconnect failed: %v)", err, rerr)
+		}
+		_, err = d.streamOnce(trimmed, h)
+	}
+	return nil, err
+}
+
+// StreamAll is StreamQuery for reading a whole table, which can take far
+// longer than any one query should: it runs until done or until ctx ends,
+// with the server's statement_timeout lifted for it. sql must return rows.
+func (d *DB) StreamAll(ctx context.Context, sql string, h StreamHandler) error {
+	d.mu.Lock()
+	defer d.unlock()
+	tx, err := d.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	if _, err := tx.Exec(ctx, noStatementTimeout); err != nil {
+		return err
+	}
+	if _, err := d.stream(ctx, tx, sql, h); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// streamOnce runs one attempt of StreamQuery. started reports whether h was
+// called at all.
+func (d *DB) streamOnce(sql string, h StreamHandler) (started bool, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.StatementTimeout())
+	defer cancel()
+	return d.stream(ctx, d.conn, sql, h)
+}
+
+// stream runs sql on q, the connection or a transaction on it, passing the
+// rows to h. started reports whether h was called at all.
+func (d *DB) stream(ctx context.Context, q interface {
+	Query(context.Context, string, ...any) (pgx.Rows, error)
+}, sql string, h StreamHandler) (started bool, err error) {
+	rows, err := q.Query(ctx, sql)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	fields := rows.FieldDescriptions()
+	columns := make([]string, len(fields))
+	columnTypes := make([]string, len(fields))
+	for i, f := range fields {
+		columns[i] = f.Name
+		columnTypes[i] = d.typeName(f.DataTypeOID)
+	}
+	if err := h.Columns(columns, columnTypes); err != nil {
+		return true, err
+	}
+
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return true, err
+		}
+		if err := h.Row(displayRow(values, columnTypes), values); err != nil {
+			return true, err
+		}
+	}
+	return true, rows.Err()
+}
+
+// executeDML runs sql, which may hold several statements, over the simple
+// protocol as pgx's Exec would, keeping every statement's command tag.
 func (d *DB) executeDML(ctx context.Context, sql string, start time.Time) (*QueryResult, *ExecResult, error) {
-	tag, err := d.Conn.Exec(ctx, sql)
+	results, err := d.conn.PgConn().Exec(ctx, sql).ReadAll()
 	if err != nil {
 		return nil, nil, err
 	}
-	elapsed := time.Since(start)
-	return nil, &ExecResult{
-		RowsAffected: tag.RowsAffected(),
-		ExecTime:     elapsed,
-	}, nil
+	er := &ExecResult{ExecTime: time.Since(start)}
+	for _, r := range results {
+		er.Tags = append(er.Tags, r.CommandTag.String())
+		er.RowsAffected = r.CommandTag.RowsAffected()
+	}
+	return nil, er, nil
+}
+
+// typeName returns the name of the type with the given OID: the name pgx's
+// type map gives it, with arrays written as "int4[]"; else one from
+// oidToTypeName; else the name looked up from the catalog by
+// lookupTypeNames, or "oid:N" until that has happened. The caller holds mu.
+func (d *DB) typeName(oid uint32) string {
+	if d.conn != nil {
+		if t, ok := d.conn.TypeMap().TypeForOID(oid); ok {
+			if strings.HasPrefix(t.Name, "_") {
+				return t.Name[1:] + "[]"
+			}
+			return t.Name
+		}
+	}
+	name := oidToTypeName(oid)
+	if !strings.HasPrefix(name, "oid:") {
+		return name
+	}
+	d.typeNamesMu.Lock()
+	defer d.typeNamesMu.Unlock()
+	if catalog, ok := d.typeNames[oid]; ok {
+		return catalog
+	}
+	return name
+}
+
+// lookupTypeNames fetches from pg_type the names of any types among fields
+// that typeName can't resolve, such as enums and extension types, caching
+// them. It reports whether it learned any names. Failures are ignored: the
+// type column just stays "oid:N".
+func (d *DB) lookupTypeNames(ctx context.Context, fields []pgconn.FieldDescription) bool {
+	var unknown []uint32
+	for _, f := range fields {
+		if strings.HasPrefix(d.typeName(f.DataTypeOID), "oid:") {
+			unknown = append(unknown, f.DataTypeOID)
+		}
+	}
+	if len(unknown) == 0 {
+		return false
+	}
+
+	rows, err := d.conn.Query(ctx, `SELECT oid, format_type(oid, NULL) FROM pg_type WHERE oid = ANY($1)`, unknown)
+	if err != nil {
+		return false
+	}
+	defer rows.Close()
+	found := make(map[uint32]string)
+	for rows.Next() {
+		var oid uint32
+		var name string
+		if rows.Scan(&oid, &name) == nil {
+			found[oid] = name
+		}
+	}
+	if rows.Err() != nil || len(found) == 0 {
+		return false
+	}
+
+	d.typeNamesMu.Lock()
+	defer d.typeNamesMu.Unlock()
+	if d.typeNames == nil {
+		d.typeNames = make(map[uint32]string)
+	}
+	for oid, name := range found {
+		d.typeNames[oid] = name
+	}
+	return true
 }
 
 // oidToTypeName maps common PostgreSQL OIDs to human-readable type names.
@@ -141,6 +456,52 @@ func oidToTypeName(oid uint32) string {
 		return "jsonb"
 	case 114:
 		return "json"
+	case 17:
+		return "bytea"
+	case 1083:
+		return "time"
+	case 1186:
+		return "interval"
+	case 3904:
+		return "int4range"
+	case 3926:
+		return "int8range"
+	case 3906:
+		return "numrange"
+	case 3908:
+		return "tsrange"
+	case 3910:
+		return "tstzrange"
+	case 3912:
+		return "daterange"
+	case 1000:
+		return "bool[]"
+	case 1005:
+		return "int2[]"
+	case 1007:
+		return "int4[]"
+	case 1016:
+		return "int8[]"
+	case 1009:
+		return "text[]"
+	case 1015:
+		return "varchar[]"
+	case 1021:
+		return "float4[]"
+	case 1022:
+		return "float8[]"
+	case 1231:
+		return "numeric[]"
+	case 1182:
+		return "date[]"
+	case 1115:
+		return "timestamp[]"
+	case 1185:
+		return "timestamptz[]"
+	case 2951:
+		return "uuid[]"
+	case 3807:
+		return "jsonb[]"
 	default:
 		return fmt.Sprintf("oid:%d", oid)
 	}
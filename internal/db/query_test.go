@@ -0,0 +1,45 @@
+package db
+
+import "testing"
+
+func TestQuoteIdentifier(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"users", `"users"`},
+		{"sales.orders", `"sales"."orders"`},
+		{`"Sales"."Orders"`, `"Sales"."Orders"`},
+		{`"a.b"`, `"a.b"`},
+		{`sales."a.b"`, `"sales"."a.b"`},
+		{`"say ""hi"""`, `"say ""hi"""`},
+		{`db.sales.orders`, `"db"."sales"."orders"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := QuoteIdentifier(tt.name); got != tt.want {
+				t.Errorf("QuoteIdentifier(%s) = %s, want %s", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitTableName(t *testing.T) {
+	tests := []struct {
+		name, schema, table string
+	}{
+		{"users", "public", "users"},
+		{"sales.orders", "sales", "orders"},
+		{`"My Schema"."a.b"`, "My Schema", "a.b"},
+		{`"a.b"`, "public", "a.b"},
+		{"db.sales.orders", "sales", "orders"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, table := splitTableName(tt.name)
+			if schema != tt.schema || table != tt.table {
+				t.Errorf("splitTableName(%s) = %q, %q, want %q, %q", tt.name, schema, table, tt.schema, tt.table)
+			}
+		})
+	}
+}
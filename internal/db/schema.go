@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -12,14 +13,23 @@ type ColumnInfo struct {
 	DataType      string
 	IsNullable    string
 	ColumnDefault *string
+	Comment       string // set with COMMENT ON COLUMN; "" if none
+}
+
+// IsAutoIncrement reports whether the column is filled from a sequence, as
+// serial columns are.
+func (c ColumnInfo) IsAutoIncrement() bool {
+	return c.ColumnDefault != nil && strings.HasPrefix(*c.ColumnDefault, "nextval(")
 }
 
 // ListDatabases returns all databases sorted by name.
 func (d *DB) ListDatabases() ([]string, error) {
+	d.mu.Lock()
+	defer d.unlock()
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	rows, err := d.Conn.Query(ctx, `
+	rows, err := d.conn.Query(ctx, `
 		SELECT datname
 		FROM pg_database
 		WHERE datistemplate = false
@@ -44,31 +54,48 @@ func (d *DB) ListDatabases() ([]string, error) {
 // CopyDatabase creates a new database using an existing one as a template.
 // PostgreSQL requires no active connections to the template, so if currently
 // connected to the source database the method temporarily switches to "postgres".
-func (d *DB) CopyDatabase(source, target string) error {
+// Cancelling ctx stops the copy; either way it ends connected to the
+// database it started on, if that can be reached.
+func (d *DB) CopyDatabase(ctx context.Context, source, target string) error {
+	d.mu.Lock()
+	defer d.unlock()
 	previousDB := d.database
 	if previousDB == source {
-		if err := d.SwitchDatabase("postgres"); err != nil {
+		if err := d.switchDatabase("postgres"); err != nil {
 			return fmt.Errorf("switch to postgres: %w", err)
 		}
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
 	defer cancel()
 
 	sql := fmt.Sprintf(
 		`CREATE DATABASE %q WITH TEMPLATE %q OWNER %q`,
 		target, source, d.user,
 	)
-	_, err := d.Conn.Exec(ctx, sql)
+	// CREATE DATABASE can't run in a transaction, so the statement timeout
+	// is lifted for the session and put back after.
+	_, err := d.conn.Exec(ctx, "SET statement_timeout = 0")
+	if err == nil {
+		_, err = d.conn.Exec(ctx, sql)
+		if !d.conn.IsClosed() {
+			resetCtx, cancelReset := context.WithTimeout(context.Background(), 5*time.Second)
+			d.conn.Exec(resetCtx, "RESET statement_timeout")
+			cancelReset()
+		}
+	}
 	if err != nil {
-		if previousDB == source {
-			d.SwitchDatabase(previousDB)
+		// A cancelled statement can take the connection down with it.
+		if previousDB == source || d.conn.IsClosed() {
+			if swErr := d.switchDatabase(previousDB); swErr != nil {
+				return fmt.Errorf("%w; reconnect to %s: %v", err, previousDB, swErr)
+			}
 		}
 		return err
 	}
 
 	if previousDB == source {
-		if err := d.SwitchDatabase(previousDB); err != nil {
+		if err := d.switchDatabase(previousDB); err != nil {
 			return fmt.Errorf("switch back to %s: %w", previousDB, err)
 		}
 	}
@@ -76,29 +103,40 @@ func (d *DB) CopyDatabase(source, target string) error {
 }
 
 // DropDatabase drops a database. If currently connected to it, switches to "postgres" first.
-// After dropping, if we were on the dropped DB we stay on "postgres".
-func (d *DB) DropDatabase(name string) error {
-	wasOnTarget := d.database == name
+// After dropping, if we were on the dropped DB we stay on "postgres". If the
+// drop fails or ctx is cancelled, it goes back to the database it started on.
+func (d *DB) DropDatabase(ctx context.Context, name string) error {
+	d.mu.Lock()
+	defer d.unlock()
+	previousDB := d.database
+	wasOnTarget := previousDB == name
 	if wasOnTarget {
-		if err := d.SwitchDatabase("postgres"); err != nil {
+		if err := d.switchDatabase("postgres"); err != nil {
 			return fmt.Errorf("switch to postgres: %w", err)
 		}
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	sql := fmt.Sprintf(`DROP DATABASE %q`, name)
-	_, err := d.Conn.Exec(ctx, sql)
+	_, err := d.conn.Exec(ctx, sql)
+	if err != nil && (wasOnTarget || d.conn.IsClosed()) {
+		if swErr := d.switchDatabase(previousDB); swErr != nil {
+			return fmt.Errorf("%w; reconnect to %s: %v", err, previousDB, swErr)
+		}
+	}
 	return err
 }
 
 // ListTables returns all public base tables sorted by name.
 func (d *DB) ListTables() ([]string, error) {
+	d.mu.Lock()
+	defer d.unlock()
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	rows, err := d.Conn.Query(ctx, `
+	rows, err := d.conn.Query(ctx, `
 		SELECT table_name
 		FROM information_schema.tables
 		WHERE table_schema = 'public'
@@ -121,12 +159,62 @@ func (d *DB) ListTables() ([]string, error) {
 	return tables, rows.Err()
 }
 
-// GetPrimaryKeys returns the primary key column names for a table.
+// ListTableComments returns the comments set with COMMENT ON TABLE on the
+// tables ListTables lists, keyed by table name. Tables without one are left out.
+func (d *DB) ListTableComments() (map[string]string, error) {
+	d.mu.Lock()
+	defer d.unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := d.conn.Query(ctx, `
+		SELECT c.relname, obj_description(c.oid, 'pg_class')
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = 'public'
+		  AND c.relkind IN ('r', 'p')
+		  AND obj_description(c.oid, 'pg_class') IS NOT NULL
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := make(map[string]string)
+	for rows.Next() {
+		var name, comment string
+		if err := rows.Scan(&name, &comment); err != nil {
+			return nil, err
+		}
+		comments[name] = comment
+	}
+	return comments, rows.Err()
+}
+
+// GetTableComment returns the comment set on a table, or "" if it has none.
+func (d *DB) GetTableComment(tableName string) (string, error) {
+	d.mu.Lock()
+	defer d.unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var comment string
+	err := d.conn.QueryRow(ctx,
+		`SELECT coalesce(obj_description(to_regclass($1), 'pg_class'), '')`,
+		QuoteIdentifier(tableName)).Scan(&comment)
+	return comment, err
+}
+
+// GetPrimaryKeys returns the primary key column names for a table. The name
+// may be schema-qualified; unqualified names are looked up in public.
 func (d *DB) GetPrimaryKeys(tableName string) ([]string, error) {
+	schema, table := splitTableName(tableName)
+	d.mu.Lock()
+	defer d.unlock()
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	rows, err := d.Conn.Query(ctx, `
+	rows, err := d.conn.Query(ctx, `
 		SELECT kcu.column_name
 		FROM information_schema.table_constraints tc
 		JOIN information_schema.key_column_usage kcu
@@ -134,9 +222,9 @@ func (d *DB) GetPrimaryKeys(tableName string) ([]string, error) {
 		  AND tc.table_schema = kcu.table_schema
 		WHERE tc.constraint_type = 'PRIMARY KEY'
 		  AND tc.table_name = $1
-		  AND tc.table_schema = 'public'
+		  AND tc.table_schema = $2
 		ORDER BY kcu.ordinal_position
-	`, tableName)
+	`, table, schema)
 	if err != nil {
 		return nil, err
 	}
@@ -153,18 +241,57 @@ func (d *DB) GetPrimaryKeys(tableName string) ([]string, error) {
 	return pks, rows.Err()
 }
 
+// ColumnSources returns where each of the named columns of a table is, as
+// a result column read from it reports in QueryResult.Sources. Names the
+// table has no column for are left out.
+func (d *DB) ColumnSources(tableName string, columns []string) (map[string]ColumnSource, error) {
+	d.mu.Lock()
+	defer d.unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := d.conn.Query(ctx, `
+		SELECT a.attname, a.attrelid, a.attnum
+		FROM pg_attribute a
+		WHERE a.attrelid = to_regclass($1)
+		  AND a.attname = ANY($2)
+		  AND NOT a.attisdropped
+	`, QuoteIdentifier(tableName), columns)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sources := make(map[string]ColumnSource)
+	for rows.Next() {
+		var name string
+		var table uint32
+		var attnum int16
+		if err := rows.Scan(&name, &table, &attnum); err != nil {
+			return nil, err
+		}
+		sources[name] = ColumnSource{Table: table, Column: uint16(attnum)}
+	}
+	return sources, rows.Err()
+}
+
 // GetColumns returns column metadata for a table.
 func (d *DB) GetColumns(tableName string) ([]ColumnInfo, error) {
+	schema, table := splitTableName(tableName)
+	d.mu.Lock()
+	defer d.unlock()
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	rows, err := d.Conn.Query(ctx, `
-		SELECT column_name, data_type, is_nullable, column_default
+	rows, err := d.conn.Query(ctx, `
+		SELECT column_name, data_type, is_nullable, column_default,
+		       coalesce(col_description((quote_ident(table_schema) || '.' || quote_ident(table_name))::regclass,
+		                                ordinal_position::int), '')
 		FROM information_schema.columns
 		WHERE table_name = $1
-		  AND table_schema = 'public'
+		  AND table_schema = $2
 		ORDER BY ordinal_position
-	`, tableName)
+	`, table, schema)
 	if err != nil {
 		return nil, err
 	}
@@ -173,10 +300,133 @@ func (d *DB) GetColumns(tableName string) ([]ColumnInfo, error) {
 	var cols []ColumnInfo
 	for rows.Next() {
 		var c ColumnInfo
-		if err := rows.Scan(&c.Name, &c.DataType, &c.IsNullable, &c.ColumnDefault); err != nil {
+		if err := rows.Scan(&c.Name, &c.DataType, &c.IsNullable, &c.ColumnDefault, &c.Comment); err != nil {
 			return nil, err
 		}
 		cols = append(cols, c)
 	}
 	return cols, rows.Err()
 }
+
+// ForeignKey describes a foreign-key constraint. Columns and RefColumns are
+// parallel; both have more than one entry for a composite key.
+type ForeignKey struct {
+	Name       string
+	Table      string
+	Columns    []string
+	RefTable   string
+	RefColumns []string
+}
+
+// GetForeignKeys returns the foreign keys defined on a table.
+func (d *DB) GetForeignKeys(tableName string) ([]ForeignKey, error) {
+	return d.queryForeignKeys("n.nspname = $2 AND c.relname = $1", tableName)
+}
+
+// GetReferencingKeys returns the foreign keys in other tables (or the same
+// one) that reference a table.
+func (d *DB) GetReferencingKeys(tableName string) ([]ForeignKey, error) {
+	return d.queryForeignKeys("nf.nspname = $2 AND cf.relname = $1", tableName)
+}
+
+// queryForeignKeys returns the foreign keys matching where, which filters on
+// the referencing table (n, c) or the referenced table (nf, cf) using $1 for
+// the table name and $2 for its schema.
+func (d *DB) queryForeignKeys(where, tableName string) ([]ForeignKey, error) {
+	schema, table := splitTableName(tableName)
+	d.mu.Lock()
+	defer d.unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := d.conn.Query(ctx, `
+		SELECT con.conname, n.nspname, c.relname, a.attname, nf.nspname, cf.relname, af.attname
+		FROM pg_constraint con
+		JOIN pg_class c ON c.oid = con.conrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_class cf ON cf.oid = con.confrelid
+		JOIN pg_namespace nf ON nf.oid = cf.relnamespace
+		CROSS JOIN LATERAL unnest(con.conkey, con.confkey) WITH ORDINALITY AS k(attnum, fattnum, ord)
+		JOIN pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.attnum
+		JOIN pg_attribute af ON af.attrelid = con.confrelid AND af.attnum = k.fattnum
+		WHERE con.contype = 'f' AND `+where+`
+		ORDER BY n.nspname, c.relname, con.conname, k.ord
+	`, table, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var fks []ForeignKey
+	for rows.Next() {
+		var name, schema, table, col, refSchema, refTable, refCol string
+		if err := rows.Scan(&name, &schema, &table, &col, &refSchema, &refTable, &refCol); err != nil {
+			return nil, err
+		}
+		table = qualifiedName(schema, table)
+		if n := len(fks); n == 0 || fks[n-1].Name != name || fks[n-1].Table != table {
+			fks = append(fks, ForeignKey{Name: name, Table: table, RefTable: qualifiedName(refSchema, refTable)})
+		}
+		fk := &fks[len(fks)-1]
+		fk.Columns = append(fk.Columns, col)
+		fk.RefColumns = append(fk.RefColumns, refCol)
+	}
+	return fks, rows.Err()
+}
+
+// qualifiedName returns table as listed in the sidebar: bare for public,
+// schema-qualified otherwise.
+func qualifiedName(schema, table string) string {
+	if schema == "public" {
+		return table
+	}
+	return schema + "." + table
+}
+
+// IndexInfo describes an index on a table. Columns holds the key columns in
+// order, an expression standing in for an expression column.
+type IndexInfo struct {
+	Name      string
+	Columns   []string
+	Unique    bool
+	Primary   bool
+	Method    string // btree, hash, gin, gist, brin ...
+	Predicate string // WHERE clause of a partial index, or ""
+}
+
+// GetIndexes returns the indexes of a table, the primary key's first.
+func (d *DB) GetIndexes(tableName string) ([]IndexInfo, error) {
+	schema, table := splitTableName(tableName)
+	d.mu.Lock()
+	defer d.unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := d.conn.Query(ctx, `
+		SELECT i.relname, ix.indisunique, ix.indisprimary, am.amname,
+		       ARRAY(SELECT pg_get_indexdef(ix.indexrelid, k, true)
+		             FROM generate_series(1, ix.indnkeyatts) AS k ORDER BY k),
+		       coalesce(pg_get_expr(ix.indpred, ix.indrelid, true), '')
+		FROM pg_index ix
+		JOIN pg_class c ON c.oid = ix.indrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_class i ON i.oid = ix.indexrelid
+		JOIN pg_am am ON am.oid = i.relam
+		WHERE c.relname = $1 AND n.nspname = $2
+		ORDER BY ix.indisprimary DESC, i.relname
+	`, table, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var indexes []IndexInfo
+	for rows.Next() {
+		var ix IndexInfo
+		if err := rows.Scan(&ix.Name, &ix.Unique, &ix.Primary, &ix.Method, &ix.Columns, &ix.Predicate); err != nil {
+			return nil, err
+		}
+		indexes = append(indexes, ix)
+	}
+	return indexes, rows.Err()
+}
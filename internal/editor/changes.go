@@ -2,22 +2,43 @@ package editor
 
 import (
 	"fmt"
+	"slices"
+	"sort"
 	"strings"
+
+	"cli-sql/internal/db"
 )
 
 // OpType represents the type of a staged change.
 type OpType int
 
+// NullValue is the sentinel used for SQL NULL in result rows and staged changes.
+// An empty string is a real empty string, not NULL. It starts with a NUL
+// byte, which no PostgreSQL text value can hold, so it never collides with
+// data such as the text "<NULL>".
+const NullValue = "\x00NULL"
+
+// DefaultValue marks an inserted cell left for the database to fill in, e.g.
+// a serial column. Such cells are omitted from the INSERT. Like NullValue it
+// can't be real data.
+const DefaultValue = "\x00DEFAULT"
+
 const (
 	OpEdit OpType = iota
 	OpDelete
 	OpInsert
+	// OpAddRow is a row added in the results grid, which keeps it until
+	// commit. The tracker only records it for Undo and Redo; the grid
+	// takes the row away and puts it back.
+	OpAddRow
 )
 
 // CellEdit represents a staged cell modification.
 type CellEdit struct {
 	TableName   string
 	RowPKValues map[string]string
+	RowPKRaw    map[string]interface{} // PK values as read from the database, if known
+	PKColumns   []string               // key columns in key order; nil sorts them by name
 	ColumnName  string
 	OldValue    string
 	NewValue    string
@@ -27,18 +48,74 @@ type CellEdit struct {
 type RowDelete struct {
 	TableName   string
 	RowPKValues map[string]string
+	RowPKRaw    map[string]interface{} // PK values as read from the database, if known
+	PKColumns   []string               // key columns in key order; nil sorts them by name
 }
 
 // RowInsert represents a staged row insertion.
 type RowInsert struct {
 	TableName string
 	Values    map[string]string
+	Columns   []string // the table's columns in order; nil sorts them by name
 }
 
-// UndoEntry records an operation for undo.
+// UndoEntry records an operation for undo. Edits and deletes are identified
+// by table and primary key rather than slice position, since unstaging a
+// delete shifts the positions of later entries.
 type UndoEntry struct {
+	Type       OpType
+	TableName  string
+	PKValues   map[string]string
+	ColumnName string // edited column, for OpEdit
+	Index      int    // index within Inserts, for OpInsert
+}
+
+// StatementOrigin identifies the staged change a generated statement came from,
+// so a failing statement can be traced back to a row.
+type StatementOrigin struct {
+	Type       OpType
+	TableName  string
+	PKValues   map[string]string // nil for inserts
+	ColumnName string            // set for edits only
+	RowCount   int               // number of rows in a coalesced insert
+}
+
+// String returns a short human description such as "UPDATE users where id=42".
+func (o StatementOrigin) String() string {
+	switch o.Type {
+	case OpEdit:
+		return fmt.Sprintf("UPDATE %s.%s where %s", o.TableName, o.ColumnName, DescribePK(o.PKValues))
+	case OpDelete:
+		return fmt.Sprintf("DELETE %s where %s", o.TableName, DescribePK(o.PKValues))
+	default:
+		if o.RowCount == 1 {
+			return fmt.Sprintf("INSERT %s (1 row)", o.TableName)
+		}
+		return fmt.Sprintf("INSERT %s (%d rows)", o.TableName, o.RowCount)
+	}
+}
+
+// DescribePK lists a primary key as "id=42" or, for a composite one, as
+// "a=1, b=2" in column name order.
+func DescribePK(pk map[string]string) string {
+	cols := make([]string, 0, len(pk))
+	for col := range pk {
+		cols = append(cols, col)
+	}
+	sort.Strings(cols)
+	parts := make([]string, len(cols))
+	for i, col := range cols {
+		parts[i] = col + "=" + pk[col]
+	}
+	return strings.Join(parts, ", ")
+}
+
+// redoEntry holds an undone operation so Redo can re-apply it.
+type redoEntry struct {
 	Type   OpType
-	Index  int // index within the respective slice
+	Edit   CellEdit
+	Delete RowDelete
+	Insert RowInsert
 }
 
 // ChangeTracker tracks all staged modifications before commit.
@@ -47,6 +124,7 @@ type ChangeTracker struct {
 	Deletes   []RowDelete
 	Inserts   []RowInsert
 	undoStack []UndoEntry
+	redoStack []redoEntry
 }
 
 // NewChangeTracker creates a new empty change tracker.
@@ -56,6 +134,11 @@ func NewChangeTracker() *ChangeTracker {
 
 // StageEdit adds a cell edit to staged changes.
 func (ct *ChangeTracker) StageEdit(edit CellEdit) {
+	ct.redoStack = nil
+	ct.stageEdit(edit)
+}
+
+func (ct *ChangeTracker) stageEdit(edit CellEdit) {
 	// Check if there is already an edit for the same cell, and update it
 	for i, e := range ct.Edits {
 		if e.TableName == edit.TableName &&
@@ -66,30 +149,90 @@ func (ct *ChangeTracker) StageEdit(edit CellEdit) {
 		}
 	}
 	ct.Edits = append(ct.Edits, edit)
-	ct.undoStack = append(ct.undoStack, UndoEntry{Type: OpEdit, Index: len(ct.Edits) - 1})
+	ct.undoStack = append(ct.undoStack, UndoEntry{
+		Type:       OpEdit,
+		TableName:  edit.TableName,
+		PKValues:   edit.RowPKValues,
+		ColumnName: edit.ColumnName,
+	})
 }
 
 // StageDelete adds a row deletion to staged changes.
 func (ct *ChangeTracker) StageDelete(del RowDelete) {
+	ct.redoStack = nil
+	ct.stageDelete(del)
+}
+
+func (ct *ChangeTracker) stageDelete(del RowDelete) {
 	ct.Deletes = append(ct.Deletes, del)
-	ct.undoStack = append(ct.undoStack, UndoEntry{Type: OpDelete, Index: len(ct.Deletes) - 1})
+	ct.undoStack = append(ct.undoStack, UndoEntry{Type: OpDelete, TableName: del.TableName, PKValues: del.RowPKValues})
 }
 
-// UnstageDelete removes a row deletion from staged changes.
-func (ct *ChangeTracker) UnstageDelete(tableName string, pkValues map[string]string) {
+// RemoveDelete drops the staged deletion of one row, and its undo step,
+// leaving the other changes as they were.
+func (ct *ChangeTracker) RemoveDelete(tableName string, pkValues map[string]string) {
+	ct.redoStack = nil
+	if _, ok := ct.removeDelete(tableName, pkValues); !ok {
+		return
+	}
+	// Remove from undo stack too
+	for j := len(ct.undoStack) - 1; j >= 0; j-- {
+		e := ct.undoStack[j]
+		if e.Type == OpDelete && e.TableName == tableName && pkMatch(e.PKValues, pkValues) {
+			ct.undoStack = append(ct.undoStack[:j], ct.undoStack[j+1:]...)
+			break
+		}
+	}
+}
+
+// RemoveEdit drops the staged edit of one cell, and its undo step, leaving
+// the other changes as they were.
+func (ct *ChangeTracker) RemoveEdit(tableName string, pkValues map[string]string, columnName string) {
+	ct.redoStack = nil
+	if _, ok := ct.removeEdit(tableName, pkValues, columnName); !ok {
+		return
+	}
+	ct.undoStack = slices.DeleteFunc(ct.undoStack, func(e UndoEntry) bool {
+		return e.Type == OpEdit && e.TableName == tableName && e.ColumnName == columnName && pkMatch(e.PKValues, pkValues)
+	})
+}
+
+// RemoveInsert drops the staged insert at index i of Inserts, renumbering
+// the undo steps of the inserts after it.
+func (ct *ChangeTracker) RemoveInsert(i int) {
+	if i < 0 || i >= len(ct.Inserts) {
+		return
+	}
+	ct.redoStack = nil
+	ct.Inserts = slices.Delete(ct.Inserts, i, i+1)
+	ct.undoStack = slices.DeleteFunc(ct.undoStack, func(e UndoEntry) bool {
+		return e.Type == OpInsert && e.Index == i
+	})
+	for j, e := range ct.undoStack {
+		if e.Type == OpInsert && e.Index > i {
+			ct.undoStack[j].Index--
+		}
+	}
+}
+
+func (ct *ChangeTracker) removeDelete(tableName string, pkValues map[string]string) (RowDelete, bool) {
 	for i, d := range ct.Deletes {
 		if d.TableName == tableName && pkMatch(d.RowPKValues, pkValues) {
 			ct.Deletes = append(ct.Deletes[:i], ct.Deletes[i+1:]...)
-			// Remove from undo stack too
-			for j := len(ct.undoStack) - 1; j >= 0; j-- {
-				if ct.undoStack[j].Type == OpDelete && ct.undoStack[j].Index == i {
-					ct.undoStack = append(ct.undoStack[:j], ct.undoStack[j+1:]...)
-					break
-				}
-			}
-			return
+			return d, true
 		}
 	}
+	return RowDelete{}, false
+}
+
+func (ct *ChangeTracker) removeEdit(tableName string, pkValues map[string]string, columnName string) (CellEdit, bool) {
+	for i, e := range ct.Edits {
+		if e.TableName == tableName && e.ColumnName == columnName && pkMatch(e.RowPKValues, pkValues) {
+			ct.Edits = append(ct.Edits[:i], ct.Edits[i+1:]...)
+			return e, true
+		}
+	}
+	return CellEdit{}, false
 }
 
 // IsRowDeleted checks if a row is marked for deletion.
@@ -104,32 +247,71 @@ func (ct *ChangeTracker) IsRowDeleted(tableName string, pkValues map[string]stri
 
 // StageInsert adds a row insertion to staged changes.
 func (ct *ChangeTracker) StageInsert(ins RowInsert) {
+	ct.redoStack = nil
+	ct.stageInsert(ins)
+}
+
+func (ct *ChangeTracker) stageInsert(ins RowInsert) {
 	ct.Inserts = append(ct.Inserts, ins)
 	ct.undoStack = append(ct.undoStack, UndoEntry{Type: OpInsert, Index: len(ct.Inserts) - 1})
 }
 
-// Undo pops the last operation from the undo stack.
-func (ct *ChangeTracker) Undo() {
+// MarkRowAdded records that a row was added in the results grid, so that
+// Undo reaches it in turn with the staged changes.
+func (ct *ChangeTracker) MarkRowAdded() {
+	ct.redoStack = nil
+	ct.undoStack = append(ct.undoStack, UndoEntry{Type: OpAddRow})
+}
+
+// Undo pops the last operation from the undo stack and saves it for Redo.
+// It returns the type of the operation, and false if there was none.
+func (ct *ChangeTracker) Undo() (OpType, bool) {
 	if len(ct.undoStack) == 0 {
-		return
+		return 0, false
 	}
 	last := ct.undoStack[len(ct.undoStack)-1]
 	ct.undoStack = ct.undoStack[:len(ct.undoStack)-1]
 
 	switch last.Type {
 	case OpEdit:
-		if last.Index < len(ct.Edits) {
-			ct.Edits = append(ct.Edits[:last.Index], ct.Edits[last.Index+1:]...)
+		if edit, ok := ct.removeEdit(last.TableName, last.PKValues, last.ColumnName); ok {
+			ct.redoStack = append(ct.redoStack, redoEntry{Type: OpEdit, Edit: edit})
 		}
 	case OpDelete:
-		if last.Index < len(ct.Deletes) {
-			ct.Deletes = append(ct.Deletes[:last.Index], ct.Deletes[last.Index+1:]...)
+		if del, ok := ct.removeDelete(last.TableName, last.PKValues); ok {
+			ct.redoStack = append(ct.redoStack, redoEntry{Type: OpDelete, Delete: del})
 		}
 	case OpInsert:
 		if last.Index < len(ct.Inserts) {
+			ct.redoStack = append(ct.redoStack, redoEntry{Type: OpInsert, Insert: ct.Inserts[last.Index]})
 			ct.Inserts = append(ct.Inserts[:last.Index], ct.Inserts[last.Index+1:]...)
 		}
+	case OpAddRow:
+		ct.redoStack = append(ct.redoStack, redoEntry{Type: OpAddRow})
+	}
+	return last.Type, true
+}
+
+// Redo re-applies the most recently undone operation. It returns the type
+// of the operation, and false if there was none.
+func (ct *ChangeTracker) Redo() (OpType, bool) {
+	if len(ct.redoStack) == 0 {
+		return 0, false
+	}
+	last := ct.redoStack[len(ct.redoStack)-1]
+	ct.redoStack = ct.redoStack[:len(ct.redoStack)-1]
+
+	switch last.Type {
+	case OpEdit:
+		ct.stageEdit(last.Edit)
+	case OpDelete:
+		ct.stageDelete(last.Delete)
+	case OpInsert:
+		ct.stageInsert(last.Insert)
+	case OpAddRow:
+		ct.undoStack = append(ct.undoStack, UndoEntry{Type: OpAddRow})
 	}
+	return last.Type, true
 }
 
 // HasChanges returns whether there are any pending changes.
@@ -142,92 +324,233 @@ func (ct *ChangeTracker) PendingCount() int {
 	return len(ct.Edits) + len(ct.Deletes) + len(ct.Inserts)
 }
 
-// GenerateSQL generates parameterized SQL statements and their args.
+// DeferConstraints puts off checking deferrable constraints, foreign keys
+// among them, until the transaction it runs in commits.
+const DeferConstraints = "SET CONSTRAINTS ALL DEFERRED"
+
+// GenerateSQL generates parameterized SQL statements, their args, and the
+// staged change each statement originates from.
 // Order: INSERTs first, then UPDATEs, then DELETEs.
-func (ct *ChangeTracker) GenerateSQL() ([]string, [][]interface{}) {
+func (ct *ChangeTracker) GenerateSQL() ([]string, [][]interface{}, []StatementOrigin) {
+	return ct.generateSQL(nil)
+}
+
+// GenerateOrderedSQL is GenerateSQL with the INSERTs and DELETEs ordered so
+// that foreign keys between the tables hold as each statement runs: rows go
+// into a referenced table before the tables referencing it, and come out of
+// it after them. refs maps a table to the tables its foreign keys reference.
+// No order satisfies tables that reference each other in a cycle; running
+// DeferConstraints first lets those through where the keys are deferrable.
+func (ct *ChangeTracker) GenerateOrderedSQL(refs map[string][]string) ([]string, [][]interface{}, []StatementOrigin) {
+	return ct.generateSQL(refDepths(refs))
+}
+
+// refDepths gives each table in refs the length of the longest chain of
+// references below it, so a table sorts after every table it references.
+// Following a cycle back round adds nothing.
+func refDepths(refs map[string][]string) map[string]int {
+	depths := make(map[string]int)
+	visiting := make(map[string]bool)
+	var depth func(table string) int
+	depth = func(table string) int {
+		if d, ok := depths[table]; ok {
+			return d
+		}
+		if visiting[table] {
+			return 0
+		}
+		visiting[table] = true
+		d := 0
+		for _, ref := range refs[table] {
+			if ref != table {
+				d = max(d, depth(ref)+1)
+			}
+		}
+		visiting[table] = false
+		depths[table] = d
+		return d
+	}
+	for table := range refs {
+		depth(table)
+	}
+	return depths
+}
+
+// generateSQL is GenerateSQL with INSERTs sorted by depth, shallowest
+// first, and DELETEs deepest first; nil leaves them in staged order.
+func (ct *ChangeTracker) generateSQL(depth map[string]int) ([]string, [][]interface{}, []StatementOrigin) {
 	var queries []string
 	var allArgs [][]interface{}
+	var origins []StatementOrigin
 
-	// INSERTs
+	// INSERTs, coalesced into one multi-row statement per table and column set
+	type insertGroup struct {
+		table string
+		cols  []string
+		rows  []map[string]string
+	}
+	var groups []*insertGroup
+	groupIndex := make(map[string]*insertGroup)
 	for _, ins := range ct.Inserts {
-		if len(ins.Values) == 0 {
-			continue
+		cols := orderedColumns(ins.Values, ins.Columns)
+		key := ins.TableName + "\x00" + strings.Join(cols, "\x00")
+		g, ok := groupIndex[key]
+		if !ok {
+			g = &insertGroup{table: ins.TableName, cols: cols}
+			groupIndex[key] = g
+			groups = append(groups, g)
 		}
-		cols := make([]string, 0, len(ins.Values))
-		placeholders := make([]string, 0, len(ins.Values))
-		args := make([]interface{}, 0, len(ins.Values))
-		i := 1
-		for col, val := range ins.Values {
-			cols = append(cols, fmt.Sprintf("%q", col))
-			if val == "<NULL>" {
-				placeholders = append(placeholders, "NULL")
-			} else {
-				placeholders = append(placeholders, fmt.Sprintf("$%d", i))
-				args = append(args, val)
-				i++
+		g.rows = append(g.rows, ins.Values)
+	}
+	if depth != nil {
+		slices.SortStableFunc(groups, func(a, b *insertGroup) int { return depth[a.table] - depth[b.table] })
+	}
+	for _, g := range groups {
+		if len(g.cols) == 0 {
+			// Every column takes its default; DEFAULT VALUES inserts one row.
+			for range g.rows {
+				queries = append(queries, fmt.Sprintf(`INSERT INTO %s DEFAULT VALUES`, db.QuoteIdentifier(g.table)))
+				allArgs = append(allArgs, nil)
+				origins = append(origins, StatementOrigin{Type: OpInsert, TableName: g.table, RowCount: 1})
 			}
+			continue
+		}
+		// Split the group so no statement binds more than the server allows.
+		perStatement := maxBindParams / len(g.cols)
+		for rows := range slices.Chunk(g.rows, perStatement) {
+			q, args := buildInsert(g.table, g.cols, rows)
+			queries = append(queries, q)
+			allArgs = append(allArgs, args)
+			origins = append(origins, StatementOrigin{Type: OpInsert, TableName: g.table, RowCount: len(rows)})
 		}
-		q := fmt.Sprintf(`INSERT INTO %q (%s) VALUES (%s)`,
-			ins.TableName,
-			strings.Join(cols, ", "),
-			strings.Join(placeholders, ", "))
-		queries = append(queries, q)
-		allArgs = append(allArgs, args)
 	}
 
 	// UPDATEs (edits)
 	for _, edit := range ct.Edits {
 		args := []interface{}{}
 		var setClause string
-		if edit.NewValue == "<NULL>" {
+		if edit.NewValue == NullValue {
 			setClause = fmt.Sprintf("%q = NULL", edit.ColumnName)
 		} else {
 			setClause = fmt.Sprintf("%q = $1", edit.ColumnName)
 			args = append(args, edit.NewValue)
 		}
 
-		whereParts := make([]string, 0, len(edit.RowPKValues))
-		paramIdx := len(args) + 1
-		for col, val := range edit.RowPKValues {
-			if val == "<NULL>" {
-				whereParts = append(whereParts, fmt.Sprintf("%q IS NULL", col))
-			} else {
-				whereParts = append(whereParts, fmt.Sprintf("%q = $%d", col, paramIdx))
-				args = append(args, val)
-				paramIdx++
-			}
-		}
+		where, whereArgs := pkWhereClause(edit.RowPKValues, edit.RowPKRaw, edit.PKColumns, len(args)+1)
+		args = append(args, whereArgs...)
 
-		q := fmt.Sprintf(`UPDATE %q SET %s WHERE %s`,
-			edit.TableName,
+		q := fmt.Sprintf(`UPDATE %s SET %s WHERE %s`,
+			db.QuoteIdentifier(edit.TableName),
 			setClause,
-			strings.Join(whereParts, " AND "))
+			where)
 		queries = append(queries, q)
 		allArgs = append(allArgs, args)
+		origins = append(origins, StatementOrigin{
+			Type:       OpEdit,
+			TableName:  edit.TableName,
+			PKValues:   edit.RowPKValues,
+			ColumnName: edit.ColumnName,
+		})
 	}
 
 	// DELETEs
-	for _, del := range ct.Deletes {
-		args := make([]interface{}, 0, len(del.RowPKValues))
-		whereParts := make([]string, 0, len(del.RowPKValues))
-		i := 1
-		for col, val := range del.RowPKValues {
-			if val == "<NULL>" {
-				whereParts = append(whereParts, fmt.Sprintf("%q IS NULL", col))
+	deletes := ct.Deletes
+	if depth != nil {
+		deletes = slices.Clone(deletes)
+		slices.SortStableFunc(deletes, func(a, b RowDelete) int { return depth[b.TableName] - depth[a.TableName] })
+	}
+	for _, del := range deletes {
+		where, args := pkWhereClause(del.RowPKValues, del.RowPKRaw, del.PKColumns, 1)
+		q := fmt.Sprintf(`DELETE FROM %s WHERE %s`,
+			db.QuoteIdentifier(del.TableName),
+			where)
+		queries = append(queries, q)
+		allArgs = append(allArgs, args)
+		origins = append(origins, StatementOrigin{Type: OpDelete, TableName: del.TableName, PKValues: del.RowPKValues})
+	}
+
+	return queries, allArgs, origins
+}
+
+// orderedColumns lists the keys of values in the order of order, followed
+// by any it leaves out sorted by name, so that generated SQL is the same
+// from one run to the next.
+func orderedColumns(values map[string]string, order []string) []string {
+	cols := make([]string, 0, len(values))
+	for _, col := range order {
+		if _, ok := values[col]; ok && !slices.Contains(cols, col) {
+			cols = append(cols, col)
+		}
+	}
+	var rest []string
+	for col := range values {
+		if !slices.Contains(cols, col) {
+			rest = append(rest, col)
+		}
+	}
+	sort.Strings(rest)
+	return append(cols, rest...)
+}
+
+// pkWhereClause builds a WHERE condition matching a row by primary key, in
+// the column order of order, with placeholders numbered from firstParam. Raw
+// database values are bound when available so that keys round-trip exactly;
+// display strings are the fallback.
+func pkWhereClause(pk map[string]string, raw map[string]interface{}, order []string, firstParam int) (string, []interface{}) {
+	cols := orderedColumns(pk, order)
+
+	var args []interface{}
+	parts := make([]string, 0, len(cols))
+	for _, col := range cols {
+		var val interface{} = pk[col]
+		if rv, ok := raw[col]; ok {
+			val = rv
+		} else if pk[col] == NullValue {
+			val = nil
+		}
+		if val == nil {
+			parts = append(parts, fmt.Sprintf("%q IS NULL", col))
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%q = $%d", col, firstParam+len(args)))
+		args = append(args, val)
+	}
+	return strings.Join(parts, " AND "), args
+}
+
+// maxBindParams is the most parameters PostgreSQL takes in one statement.
+const maxBindParams = 65535
+
+// buildInsert renders a single INSERT with one VALUES tuple per row. NULL
+// values are inlined as literals, so placeholders are numbered only across
+// the bound params.
+func buildInsert(table string, cols []string, rows []map[string]string) (string, []interface{}) {
+	quoted := make([]string, len(cols))
+	for i, col := range cols {
+		quoted[i] = fmt.Sprintf("%q", col)
+	}
+
+	var args []interface{}
+	tuples := make([]string, 0, len(rows))
+	for _, row := range rows {
+		placeholders := make([]string, len(cols))
+		for i, col := range cols {
+			val := row[col]
+			if val == NullValue {
+				placeholders[i] = "NULL"
 			} else {
-				whereParts = append(whereParts, fmt.Sprintf("%q = $%d", col, i))
 				args = append(args, val)
-				i++
+				placeholders[i] = fmt.Sprintf("$%d", len(args))
 			}
 		}
-		q := fmt.Sprintf(`DELETE FROM %q WHERE %s`,
-			del.TableName,
-			strings.Join(whereParts, " AND "))
-		queries = append(queries, q)
-		allArgs = append(allArgs, args)
+		tuples = append(tuples, "("+strings.Join(placeholders, ", ")+")")
 	}
 
-	return queries, allArgs
+	q := fmt.Sprintf(`INSERT INTO %s (%s) VALUES %s`,
+		db.QuoteIdentifier(table),
+		strings.Join(quoted, ", "),
+		strings.Join(tuples, ", "))
+	return q, args
 }
 
 // Clear removes all staged changes.
@@ -236,6 +559,7 @@ func (ct *ChangeTracker) Clear() {
 	ct.Deletes = nil
 	ct.Inserts = nil
 	ct.undoStack = nil
+	ct.redoStack = nil
 }
 
 // GetCellEdit returns the new value for a cell if it has a staged edit.
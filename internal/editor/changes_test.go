@@ -0,0 +1,316 @@
+package editor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildInsert(t *testing.T) {
+	tests := []struct {
+		name  string
+		cols  []string
+		rows  []map[string]string
+		wantQ string
+		wantA []interface{}
+	}{
+		{
+			name:  "single row",
+			cols:  []string{"id", "name"},
+			rows:  []map[string]string{{"id": "1", "name": "ann"}},
+			wantQ: `INSERT INTO "users" ("id", "name") VALUES ($1, $2)`,
+			wantA: []interface{}{"1", "ann"},
+		},
+		{
+			name: "NULLs are inlined and numbering skips them",
+			cols: []string{"id", "name", "email"},
+			rows: []map[string]string{
+				{"id": "1", "name": NullValue, "email": "a@x"},
+				{"id": "2", "name": "bob", "email": NullValue},
+				{"id": NullValue, "name": NullValue, "email": NullValue},
+				{"id": "4", "name": "", "email": "d@x"},
+			},
+			wantQ: `INSERT INTO "users" ("id", "name", "email") VALUES ($1, NULL, $2), ($3, $4, NULL), (NULL, NULL, NULL), ($5, $6, $7)`,
+			wantA: []interface{}{"1", "a@x", "2", "bob", "4", "", "d@x"},
+		},
+		{
+			name:  "every value NULL",
+			cols:  []string{"a"},
+			rows:  []map[string]string{{"a": NullValue}, {"a": NullValue}},
+			wantQ: `INSERT INTO "users" ("a") VALUES (NULL), (NULL)`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, args := buildInsert("users", tt.cols, tt.rows)
+			if q != tt.wantQ {
+				t.Errorf("query:\n got  %s\n want %s", q, tt.wantQ)
+			}
+			if !reflect.DeepEqual(args, tt.wantA) {
+				t.Errorf("args = %#v, want %#v", args, tt.wantA)
+			}
+		})
+	}
+}
+
+func TestGenerateSQLCoalescesInserts(t *testing.T) {
+	ct := NewChangeTracker()
+	ct.StageInsert(RowInsert{TableName: "users", Values: map[string]string{"id": "1", "name": NullValue}, Columns: []string{"id", "name"}})
+	ct.StageInsert(RowInsert{TableName: "users", Values: map[string]string{"id": "2", "name": "bob"}, Columns: []string{"id", "name"}})
+	ct.StageInsert(RowInsert{TableName: "users", Values: map[string]string{"id": "3"}, Columns: []string{"id", "name"}})
+
+	queries, args, origins := ct.GenerateSQL()
+	wantQ := []string{
+		`INSERT INTO "users" ("id", "name") VALUES ($1, NULL), ($2, $3)`,
+		`INSERT INTO "users" ("id") VALUES ($1)`,
+	}
+	if !reflect.DeepEqual(queries, wantQ) {
+		t.Fatalf("queries = %q, want %q", queries, wantQ)
+	}
+	wantA := [][]interface{}{{"1", "2", "bob"}, {"3"}}
+	if !reflect.DeepEqual(args, wantA) {
+		t.Errorf("args = %#v, want %#v", args, wantA)
+	}
+	if origins[0].RowCount != 2 || origins[1].RowCount != 1 {
+		t.Errorf("row counts = %d, %d, want 2, 1", origins[0].RowCount, origins[1].RowCount)
+	}
+}
+
+func TestUndoRedo(t *testing.T) {
+	pk := map[string]string{"id": "1"}
+	edit := func(v string) CellEdit {
+		return CellEdit{TableName: "users", RowPKValues: pk, ColumnName: "name", OldValue: "ann", NewValue: v}
+	}
+
+	ct := NewChangeTracker()
+	ct.StageEdit(edit("bob"))
+	ct.StageDelete(RowDelete{TableName: "users", RowPKValues: map[string]string{"id": "2"}})
+
+	ct.Undo()
+	if len(ct.Deletes) != 0 || len(ct.Edits) != 1 {
+		t.Fatalf("after undo: %d deletes, %d edits, want 0, 1", len(ct.Deletes), len(ct.Edits))
+	}
+	ct.Undo()
+	if ct.HasChanges() {
+		t.Fatalf("after second undo: %d changes, want none", ct.PendingCount())
+	}
+
+	ct.Redo()
+	if v, ok := ct.GetCellEdit("users", pk, "name"); !ok || v != "bob" {
+		t.Fatalf("after redo: edit = %q, %v, want bob", v, ok)
+	}
+	ct.Redo()
+	if !ct.IsRowDeleted("users", map[string]string{"id": "2"}) {
+		t.Fatal("after second redo: row 2 is not deleted")
+	}
+
+	// Undo once more, then stage something new: the undone delete can no
+	// longer be redone.
+	ct.Undo()
+	ct.StageInsert(RowInsert{TableName: "users", Values: map[string]string{"id": "3"}})
+	ct.Redo()
+	if ct.IsRowDeleted("users", map[string]string{"id": "2"}) {
+		t.Error("redo after a new change brought back the undone delete")
+	}
+	if len(ct.Inserts) != 1 || len(ct.Edits) != 1 {
+		t.Errorf("got %d inserts, %d edits, want 1, 1", len(ct.Inserts), len(ct.Edits))
+	}
+
+	// Undoing the insert and redoing it puts the same row back.
+	ct.Undo()
+	if len(ct.Inserts) != 0 {
+		t.Fatalf("after undoing the insert: %d inserts, want 0", len(ct.Inserts))
+	}
+	ct.Redo()
+	if len(ct.Inserts) != 1 || ct.Inserts[0].Values["id"] != "3" {
+		t.Errorf("after redoing the insert: %+v", ct.Inserts)
+	}
+}
+
+func TestUndoAfterRemovingMiddleDelete(t *testing.T) {
+	row := func(id string) map[string]string { return map[string]string{"id": id} }
+
+	ct := NewChangeTracker()
+	for _, id := range []string{"1", "2", "3"} {
+		ct.StageDelete(RowDelete{TableName: "users", RowPKValues: row(id)})
+	}
+	ct.RemoveDelete("users", row("2"))
+	if ct.IsRowDeleted("users", row("2")) || len(ct.Deletes) != 2 {
+		t.Fatalf("after unstaging row 2: %+v", ct.Deletes)
+	}
+
+	ct.Undo()
+	if ct.IsRowDeleted("users", row("3")) || !ct.IsRowDeleted("users", row("1")) {
+		t.Fatalf("first undo should unstage row 3 only: %+v", ct.Deletes)
+	}
+	ct.Undo()
+	if len(ct.Deletes) != 0 {
+		t.Fatalf("second undo should unstage row 1: %+v", ct.Deletes)
+	}
+
+	// The stack is empty now: another undo changes nothing, and redo
+	// brings the rows back in the order they were undone.
+	ct.Undo()
+	ct.Redo()
+	ct.Redo()
+	if len(ct.Deletes) != 2 || ct.Deletes[0].RowPKValues["id"] != "1" || ct.Deletes[1].RowPKValues["id"] != "3" {
+		t.Errorf("after redoing both: %+v", ct.Deletes)
+	}
+}
+
+func TestPKWhereClause(t *testing.T) {
+	// A composite key of an int column and a text column, as read back from
+	// the database: the int is bound raw, the text falls back to its display
+	// string.
+	pk := map[string]string{"tenant": "7", "name": "ann"}
+	raw := map[string]interface{}{"tenant": int32(7)}
+
+	tests := []struct {
+		name       string
+		raw        map[string]interface{}
+		order      []string
+		firstParam int
+		wantWhere  string
+		wantArgs   []interface{}
+	}{
+		{
+			name:       "no order sorts by name",
+			raw:        raw,
+			firstParam: 1,
+			wantWhere:  `"name" = $1 AND "tenant" = $2`,
+			wantArgs:   []interface{}{"ann", int32(7)},
+		},
+		{
+			name:       "key order is kept",
+			raw:        raw,
+			order:      []string{"tenant", "name"},
+			firstParam: 1,
+			wantWhere:  `"tenant" = $1 AND "name" = $2`,
+			wantArgs:   []interface{}{int32(7), "ann"},
+		},
+		{
+			name:       "numbering starts at firstParam",
+			raw:        raw,
+			order:      []string{"tenant", "name"},
+			firstParam: 2,
+			wantWhere:  `"tenant" = $2 AND "name" = $3`,
+			wantArgs:   []interface{}{int32(7), "ann"},
+		},
+		{
+			name:       "display strings without raw values",
+			order:      []string{"tenant", "name"},
+			firstParam: 1,
+			wantWhere:  `"tenant" = $1 AND "name" = $2`,
+			wantArgs:   []interface{}{"7", "ann"},
+		},
+		{
+			name:       "NULL key part takes no param",
+			raw:        map[string]interface{}{"tenant": nil},
+			order:      []string{"tenant", "name"},
+			firstParam: 1,
+			wantWhere:  `"tenant" IS NULL AND "name" = $1`,
+			wantArgs:   []interface{}{"ann"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			where, args := pkWhereClause(pk, tt.raw, tt.order, tt.firstParam)
+			if where != tt.wantWhere {
+				t.Errorf("where:\n got  %s\n want %s", where, tt.wantWhere)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args = %#v, want %#v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestGenerateSQLCompositeKey(t *testing.T) {
+	pk := map[string]string{"tenant": "7", "name": "ann"}
+	raw := map[string]interface{}{"tenant": int32(7)}
+	order := []string{"tenant", "name"}
+
+	ct := NewChangeTracker()
+	ct.StageEdit(CellEdit{TableName: "users", RowPKValues: pk, RowPKRaw: raw, PKColumns: order, ColumnName: "email", NewValue: "a@x"})
+	ct.StageDelete(RowDelete{TableName: "users", RowPKValues: pk, RowPKRaw: raw, PKColumns: order})
+
+	queries, args, _ := ct.GenerateSQL()
+	wantQ := []string{
+		`UPDATE "users" SET "email" = $1 WHERE "tenant" = $2 AND "name" = $3`,
+		`DELETE FROM "users" WHERE "tenant" = $1 AND "name" = $2`,
+	}
+	if !reflect.DeepEqual(queries, wantQ) {
+		t.Fatalf("queries = %q, want %q", queries, wantQ)
+	}
+	wantA := [][]interface{}{{"a@x", int32(7), "ann"}, {int32(7), "ann"}}
+	if !reflect.DeepEqual(args, wantA) {
+		t.Errorf("args = %#v, want %#v", args, wantA)
+	}
+}
+
+func TestGenerateSQLSchemaQualified(t *testing.T) {
+	pk := map[string]string{"id": "1"}
+	ct := NewChangeTracker()
+	ct.StageInsert(RowInsert{TableName: "sales.orders", Values: map[string]string{"id": "2"}})
+	ct.StageEdit(CellEdit{TableName: "sales.orders", RowPKValues: pk, ColumnName: "total", NewValue: "9"})
+	ct.StageDelete(RowDelete{TableName: `"Sales"."a.b"`, RowPKValues: pk})
+
+	queries, _, _ := ct.GenerateSQL()
+	want := []string{
+		`INSERT INTO "sales"."orders" ("id") VALUES ($1)`,
+		`UPDATE "sales"."orders" SET "total" = $1 WHERE "id" = $2`,
+		`DELETE FROM "Sales"."a.b" WHERE "id" = $1`,
+	}
+	if !reflect.DeepEqual(queries, want) {
+		t.Errorf("queries:\n got  %q\n want %q", queries, want)
+	}
+}
+
+func TestGenerateSQLSplitsLargeInserts(t *testing.T) {
+	cols := []string{"id", "name"}
+	perStatement := maxBindParams / len(cols)
+	ct := NewChangeTracker()
+	for i := 0; i < perStatement+1; i++ {
+		ct.StageInsert(RowInsert{TableName: "users", Values: map[string]string{"id": "1", "name": "x"}, Columns: cols})
+	}
+
+	queries, args, origins := ct.GenerateSQL()
+	if len(queries) != 2 {
+		t.Fatalf("got %d statements, want 2", len(queries))
+	}
+	if len(args[0]) != perStatement*len(cols) || len(args[1]) != len(cols) {
+		t.Errorf("bound %d and %d params, want %d and %d", len(args[0]), len(args[1]), perStatement*len(cols), len(cols))
+	}
+	if origins[0].RowCount != perStatement || origins[1].RowCount != 1 {
+		t.Errorf("row counts = %d, %d, want %d, 1", origins[0].RowCount, origins[1].RowCount, perStatement)
+	}
+	if want := `INSERT INTO "users" ("id", "name") VALUES ($1, $2)`; queries[1] != want {
+		t.Errorf("second statement = %s, want %s", queries[1], want)
+	}
+}
+
+func TestUndoRedoReportAddedRows(t *testing.T) {
+	ct := NewChangeTracker()
+	ct.MarkRowAdded()
+	ct.StageDelete(RowDelete{TableName: "users", RowPKValues: map[string]string{"id": "1"}})
+
+	steps := []struct {
+		do   func() (OpType, bool)
+		want OpType
+	}{
+		{ct.Undo, OpDelete},
+		{ct.Undo, OpAddRow},
+		{ct.Redo, OpAddRow},
+		{ct.Redo, OpDelete},
+	}
+	for i, s := range steps {
+		if op, ok := s.do(); !ok || op != s.want {
+			t.Errorf("step %d: got %v, %v, want %v, true", i, op, ok, s.want)
+		}
+	}
+	if _, ok := ct.Redo(); ok {
+		t.Error("redo with nothing undone reported an operation")
+	}
+	if ct.PendingCount() != 1 {
+		t.Errorf("pending = %d, want 1: a marked row is not a staged change", ct.PendingCount())
+	}
+}
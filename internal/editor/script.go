@@ -0,0 +1,112 @@
+package editor
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"cli-sql/internal/db"
+)
+
+// Script renders the statements GenerateSQL returns, with their args
+// written in as literals, as one transaction to paste into a migration or
+// a review. Like a commit, it starts with DeferConstraints.
+func Script(queries []string, allArgs [][]interface{}) string {
+	var b strings.Builder
+	b.WriteString("BEGIN;\n" + DeferConstraints + ";\n\n")
+	for i, q := range queries {
+		var args []interface{}
+		if i < len(allArgs) {
+			args = allArgs[i]
+		}
+		b.WriteString(InlineArgs(q, args))
+		b.WriteString(";\n")
+	}
+	b.WriteString("\nCOMMIT;\n")
+	return b.String()
+}
+
+// InlineArgs replaces the $1, $2 ... placeholders of query with args as SQL
+// literals. Placeholders inside quoted identifiers and string literals are
+// left alone, as are ones with no arg.
+func InlineArgs(query string, args []interface{}) string {
+	var b strings.Builder
+	var quote byte // '"' or '\'' while inside one
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '$':
+			j := i + 1
+			for j < len(query) && query[j] >= '0' && query[j] <= '9' {
+				j++
+			}
+			if n, err := strconv.Atoi(query[i+1 : j]); err == nil && n >= 1 && n <= len(args) {
+				b.WriteString(Literal(args[n-1]))
+				i = j - 1
+				continue
+			}
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// Literal writes v, a value bound to a placeholder, as an SQL literal.
+// Values the server would have parsed from text, such as staged edits,
+// become quoted strings and are cast where they are used, just as a bound
+// text value would be.
+func Literal(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return db.QuoteLiteral(v)
+	case bool:
+		if v {
+			return "TRUE"
+		}
+		return "FALSE"
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(v)
+	case float32:
+		return floatLiteral(float64(v), 32)
+	case float64:
+		return floatLiteral(v, 64)
+	case time.Time:
+		return db.QuoteLiteral(v.Format("2006-01-02 15:04:05.999999999Z07:00"))
+	case []byte:
+		return db.QuoteLiteral(`\x`+hex.EncodeToString(v)) + "::bytea"
+	case [16]byte:
+		// pgx scans uuid as its 16 bytes.
+		h := hex.EncodeToString(v[:])
+		return db.QuoteLiteral(h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:])
+	case driver.Valuer:
+		dv, err := v.Value()
+		if err == nil {
+			return Literal(dv)
+		}
+	case fmt.Stringer:
+		return db.QuoteLiteral(v.String())
+	}
+	return db.QuoteLiteral(fmt.Sprint(v))
+}
+
+// floatLiteral writes f in full precision; NaN and the infinities, which
+// have no numeric literal, are quoted.
+func floatLiteral(f float64, bits int) string {
+	s := strconv.FormatFloat(f, 'g', -1, bits)
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return db.QuoteLiteral(s)
+	}
+	return s
+}
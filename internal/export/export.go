@@ -0,0 +1,265 @@
+// Package export renders query results as text for writing to files or stdout.
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"cli-sql/internal/db"
+)
+
+// Format is an output format for query results.
+type Format string
+
+const (
+	FormatTable Format = "table"
+	FormatCSV   Format = "csv"
+	FormatJSON  Format = "json"
+)
+
+// ParseFormat validates a format name given on the command line.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case FormatTable, FormatCSV, FormatJSON:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown format %q (want table, csv or json)", s)
+}
+
+// Write renders qr to w in the given format.
+func Write(w io.Writer, format Format, qr *db.QueryResult) error {
+	switch format {
+	case FormatCSV:
+		return WriteCSV(w, qr)
+	case FormatJSON:
+		return WriteJSON(w, qr)
+	default:
+		return WriteTable(w, qr)
+	}
+}
+
+// WriteExec reports the result of a statement that returns no rows.
+func WriteExec(w io.Writer, format Format, er *db.ExecResult) error {
+	if format == FormatJSON {
+		return json.NewEncoder(w).Encode(map[string]int64{"rows_affected": er.RowsAffected})
+	}
+	_, err := fmt.Fprintf(w, "%d rows affected\n", er.RowsAffected)
+	return err
+}
+
+// WriteTable writes qr as a psql-style aligned table.
+func WriteTable(w io.Writer, qr *db.QueryResult) error {
+	widths := make([]int, len(qr.Columns))
+	for i, col := range qr.Columns {
+		widths[i] = utf8.RuneCountInString(col)
+	}
+	for _, row := range qr.Rows {
+		for i, cell := range row {
+			if n := utf8.RuneCountInString(cell); i < len(widths) && n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	var b strings.Builder
+	writeRow := func(cells []string) {
+		parts := make([]string, len(cells))
+		for i, cell := range cells {
+			parts[i] = cell + strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell))
+		}
+		b.WriteString(strings.TrimRight(" "+strings.Join(parts, " | "), " "))
+		b.WriteString("\n")
+	}
+
+	writeRow(qr.Columns)
+	seps := make([]string, len(widths))
+	for i, n := range widths {
+		seps[i] = strings.Repeat("-", n+2)
+	}
+	b.WriteString(strings.Join(seps, "+") + "\n")
+	for _, row := range qr.Rows {
+		writeRow(row)
+	}
+	if qr.RowCount == 1 {
+		b.WriteString("(1 row)\n")
+	} else {
+		fmt.Fprintf(&b, "(%d rows)\n", qr.RowCount)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteCSV writes qr as CSV with a header row. NULLs become empty fields and
+// empty strings quoted ones (""), as COPY tells them apart.
+func WriteCSV(w io.Writer, qr *db.QueryResult) error {
+	return writeStream(NewStreamWriter(w, FormatCSV), qr)
+}
+
+// WriteJSON writes qr as a JSON array with one object per row.
+func WriteJSON(w io.Writer, qr *db.QueryResult) error {
+	return writeStream(NewStreamWriter(w, FormatJSON), qr)
+}
+
+// writeStream feeds a complete result through sw.
+func writeStream(sw *StreamWriter, qr *db.QueryResult) error {
+	if err := sw.Columns(qr.Columns, qr.ColumnTypes); err != nil {
+		return err
+	}
+	for r, row := range qr.Rows {
+		raw := make([]interface{}, len(row))
+		for i := range row {
+			raw[i] = rawValue(qr, r, i)
+		}
+		if err := sw.Row(row, raw); err != nil {
+			return err
+		}
+	}
+	return sw.Close()
+}
+
+// StreamWriter writes rows as they are produced, for use as a
+// db.StreamHandler. CSV and JSON are written incrementally; the table format
+// has to see every row to size its columns, so it is buffered until Close.
+// Close must be called once the rows are done.
+type StreamWriter struct {
+	w       io.Writer
+	format  Format
+	columns []string
+	rows    int
+
+	csv   *bufio.Writer
+	table *db.QueryResult
+}
+
+// NewStreamWriter returns a StreamWriter writing format to w.
+func NewStreamWriter(w io.Writer, format Format) *StreamWriter {
+	return &StreamWriter{w: w, format: format}
+}
+
+// Columns starts the output: the CSV header row or the opening of the
+// JSON array.
+func (sw *StreamWriter) Columns(columns, columnTypes []string) error {
+	sw.columns = columns
+	switch sw.format {
+	case FormatCSV:
+		sw.csv = bufio.NewWriter(sw.w)
+		return writeCSVRecord(sw.csv, columns, nil)
+	case FormatJSON:
+		return nil
+	default:
+		sw.table = &db.QueryResult{Columns: columns, ColumnTypes: columnTypes}
+		return nil
+	}
+}
+
+// Row writes one row given its display text and raw values.
+func (sw *StreamWriter) Row(row []string, raw []interface{}) error {
+	sw.rows++
+	switch sw.format {
+	case FormatCSV:
+		record := make([]string, len(row))
+		nulls := make([]bool, len(row))
+		for i, cell := range row {
+			if i >= len(raw) || raw[i] != nil {
+				record[i] = cell
+			} else {
+				nulls[i] = true
+			}
+		}
+		return writeCSVRecord(sw.csv, record, nulls)
+	case FormatJSON:
+		obj := make(map[string]interface{}, len(row))
+		for i, cell := range row {
+			if i < len(sw.columns) && i < len(raw) {
+				obj[sw.columns[i]] = jsonValue(raw[i], cell)
+			}
+		}
+		data, err := json.MarshalIndent(obj, "  ", "  ")
+		if err != nil {
+			return err
+		}
+		sep := ",\n  "
+		if sw.rows == 1 {
+			sep = "[\n  "
+		}
+		_, err = io.WriteString(sw.w, sep+string(data))
+		return err
+	default:
+		sw.table.Rows = append(sw.table.Rows, row)
+		sw.table.RowCount++
+		return nil
+	}
+}
+
+// Close finishes the output.
+func (sw *StreamWriter) Close() error {
+	switch sw.format {
+	case FormatCSV:
+		return sw.csv.Flush()
+	case FormatJSON:
+		end := "\n]\n"
+		if sw.rows == 0 {
+			end = "[]\n"
+		}
+		_, err := io.WriteString(sw.w, end)
+		return err
+	default:
+		return WriteTable(sw.w, sw.table)
+	}
+}
+
+// writeCSVRecord writes one CSV line as encoding/csv would, except that an
+// empty field is quoted unless nulls marks it NULL. COPY ... (FORMAT csv),
+// which db.CopyFromCSV loads with, reads an unquoted empty field as NULL and
+// a quoted one as the empty string.
+func writeCSVRecord(w *bufio.Writer, record []string, nulls []bool) error {
+	for i, field := range record {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		null := i < len(nulls) && nulls[i]
+		if null || !csvNeedsQuotes(field) {
+			w.WriteString(field)
+			continue
+		}
+		w.WriteByte('"')
+		w.WriteString(strings.ReplaceAll(field, `"`, `""`))
+		w.WriteByte('"')
+	}
+	_, err := w.WriteString("\n")
+	return err
+}
+
+// csvNeedsQuotes reports whether a non-NULL field has to be quoted: when it
+// is empty, holds a separator, quote or line break, starts with a space,
+// or is \., which COPY would take for the end of the data.
+func csvNeedsQuotes(field string) bool {
+	return field == "" || field == `\.` || strings.ContainsAny(field, ",\"\r\n") ||
+		field[0] == ' ' || field[0] == '\t'
+}
+
+// rawValue returns the pgx-decoded value of a cell. Results without raw
+// values fall back to the display text, which is never nil.
+func rawValue(qr *db.QueryResult, row, col int) interface{} {
+	if row < len(qr.RawRows) && col < len(qr.RawRows[row]) {
+		return qr.RawRows[row][col]
+	}
+	return qr.Rows[row][col]
+}
+
+// jsonValue keeps values that have a natural JSON form (numbers, booleans,
+// decoded json/jsonb) and uses the display text for everything else, such as
+// UUIDs, which pgx decodes to byte arrays.
+func jsonValue(raw interface{}, text string) interface{} {
+	switch raw.(type) {
+	case nil, bool, string, map[string]interface{}, []interface{},
+		int, int8, int16, int32, int64, uint8, uint16, uint32, uint64, float32, float64:
+		return raw
+	}
+	return text
+}
@@ -0,0 +1,297 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"cli-sql/internal/db"
+)
+
+// RefreshActivityMsg asks for pg_stat_activity to be read again.
+type RefreshActivityMsg struct{}
+
+// SignalBackendMsg is sent once the user has confirmed cancelling the query
+// of a backend, or terminating it.
+type SignalBackendMsg struct {
+	PID       int32
+	Terminate bool
+}
+
+// ActivityModel is a modal listing the server's client backends: what each
+// runs, for how long, and which others it waits on for locks.
+type ActivityModel struct {
+	visible   bool
+	rows      []db.Activity
+	err       error
+	updated   time.Time
+	cursor    int
+	scroll    int
+	confirm   *SignalBackendMsg // signal waiting for y/n
+	width     int
+	height    int
+	statusMsg string
+}
+
+func NewActivityModel() ActivityModel {
+	return ActivityModel{}
+}
+
+func (m *ActivityModel) Open() {
+	*m = ActivityModel{visible: true, width: m.width, height: m.height}
+}
+
+func (m *ActivityModel) Close() {
+	m.visible = false
+}
+
+func (m ActivityModel) Visible() bool {
+	return m.visible
+}
+
+func (m *ActivityModel) SetSize(w, h int) {
+	m.width = w
+	m.height = h
+}
+
+// SetActivity shows rows, or err if they could not be read. The cursor stays
+// on the backend it was on while that is still listed.
+func (m *ActivityModel) SetActivity(rows []db.Activity, err error) {
+	m.updated = time.Now()
+	m.err = err
+	if err != nil {
+		return
+	}
+	pid := int32(-1)
+	if m.cursor < len(m.rows) {
+		pid = m.rows[m.cursor].PID
+	}
+	m.rows = rows
+	m.cursor = min(m.cursor, max(0, len(rows)-1))
+	for i, a := range rows {
+		if a.PID == pid {
+			m.cursor = i
+		}
+	}
+	m.clampScroll()
+}
+
+// SetStatus shows text under the hint line until the next key.
+func (m *ActivityModel) SetStatus(text string) {
+	m.statusMsg = text
+}
+
+func (m ActivityModel) Update(msg tea.Msg) (ActivityModel, tea.Cmd) {
+	if !m.visible {
+		return m, nil
+	}
+
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	m.statusMsg = ""
+	if m.confirm != nil {
+		signal := *m.confirm
+		m.confirm = nil
+		if key.String() == "y" || key.String() == "Y" {
+			return m, func() tea.Msg { return signal }
+		}
+		m.statusMsg = "Cancelled"
+		return m, nil
+	}
+
+	switch {
+	case key.String() == "esc" || key.String() == "q" || KeyMatches(key, ActionActivity):
+		m.Close()
+	case KeyMatches(key, ActionUp):
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case KeyMatches(key, ActionDown):
+		if m.cursor < len(m.rows)-1 {
+			m.cursor++
+		}
+	case KeyMatches(key, ActionTop):
+		m.cursor = 0
+	case KeyMatches(key, ActionBottom):
+		m.cursor = max(0, len(m.rows)-1)
+	case key.String() == "r":
+		return m, func() tea.Msg { return RefreshActivityMsg{} }
+	case key.String() == "c" || key.String() == "x":
+		if len(m.rows) == 0 {
+			return m, nil
+		}
+		a := m.rows[m.cursor]
+		if a.Self {
+			m.statusMsg = "That backend is this connection"
+			return m, nil
+		}
+		m.confirm = &SignalBackendMsg{PID: a.PID, Terminate: key.String() == "x"}
+	}
+	m.clampScroll()
+	return m, nil
+}
+
+func (m *ActivityModel) clampScroll() {
+	h := m.bodyHeight()
+	if m.cursor < m.scroll {
+		m.scroll = m.cursor
+	} else if m.cursor >= m.scroll+h {
+		m.scroll = m.cursor - h + 1
+	}
+}
+
+// bodyHeight is how many backends fit inside the modal.
+func (m ActivityModel) bodyHeight() int {
+	// Border, padding, title, hint, status and header lines, and the query
+	// of the selected backend below the list.
+	return max(1, m.height-13)
+}
+
+// blocking returns the backends others are waiting on.
+func (m ActivityModel) blocking() map[int32]bool {
+	blockers := map[int32]bool{}
+	for _, a := range m.rows {
+		for _, pid := range a.BlockedBy {
+			blockers[pid] = true
+		}
+	}
+	return blockers
+}
+
+// formatElapsed renders d to the largest two units, as "2h05m", "3m07s" or
+// "1.2s".
+func formatElapsed(d time.Duration) string {
+	switch {
+	case d <= 0:
+		return ""
+	case d < time.Second:
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	case d < time.Minute:
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	case d < time.Hour:
+		return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+	}
+	return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// oneLine collapses the whitespace of a query so it fits on a row.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func (m ActivityModel) View() string {
+	if !m.visible {
+		return ""
+	}
+
+	modalW := max(20, m.width-4)
+	textW := max(10, modalW-6)
+	blockers := m.blocking()
+
+	pids := make([]string, len(m.rows))
+	blocked := make([]string, len(m.rows))
+	pidW, userW, dbW, stateW, waitW, blockedW := len("pid"), len("user"), len("database"), len("state"), len("wait"), len("blocked by")
+	for i, a := range m.rows {
+		pids[i] = fmt.Sprint(a.PID)
+		by := make([]string, len(a.BlockedBy))
+		for j, pid := range a.BlockedBy {
+			by[j] = fmt.Sprint(pid)
+		}
+		blocked[i] = strings.Join(by, ",")
+		pidW = max(pidW, len(pids[i]))
+		userW = max(userW, lipgloss.Width(a.User))
+		dbW = max(dbW, lipgloss.Width(a.Database))
+		stateW = max(stateW, lipgloss.Width(a.State))
+		waitW = max(waitW, lipgloss.Width(a.Wait))
+		blockedW = max(blockedW, len(blocked[i]))
+	}
+	userW, dbW, waitW, blockedW = min(userW, 16), min(dbW, 16), min(waitW, 24), min(blockedW, 16)
+	const timeW = 7
+
+	var b strings.Builder
+	b.WriteString(HeaderStyle.Render(fmt.Sprintf("Server activity (%d backends)", len(m.rows))))
+	if !m.updated.IsZero() {
+		b.WriteString(DimText.Render("  as of " + m.updated.Format("15:04:05")))
+	}
+	b.WriteString("\n")
+	b.WriteString(DimText.Render(truncateDisplay("  r refresh | c cancel query | x terminate backend | * this connection | Esc close", textW)))
+	b.WriteString("\n")
+	switch {
+	case m.confirm != nil && m.confirm.Terminate:
+		b.WriteString(ErrorText.Render(fmt.Sprintf("  Terminate backend %d, rolling back its transaction? (y/n)", m.confirm.PID)))
+	case m.confirm != nil:
+		b.WriteString(ModifiedText.Render(fmt.Sprintf("  Cancel the query of backend %d? (y/n)", m.confirm.PID)))
+	case m.err != nil:
+		b.WriteString(ErrorText.Render(truncateDisplay("  "+m.err.Error(), textW)))
+	case m.statusMsg != "":
+		b.WriteString(DimText.Render(truncateDisplay("  "+m.statusMsg, textW)))
+	}
+	b.WriteString("\n\n")
+
+	header := fmt.Sprintf("    %s  %s  %s  %s  %s  %s  %s  %s", padDisplay("pid", pidW), padDisplay("user", userW),
+		padDisplay("database", dbW), padDisplay("state", stateW), padDisplay("time", timeW),
+		padDisplay("wait", waitW), padDisplay("blocked by", blockedW), "query")
+	b.WriteString(HeaderStyle.Render(truncateDisplay(header, textW)))
+
+	h := m.bodyHeight()
+	end := min(m.scroll+h, len(m.rows))
+	for i := m.scroll; i < end; i++ {
+		a := m.rows[i]
+		mark := " "
+		if a.Self {
+			mark = "*"
+		}
+		line := fmt.Sprintf("%s %s  %s  %s  %s  %s  %s  %s  %s", mark, padDisplay(pids[i], pidW),
+			padDisplay(truncateDisplay(a.User, userW), userW),
+			padDisplay(truncateDisplay(a.Database, dbW), dbW),
+			padDisplay(a.State, stateW), padDisplay(formatElapsed(a.Duration), timeW),
+			padDisplay(truncateDisplay(a.Wait, waitW), waitW),
+			padDisplay(truncateDisplay(blocked[i], blockedW), blockedW), oneLine(a.Query))
+		line = truncateDisplay(line, textW-2)
+		b.WriteString("\n")
+		switch {
+		case i == m.cursor:
+			b.WriteString(AccentText.Bold(true).Render("▸ " + line))
+		case blockers[a.PID]:
+			b.WriteString(ErrorText.Render("  " + line))
+		case len(a.BlockedBy) > 0:
+			b.WriteString(ModifiedText.Render("  " + line))
+		case a.State != "active":
+			b.WriteString(DimText.Render("  " + line))
+		default:
+			b.WriteString("  " + line)
+		}
+	}
+	if len(m.rows) == 0 && m.err == nil {
+		b.WriteString("\n" + DimText.Render("  Loading…"))
+	}
+
+	// The whole query of the selected backend, as far as it fits.
+	b.WriteString("\n\n")
+	if m.cursor < len(m.rows) {
+		a := m.rows[m.cursor]
+		about := fmt.Sprintf("Backend %d", a.PID)
+		if a.Application != "" {
+			about += " (" + a.Application + ")"
+		}
+		if blockers[a.PID] {
+			about += ": blocking others"
+		}
+		b.WriteString(SubHeaderStyle.Render(truncateDisplay(sanitizeCell(about), textW)))
+		b.WriteString("\n")
+		b.WriteString(truncateDisplay(sanitizeCell(oneLine(a.Query)), textW))
+	}
+
+	modalStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ColorAccent).
+		Padding(1, 2).
+		Width(modalW)
+
+	return centerModal(modalStyle.Render(b.String()), m.width, m.height)
+}
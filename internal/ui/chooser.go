@@ -0,0 +1,137 @@
+package ui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ChooserSelectedMsg is sent when the user picks an option in the chooser.
+type ChooserSelectedMsg struct {
+	Index int
+}
+
+// ChooserModel is a small modal list for picking one of several options.
+type ChooserModel struct {
+	visible bool
+	title   string
+	options []string
+	cursor  int
+	width   int
+	height  int
+}
+
+func NewChooserModel() ChooserModel {
+	return ChooserModel{}
+}
+
+// Open shows the chooser with the given options.
+func (m *ChooserModel) Open(title string, options []string) {
+	m.visible = true
+	m.title = title
+	m.options = options
+	m.cursor = 0
+}
+
+func (m *ChooserModel) Close() {
+	m.visible = false
+}
+
+func (m ChooserModel) Visible() bool {
+	return m.visible
+}
+
+func (m *ChooserModel) SetSize(w, h int) {
+	m.width = w
+	m.height = h
+}
+
+func (m ChooserModel) Update(msg tea.Msg) (ChooserModel, tea.Cmd) {
+	if !m.visible {
+		return m, nil
+	}
+
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "esc", "q":
+			m.Close()
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.cursor < len(m.options)-1 {
+				m.cursor++
+			}
+		case "enter":
+			if m.cursor < len(m.options) {
+				idx := m.cursor
+				m.Close()
+				return m, func() tea.Msg { return ChooserSelectedMsg{Index: idx} }
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m ChooserModel) View() string {
+	if !m.visible {
+		return ""
+	}
+
+	modalW := 60
+	if m.width > 0 && modalW > m.width-4 {
+		modalW = m.width - 4
+	}
+
+	var b strings.Builder
+	b.WriteString(HeaderStyle.Render(m.title))
+	b.WriteString("\n")
+	b.WriteString(DimText.Render("  Enter choose | Esc cancel"))
+	b.WriteString("\n\n")
+	for i, opt := range m.options {
+		if i == m.cursor {
+			b.WriteString(SidebarCursorItem.Width(modalW - 4).Render("  " + opt))
+		} else {
+			b.WriteString(SidebarTableItem.Render("  " + opt))
+		}
+		b.WriteString("\n")
+	}
+
+	modalStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ColorAccent).
+		Padding(1, 2).
+		Width(modalW)
+
+	return centerModal(modalStyle.Render(b.String()), m.width, m.height)
+}
+
+// centerModal pads a rendered modal so it sits in the middle of a w×h screen.
+func centerModal(rendered string, w, h int) string {
+	if w <= 0 || h <= 0 {
+		return rendered
+	}
+	renderedLines := strings.Split(rendered, "\n")
+	modalH := len(renderedLines)
+	topPad := (h - modalH) / 2
+	if topPad < 0 {
+		topPad = 0
+	}
+	leftPad := (w - lipgloss.Width(rendered)) / 2
+	if leftPad < 0 {
+		leftPad = 0
+	}
+
+	var out strings.Builder
+	for i := 0; i < topPad; i++ {
+		out.WriteString("\n")
+	}
+	for _, line := range renderedLines {
+		out.WriteString(strings.Repeat(" ", leftPad))
+		out.WriteString(line)
+		out.WriteString("\n")
+	}
+	return out.String()
+}
@@ -0,0 +1,359 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"cli-sql/internal/db"
+)
+
+// DescribeTableMsg is sent when the user asks to see a table's columns.
+type DescribeTableMsg struct {
+	Table string
+}
+
+// GeneratedSQLMsg carries a statement built in the columns overlay, a
+// CREATE INDEX or COMMENT ON, for the editor.
+type GeneratedSQLMsg struct {
+	SQL string
+}
+
+// indexMethods are the access methods the columns overlay cycles through.
+var indexMethods = []string{"btree", "hash", "gin", "gist", "brin"}
+
+// ColumnsModel is a modal describing a table's columns and indexes, from
+// which a CREATE INDEX over the picked columns can be built.
+type ColumnsModel struct {
+	visible  bool
+	table    string
+	comment  string
+	columns  []db.ColumnInfo
+	indexes  []db.IndexInfo
+	picked   []int // indexes into columns, in the order they were picked
+	cursor   int
+	scroll   int
+	unique   bool
+	method   int // into indexMethods
+	width    int
+	height   int
+	errorMsg string
+}
+
+func NewColumnsModel() ColumnsModel {
+	return ColumnsModel{}
+}
+
+// Open describes table, whose own comment is comment.
+func (m *ColumnsModel) Open(table, comment string, columns []db.ColumnInfo, indexes []db.IndexInfo) {
+	*m = ColumnsModel{visible: true, table: table, comment: comment, columns: columns, indexes: indexes,
+		width: m.width, height: m.height}
+}
+
+func (m *ColumnsModel) Close() {
+	m.visible = false
+}
+
+func (m ColumnsModel) Visible() bool {
+	return m.visible
+}
+
+func (m *ColumnsModel) SetSize(w, h int) {
+	m.width = w
+	m.height = h
+}
+
+func (m ColumnsModel) Update(msg tea.Msg) (ColumnsModel, tea.Cmd) {
+	if !m.visible {
+		return m, nil
+	}
+
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		m.errorMsg = ""
+		switch {
+		case msg.String() == "esc" || msg.String() == "q":
+			m.Close()
+		case KeyMatches(msg, ActionUp):
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case KeyMatches(msg, ActionDown):
+			if m.cursor < len(m.columns)-1 {
+				m.cursor++
+			}
+		case msg.String() == " ":
+			m.togglePick(m.cursor)
+		case msg.String() == "u":
+			m.unique = !m.unique
+			if m.unique {
+				// Only btree indexes can enforce uniqueness.
+				m.method = 0
+			}
+		case msg.String() == "m":
+			m.method = (m.method + 1) % len(indexMethods)
+			if m.method != 0 {
+				m.unique = false
+			}
+		case msg.String() == "enter":
+			picked := m.picked
+			if len(picked) == 0 && len(m.columns) > 0 {
+				picked = []int{m.cursor}
+			}
+			if len(picked) == 0 {
+				m.errorMsg = "No columns to index"
+				return m, nil
+			}
+			sql := m.indexSQL(picked)
+			m.Close()
+			return m, func() tea.Msg { return GeneratedSQLMsg{SQL: sql} }
+		case msg.String() == "t":
+			sql := fmt.Sprintf("COMMENT ON TABLE %s IS %s;", db.QuoteIdentifier(m.table), db.QuoteLiteral(m.comment))
+			m.Close()
+			return m, func() tea.Msg { return GeneratedSQLMsg{SQL: sql} }
+		case msg.String() == "c" && len(m.columns) > 0:
+			c := m.columns[m.cursor]
+			sql := fmt.Sprintf("COMMENT ON COLUMN %s.%s IS %s;", db.QuoteIdentifier(m.table), db.QuoteIdentifier(c.Name), db.QuoteLiteral(c.Comment))
+			m.Close()
+			return m, func() tea.Msg { return GeneratedSQLMsg{SQL: sql} }
+		}
+		h := m.bodyHeight()
+		if m.cursor < m.scroll {
+			m.scroll = m.cursor
+		} else if m.cursor >= m.scroll+h {
+			m.scroll = m.cursor - h + 1
+		}
+	}
+	return m, nil
+}
+
+// togglePick adds column i to the index, or takes it out.
+func (m *ColumnsModel) togglePick(i int) {
+	for j, p := range m.picked {
+		if p == i {
+			m.picked = append(m.picked[:j:j], m.picked[j+1:]...)
+			return
+		}
+	}
+	m.picked = append(m.picked, i)
+}
+
+// pickOrder returns the 1-based position of column i in the index, or 0.
+func (m ColumnsModel) pickOrder(i int) int {
+	for j, p := range m.picked {
+		if p == i {
+			return j + 1
+		}
+	}
+	return 0
+}
+
+// indexSQL builds the CREATE INDEX over columns picked, named after the
+// table and columns as idx_<table>_<columns>.
+func (m ColumnsModel) indexSQL(picked []int) string {
+	names := make([]string, len(picked))
+	quoted := make([]string, len(picked))
+	for i, p := range picked {
+		names[i] = m.columns[p].Name
+		quoted[i] = db.QuoteIdentifier(m.columns[p].Name)
+	}
+	table := m.table
+	if i := strings.LastIndexByte(table, '.'); i >= 0 {
+		table = table[i+1:]
+	}
+
+	var b strings.Builder
+	b.WriteString("CREATE ")
+	if m.unique {
+		b.WriteString("UNIQUE ")
+	}
+	fmt.Fprintf(&b, "INDEX %s ON %s", db.QuoteIdentifier(indexName(table, names)), db.QuoteIdentifier(m.table))
+	if method := indexMethods[m.method]; method != "btree" {
+		b.WriteString(" USING " + method)
+	}
+	fmt.Fprintf(&b, " (%s);", strings.Join(quoted, ", "))
+	return b.String()
+}
+
+// indexName returns idx_<table>_<col>_..., lowercased with anything but
+// letters, digits and underscores replaced, and cut to PostgreSQL's 63-byte
+// identifier limit.
+func indexName(table string, columns []string) string {
+	name := "idx_" + table + "_" + strings.Join(columns, "_")
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		}
+		return '_'
+	}, name)
+	if len(name) > 63 {
+		name = name[:63]
+	}
+	return name
+}
+
+// indexUse returns "●" if column leads one of the indexes, "·" if it is a
+// later key of one, and " " otherwise.
+func (m ColumnsModel) indexUse(column string) string {
+	use := " "
+	for _, ix := range m.indexes {
+		for i, c := range ix.Columns {
+			if unquoteIdent(c) != column {
+				continue
+			}
+			if i == 0 {
+				return "●"
+			}
+			use = "·"
+		}
+	}
+	return use
+}
+
+// unquoteIdent undoes the quoting pg_get_indexdef gives a column name.
+func unquoteIdent(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		return strings.ReplaceAll(s[1:len(s)-1], `""`, `"`)
+	}
+	return s
+}
+
+// indexLines describes each index on one line:
+// "users_pkey  btree PRIMARY KEY (id)".
+func (m ColumnsModel) indexLines() []string {
+	lines := make([]string, len(m.indexes))
+	for i, ix := range m.indexes {
+		kind := ""
+		switch {
+		case ix.Primary:
+			kind = " PRIMARY KEY"
+		case ix.Unique:
+			kind = " UNIQUE"
+		}
+		lines[i] = fmt.Sprintf("%s  %s%s (%s)", ix.Name, ix.Method, kind, strings.Join(ix.Columns, ", "))
+		if ix.Predicate != "" {
+			lines[i] += " WHERE " + ix.Predicate
+		}
+	}
+	return lines
+}
+
+// indexHeight is how many lines the index list below the columns takes:
+// its heading and up to a third of the modal.
+func (m ColumnsModel) indexHeight() int {
+	if len(m.indexes) == 0 {
+		return 2
+	}
+	return 2 + min(len(m.indexes), max(1, (m.height-10)/3))
+}
+
+// bodyHeight is how many columns fit inside the modal.
+func (m ColumnsModel) bodyHeight() int {
+	// Border, padding, title, comment, hint, blank and header lines, and
+	// the indexes.
+	h := m.height - 10 - m.indexHeight()
+	if m.comment != "" {
+		h--
+	}
+	return max(1, h)
+}
+
+func (m ColumnsModel) View() string {
+	if !m.visible {
+		return ""
+	}
+
+	modalW := 90
+	if m.width > 0 && modalW > m.width-4 {
+		modalW = m.width - 4
+	}
+	textW := max(10, modalW-6)
+
+	nameW, typeW := len("column"), len("type")
+	for _, c := range m.columns {
+		nameW = max(nameW, lipgloss.Width(c.Name))
+		typeW = max(typeW, lipgloss.Width(c.DataType))
+	}
+	nameW, typeW = min(nameW, 30), min(typeW, 24)
+
+	var b strings.Builder
+	b.WriteString(HeaderStyle.Render(truncateDisplay(fmt.Sprintf("Columns of %s (%d)", m.table, len(m.columns)), textW)))
+	b.WriteString("\n")
+	if m.comment != "" {
+		b.WriteString(truncateDisplay(sanitizeCell(m.comment), textW))
+		b.WriteString("\n")
+	}
+	index := "index: " + indexMethods[m.method]
+	if m.unique {
+		index = "index: UNIQUE " + indexMethods[m.method]
+	}
+	b.WriteString(DimText.Render(truncateDisplay(
+		fmt.Sprintf("  %s | Space pick | u unique | m method | Enter build | c/t comment | Esc", index), textW)))
+	b.WriteString("\n")
+	if m.errorMsg != "" {
+		b.WriteString(ErrorText.Render("  " + m.errorMsg))
+	}
+	b.WriteString("\n")
+
+	header := fmt.Sprintf("        %s  %s  %s  %s", padDisplay("column", nameW), padDisplay("type", typeW), "null", "default")
+	b.WriteString(HeaderStyle.Render(truncateDisplay(header, textW)))
+
+	h := m.bodyHeight()
+	end := min(m.scroll+h, len(m.columns))
+	for i := m.scroll; i < end; i++ {
+		c := m.columns[i]
+		pick := "   "
+		if n := m.pickOrder(i); n > 0 {
+			pick = fmt.Sprintf("[%d]", n)
+		}
+		null := "yes "
+		if c.IsNullable == "NO" {
+			null = "no  "
+		}
+		def := ""
+		if c.ColumnDefault != nil {
+			def = *c.ColumnDefault
+		}
+		line := fmt.Sprintf("%s %s %s  %s  %s  %s", pick, m.indexUse(c.Name),
+			padDisplay(truncateDisplay(c.Name, nameW), nameW),
+			padDisplay(truncateDisplay(c.DataType, typeW), typeW), null, sanitizeCell(def))
+		if c.Comment != "" {
+			line += "  -- " + sanitizeCell(c.Comment)
+		}
+		line = truncateDisplay(line, textW-2)
+		b.WriteString("\n")
+		if i == m.cursor {
+			b.WriteString(AccentText.Bold(true).Render("▸ " + line))
+		} else {
+			b.WriteString("  " + line)
+		}
+	}
+
+	b.WriteString("\n\n")
+	if len(m.indexes) == 0 {
+		b.WriteString(HeaderStyle.Render("No indexes"))
+	} else {
+		b.WriteString(HeaderStyle.Render(fmt.Sprintf("Indexes (%d)", len(m.indexes))))
+		b.WriteString(DimText.Render("  ● leading key  · later key"))
+		lines := m.indexLines()
+		shown := m.indexHeight() - 2
+		for i, line := range lines[:shown] {
+			if i == shown-1 && shown < len(lines) {
+				line = fmt.Sprintf("… and %d more", len(lines)-shown+1)
+			}
+			b.WriteString("\n  " + truncateDisplay(sanitizeCell(line), textW-2))
+		}
+	}
+
+	modalStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ColorAccent).
+		Padding(1, 2).
+		Width(modalW)
+
+	return centerModal(modalStyle.Render(b.String()), m.width, m.height)
+}
@@ -0,0 +1,378 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"cli-sql/internal/db"
+	"cli-sql/internal/editor"
+)
+
+// DiffKind says how a row of one result set compares with the other.
+type DiffKind int
+
+const (
+	DiffSame DiffKind = iota
+	DiffAdded
+	DiffRemoved
+	DiffChanged
+)
+
+// DiffRow is one row of a ResultDiff. Left is nil for an added row and
+// Right for a removed one. Null cells hold editor.NullValue.
+type DiffRow struct {
+	Kind        DiffKind
+	Left, Right []string
+}
+
+// ResultDiff is the row-by-row comparison of two result sets.
+type ResultDiff struct {
+	Columns                       []string
+	Keys                          []string // columns rows were matched on; empty for whole rows
+	Rows                          []DiffRow
+	Same, Added, Removed, Changed int
+}
+
+// Summary counts the rows of each kind, as "2 added, 1 removed, 3 changed, 40 same".
+func (d ResultDiff) Summary() string {
+	return fmt.Sprintf("%d added, %d removed, %d changed, %d same", d.Added, d.Removed, d.Changed, d.Same)
+}
+
+// DiffResults compares right against left, matching rows on the key
+// columns. Without keys a row is only matched by an identical one, so a
+// changed row shows as removed and added. Rows keep left's order, with
+// rows only in right after them. Both sets must have the same columns.
+func DiffResults(left, right *db.QueryResult, keys []string) (ResultDiff, error) {
+	d := ResultDiff{Columns: left.Columns, Keys: keys}
+	rightCol := make(map[string]int, len(right.Columns))
+	for i, c := range right.Columns {
+		rightCol[c] = i
+	}
+	if len(left.Columns) != len(right.Columns) {
+		return d, fmt.Errorf("the results have different columns (%d and %d)", len(left.Columns), len(right.Columns))
+	}
+	order := make([]int, len(left.Columns)) // right column of each left column
+	for i, c := range left.Columns {
+		j, ok := rightCol[c]
+		if !ok {
+			return d, fmt.Errorf("column %s is missing from the second result", c)
+		}
+		order[i] = j
+	}
+	keyCols := make([]int, len(keys))
+	for i, k := range keys {
+		keyCols[i] = -1
+		for j, c := range left.Columns {
+			if c == k {
+				keyCols[i] = j
+			}
+		}
+		if keyCols[i] < 0 {
+			return d, fmt.Errorf("key column %s is not in the results", k)
+		}
+	}
+
+	leftRows := diffCells(left, nil)
+	rightRows := diffCells(right, order)
+	keyOf := func(row []string) string {
+		if len(keyCols) == 0 {
+			return strings.Join(row, "\x1f")
+		}
+		parts := make([]string, len(keyCols))
+		for i, c := range keyCols {
+			parts[i] = row[c]
+		}
+		return strings.Join(parts, "\x1f")
+	}
+
+	// A key may repeat when the rows aren't keyed; match them in order.
+	pending := make(map[string][]int, len(rightRows))
+	for i, row := range rightRows {
+		k := keyOf(row)
+		pending[k] = append(pending[k], i)
+	}
+	matched := make([]bool, len(rightRows))
+	for _, row := range leftRows {
+		k := keyOf(row)
+		idx := pending[k]
+		if len(idx) == 0 {
+			d.Rows = append(d.Rows, DiffRow{Kind: DiffRemoved, Left: row})
+			d.Removed++
+			continue
+		}
+		pending[k] = idx[1:]
+		matched[idx[0]] = true
+		other := rightRows[idx[0]]
+		if equalRows(row, other) {
+			d.Rows = append(d.Rows, DiffRow{Kind: DiffSame, Left: row, Right: other})
+			d.Same++
+		} else {
+			d.Rows = append(d.Rows, DiffRow{Kind: DiffChanged, Left: row, Right: other})
+			d.Changed++
+		}
+	}
+	for i, row := range rightRows {
+		if !matched[i] {
+			d.Rows = append(d.Rows, DiffRow{Kind: DiffAdded, Right: row})
+			d.Added++
+		}
+	}
+	return d, nil
+}
+
+// diffCells returns the displayed rows of r with nulls as editor.NullValue,
+// the columns reordered by order when it is given.
+func diffCells(r *db.QueryResult, order []int) [][]string {
+	rows := make([][]string, len(r.Rows))
+	for i, src := range r.Rows {
+		row := make([]string, len(src))
+		for c := range src {
+			j := c
+			if order != nil {
+				j = order[c]
+			}
+			row[c] = src[j]
+			if i < len(r.Nulls) && r.Nulls[i][j] {
+				row[c] = editor.NullValue
+			}
+		}
+		rows[i] = row
+	}
+	return rows
+}
+
+func equalRows(a, b []string) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// maxDiffColWidth caps how wide a column of the diff grid is drawn.
+const maxDiffColWidth = 30
+
+// DiffModel is a modal showing a ResultDiff as one merged grid: rows only
+// in the second set marked +, rows only in the first -, and changed rows ~
+// with each differing cell shown as old → new.
+type DiffModel struct {
+	visible     bool
+	title       string
+	diff        ResultDiff
+	onlyChanged bool
+	scroll      int
+	colOffset   int
+	width       int
+	height      int
+}
+
+func NewDiffModel() DiffModel {
+	return DiffModel{}
+}
+
+// Open shows d under title, which names the two sides.
+func (m *DiffModel) Open(title string, d ResultDiff) {
+	m.visible = true
+	m.title = title
+	m.diff = d
+	m.onlyChanged = false
+	m.scroll = 0
+	m.colOffset = 0
+}
+
+func (m *DiffModel) Close() {
+	m.visible = false
+}
+
+func (m DiffModel) Visible() bool {
+	return m.visible
+}
+
+func (m *DiffModel) SetSize(w, h int) {
+	m.width = w
+	m.height = h
+}
+
+func (m DiffModel) Update(msg tea.Msg) (DiffModel, tea.Cmd) {
+	if !m.visible {
+		return m, nil
+	}
+
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case msg.String() == "esc" || msg.String() == "q":
+			m.Close()
+		case msg.String() == "c":
+			m.onlyChanged = !m.onlyChanged
+			m.scroll = 0
+		case KeyMatches(msg, ActionUp):
+			if m.scroll > 0 {
+				m.scroll--
+			}
+		case KeyMatches(msg, ActionDown):
+			if m.scroll < m.maxScroll() {
+				m.scroll++
+			}
+		case KeyMatches(msg, ActionPageUp):
+			m.scroll = max(0, m.scroll-m.bodyHeight())
+		case KeyMatches(msg, ActionPageDown):
+			m.scroll = min(m.maxScroll(), m.scroll+m.bodyHeight())
+		case KeyMatches(msg, ActionTop):
+			m.scroll = 0
+		case KeyMatches(msg, ActionBottom):
+			m.scroll = m.maxScroll()
+		case KeyMatches(msg, ActionLeft):
+			if m.colOffset > 0 {
+				m.colOffset--
+			}
+		case KeyMatches(msg, ActionRight):
+			if m.colOffset < len(m.diff.Columns)-1 {
+				m.colOffset++
+			}
+		}
+	}
+	return m, nil
+}
+
+// rows returns the rows shown: all of them, or only those that differ.
+func (m DiffModel) rows() []DiffRow {
+	if !m.onlyChanged {
+		return m.diff.Rows
+	}
+	var rows []DiffRow
+	for _, r := range m.diff.Rows {
+		if r.Kind != DiffSame {
+			rows = append(rows, r)
+		}
+	}
+	return rows
+}
+
+// bodyHeight is how many rows fit inside the modal.
+func (m DiffModel) bodyHeight() int {
+	// Border, padding, title, hint, blank and header lines.
+	return max(1, m.height-10)
+}
+
+func (m DiffModel) maxScroll() int {
+	return max(0, len(m.rows())-m.bodyHeight())
+}
+
+// diffCell is the text of column c in r: the value, or old → new where a
+// changed row differs.
+func diffCell(r DiffRow, c int) (string, bool) {
+	switch {
+	case r.Left == nil:
+		return sanitizeCell(cellLabel(r.Right[c])), false
+	case r.Right == nil || r.Left[c] == r.Right[c]:
+		return sanitizeCell(cellLabel(r.Left[c])), false
+	}
+	return sanitizeCell(cellLabel(r.Left[c])) + " → " + sanitizeCell(cellLabel(r.Right[c])), true
+}
+
+func (m DiffModel) View() string {
+	if !m.visible {
+		return ""
+	}
+
+	modalW := max(20, m.width-4)
+	textW := max(10, modalW-6)
+	rows := m.rows()
+	h := m.bodyHeight()
+	start := min(m.scroll, m.maxScroll())
+	end := min(start+h, len(rows))
+
+	// Columns are sized to the rows on screen.
+	widths := make([]int, len(m.diff.Columns))
+	for c, name := range m.diff.Columns {
+		widths[c] = lipgloss.Width(name)
+		for _, r := range rows[start:end] {
+			text, _ := diffCell(r, c)
+			widths[c] = max(widths[c], lipgloss.Width(text))
+		}
+		widths[c] = min(widths[c], maxDiffColWidth)
+	}
+	var visible []int
+	used := 2 // marker
+	for c := m.colOffset; c < len(m.diff.Columns); c++ {
+		if len(visible) > 0 && used+widths[c]+2 > textW {
+			break
+		}
+		visible = append(visible, c)
+		used += widths[c] + 2
+	}
+
+	var b strings.Builder
+	b.WriteString(HeaderStyle.Render(truncateDisplay(m.title, textW)))
+	b.WriteString("\n")
+	summary := m.diff.Summary()
+	if len(m.diff.Keys) > 0 {
+		summary += " | keyed on " + strings.Join(m.diff.Keys, ", ")
+	} else {
+		summary += " | no primary key: rows matched whole"
+	}
+	b.WriteString(DimText.Render(truncateDisplay(summary, textW)))
+	b.WriteString("\n")
+	show := "c changes only"
+	if m.onlyChanged {
+		show = "c show all rows"
+	}
+	hint := fmt.Sprintf("  %s | %s/%s columns | Esc close", show, KeyLabel(ActionLeft), KeyLabel(ActionRight))
+	if len(rows) > h {
+		hint += fmt.Sprintf(" [%d-%d of %d]", start+1, end, len(rows))
+	}
+	b.WriteString(DimText.Render(truncateDisplay(hint, textW)))
+	b.WriteString("\n\n")
+
+	header := "  "
+	for _, c := range visible {
+		header += padDisplay(truncateDisplay(m.diff.Columns[c], widths[c]), widths[c]) + "  "
+	}
+	b.WriteString(HeaderStyle.Render(header))
+	for _, r := range rows[start:end] {
+		b.WriteString("\n")
+		marker, style := "  ", lipgloss.NewStyle()
+		switch r.Kind {
+		case DiffAdded:
+			marker, style = "+ ", NewRowText
+		case DiffRemoved:
+			marker, style = "- ", DeletedText
+		case DiffChanged:
+			marker = ModifiedText.Render("~ ")
+		}
+		if r.Kind != DiffChanged {
+			marker = style.Render(marker)
+		}
+		b.WriteString(marker)
+		for _, c := range visible {
+			text, changed := diffCell(r, c)
+			cell := padDisplay(truncateDisplay(text, widths[c]), widths[c])
+			switch {
+			case changed:
+				cell = ModifiedText.Render(cell)
+			case r.Kind == DiffSame:
+				cell = DimText.Render(cell)
+			default:
+				cell = style.Render(cell)
+			}
+			b.WriteString(cell + "  ")
+		}
+	}
+
+	modalStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ColorAccent).
+		Padding(1, 2).
+		Width(modalW)
+
+	return centerModal(modalStyle.Render(b.String()), m.width, m.height)
+}
+
+// padDisplay pads s with spaces to w terminal columns.
+func padDisplay(s string, w int) string {
+	return s + strings.Repeat(" ", max(0, w-lipgloss.Width(s)))
+}
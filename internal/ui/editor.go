@@ -2,8 +2,11 @@ package ui
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 	"unicode"
+	"unicode/utf8"
 
 	"github.com/charmbracelet/bubbles/textarea"
 	tea "github.com/charmbracelet/bubbletea"
@@ -15,7 +18,18 @@ type ExecuteQueryMsg struct {
 	SQL string
 }
 
-var GhostStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#555555"))
+// GhostStyle renders autocomplete suggestions; ApplyTheme sets it.
+var GhostStyle lipgloss.Style
+
+// BracketMatch marks the parenthesis paired with the one under the cursor;
+// ApplyTheme sets it.
+var BracketMatch lipgloss.Style
+
+// ErrorMark underlines the token a query error points at; ApplyTheme sets it.
+var ErrorMark lipgloss.Style
+
+// autoPairs are the characters the editor closes as they are typed.
+var autoPairs = map[rune]rune{'(': ')', '\'': '\'', '"': '"'}
 
 type ghostCandidate struct {
 	full    string
@@ -35,6 +49,16 @@ type EditorModel struct {
 	ghostMatches    []ghostCandidate
 	ghostIndex      int
 	tableNames      []string
+	keywordCase     KeywordCase
+	// errStart and errEnd are the byte range of the text marked by
+	// MarkError; errEnd is 0 when nothing is marked.
+	errStart int
+	errEnd   int
+	history  editHistory
+	// gotoInput is the line number typed at the go-to-line prompt, shown
+	// in the header while gotoLine is set.
+	gotoLine  bool
+	gotoInput string
 }
 
 // SetTableNames updates the list of table names used for autocomplete.
@@ -42,6 +66,11 @@ func (m *EditorModel) SetTableNames(names []string) {
 	m.tableNames = names
 }
 
+// SetKeywordCase sets the keyword case used when formatting SQL.
+func (m *EditorModel) SetKeywordCase(kc KeywordCase) {
+	m.keywordCase = kc
+}
+
 // NewEditorModel creates a new SQL editor.
 func NewEditorModel() EditorModel {
 	ta := textarea.New()
@@ -63,6 +92,7 @@ func (m *EditorModel) SetFocused(f bool) {
 		m.textarea.Focus()
 	} else {
 		m.textarea.Blur()
+		m.gotoLine = false
 	}
 }
 
@@ -98,53 +128,117 @@ func (m EditorModel) Init() tea.Cmd {
 	return nil
 }
 
-// Update handles key events.
+// Update handles key events. Edits are recorded for undo, keystrokes typed
+// in quick succession as one step.
 func (m EditorModel) Update(msg tea.Msg) (EditorModel, tea.Cmd) {
 	if !m.focused {
 		return m, nil
 	}
+	if key, ok := msg.(tea.KeyMsg); ok {
+		if m.gotoLine {
+			m.updateGoToLine(key)
+			return m, nil
+		}
+		switch {
+		case KeyMatches(key, ActionGoToLine):
+			m.gotoLine = true
+			m.gotoInput = ""
+			return m, nil
+		case KeyMatches(key, ActionUndo):
+			m.undo()
+			return m, nil
+		case KeyMatches(key, ActionRedo):
+			m.redo()
+			return m, nil
+		}
+	}
 
+	before := m.snapshot()
+	m, cmd := m.update(msg)
+	if m.textarea.Value() != before.text {
+		m.history.record(before, time.Now(), significantEdit(msg))
+	}
+	return m, cmd
+}
+
+func (m EditorModel) update(msg tea.Msg) (EditorModel, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+j":
+		switch {
+		case msg.Paste:
+			m.paste(msg.Runes)
+			return m, nil
+		case KeyMatches(msg, ActionWordLeft):
+			m.wordLeft()
+			m.clearGhost()
+			return m, nil
+		case KeyMatches(msg, ActionWordRight):
+			m.wordRight()
+			m.clearGhost()
+			return m, nil
+		case KeyMatches(msg, ActionDeleteWord):
+			m.deleteWordBefore()
+			m.clearErrorMark()
+			m.updateGhost()
+			return m, nil
+		case KeyMatches(msg, ActionExecuteStatement):
 			sql := m.statementAtCursor()
 			if sql == "" {
 				return m, nil
 			}
-			formatted := FormatSQL(sql)
+			formatted := FormatSQLWithCase(sql, m.keywordCase)
 			m.textarea.Reset()
 			m.textarea.InsertString(formatted)
 			m.clearGhost()
+			m.clearErrorMark()
 			return m, func() tea.Msg {
 				return ExecuteQueryMsg{SQL: sql}
 			}
-		case "ctrl+e":
+		case KeyMatches(msg, ActionExecuteAll):
 			sql := strings.TrimSpace(m.textarea.Value())
 			if sql == "" {
 				return m, nil
 			}
-			formatted := FormatSQL(sql)
+			formatted := FormatSQLWithCase(sql, m.keywordCase)
 			m.textarea.Reset()
 			m.textarea.InsertString(formatted)
 			m.clearGhost()
+			m.clearErrorMark()
 			return m, func() tea.Msg {
 				return ExecuteQueryMsg{SQL: sql}
 			}
-		case "tab":
-			if m.ghost != "" {
-				for i := 0; i < m.ghostPartialLen; i++ {
-					m.textarea, _ = m.textarea.Update(tea.KeyMsg{Type: tea.KeyBackspace})
-				}
-				m.textarea.InsertString(m.ghostFull)
-				m.clearGhost()
-				m.updateGhost()
+		case KeyMatches(msg, ActionExplain):
+			sql := m.statementAtCursor()
+			if sql == "" {
 				return m, nil
 			}
+			return m, func() tea.Msg {
+				return ExplainQueryMsg{SQL: sql}
+			}
+		case KeyMatches(msg, ActionFormat):
+			text := m.textarea.Value()
+			if strings.TrimSpace(text) == "" {
+				return m, nil
+			}
+			line := m.textarea.Line()
+			m.textarea.SetValue(FormatSQLWithCase(text, m.keywordCase))
+			m.setCursorLine(line)
+			m.clearGhost()
+			m.clearErrorMark()
+			return m, nil
+		case KeyMatches(msg, ActionAcceptCompletion) && m.ghost != "":
+			for i := 0; i < m.ghostPartialLen; i++ {
+				m.textarea, _ = m.textarea.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+			}
+			m.textarea.InsertString(m.ghostFull)
+			m.clearGhost()
+			m.updateGhost()
+			return m, nil
+		case msg.String() == "tab":
 			m.textarea.InsertString("  ")
 			m.updateGhost()
 			return m, nil
-		case "up":
+		case msg.String() == "up":
 			if len(m.ghostMatches) > 1 {
 				m.ghostIndex--
 				if m.ghostIndex < 0 {
@@ -153,7 +247,7 @@ func (m EditorModel) Update(msg tea.Msg) (EditorModel, tea.Cmd) {
 				m.applyGhostIndex()
 				return m, nil
 			}
-		case "down":
+		case msg.String() == "down":
 			if len(m.ghostMatches) > 1 {
 				m.ghostIndex++
 				if m.ghostIndex >= len(m.ghostMatches) {
@@ -162,11 +256,25 @@ func (m EditorModel) Update(msg tea.Msg) (EditorModel, tea.Cmd) {
 				m.applyGhostIndex()
 				return m, nil
 			}
+		case msg.Type == tea.KeyRunes && len(msg.Runes) == 1 && !msg.Alt:
+			if m.typePair(msg.Runes[0]) {
+				m.updateGhost()
+				return m, nil
+			}
+		case msg.Type == tea.KeyBackspace:
+			if m.deletePair() {
+				m.updateGhost()
+				return m, nil
+			}
 		}
 	}
 
+	before := m.textarea.Value()
 	var cmd tea.Cmd
 	m.textarea, cmd = m.textarea.Update(msg)
+	if m.textarea.Value() != before {
+		m.clearErrorMark()
+	}
 	m.updateGhost()
 	return m, cmd
 }
@@ -176,11 +284,199 @@ func (m EditorModel) Value() string {
 	return m.textarea.Value()
 }
 
-// SetValue replaces the editor content with the given text.
+// SetValue replaces the editor content with the given text, as one step
+// that can be undone.
 func (m *EditorModel) SetValue(s string) {
+	if before := m.snapshot(); before.text != s {
+		m.history.record(before, time.Now(), true)
+	}
 	m.textarea.Reset()
 	m.textarea.InsertString(s)
 	m.clearGhost()
+	m.clearErrorMark()
+}
+
+// MarkError moves the cursor to character pos (1-based, as PostgreSQL
+// reports error positions) of sql and underlines the token there. sql is
+// what was sent to the server; the editor holds it reformatted, so the two
+// are lined up by their non-space characters, and nothing is marked unless
+// they agree apart from whitespace and case. It returns the 1-based line
+// and column of the mark.
+func (m *EditorModel) MarkError(sql string, pos int) (line, col int, ok bool) {
+	text := m.textarea.Value()
+	if pos < 1 || foldSQL(sql) != foldSQL(text) {
+		return 0, 0, false
+	}
+
+	// Count the non-space characters before pos, and how many the token
+	// there spans.
+	src := []rune(sql)
+	idx := min(pos-1, len(src))
+	for idx < len(src) && unicode.IsSpace(src[idx]) {
+		idx++
+	}
+	n := 0
+	for _, r := range src[:idx] {
+		if !unicode.IsSpace(r) {
+			n++
+		}
+	}
+	tokLen := 1
+	if idx < len(src) && isWordRune(src[idx]) {
+		for idx+tokLen < len(src) && isWordRune(src[idx+tokLen]) {
+			tokLen++
+		}
+	}
+
+	var offs []int
+	for i, r := range text {
+		if !unicode.IsSpace(r) {
+			offs = append(offs, i)
+		}
+	}
+	if len(offs) == 0 {
+		return 0, 0, false
+	}
+	if n >= len(offs) {
+		// "at end of input": mark the last character.
+		n, tokLen = len(offs)-1, 1
+	}
+	last := offs[n+tokLen-1]
+	_, size := utf8.DecodeRuneInString(text[last:])
+	m.errStart, m.errEnd = offs[n], last+size
+
+	lineStart := strings.LastIndexByte(text[:m.errStart], '\n') + 1
+	line = strings.Count(text[:m.errStart], "\n")
+	col = utf8.RuneCountInString(text[lineStart:m.errStart])
+	m.setCursorLine(line)
+	m.textarea.SetCursor(col)
+	m.clearGhost()
+	return line + 1, col + 1, true
+}
+
+func (m *EditorModel) clearErrorMark() {
+	m.errStart, m.errEnd = 0, 0
+}
+
+// foldSQL drops whitespace and case, which formatting may change.
+func foldSQL(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), ""))
+}
+
+// cursorLineRunes returns the line holding the cursor and the cursor's
+// column in it.
+func (m EditorModel) cursorLineRunes() ([]rune, int) {
+	lines := strings.Split(m.textarea.Value(), "\n")
+	row := m.textarea.Line()
+	if row >= len(lines) {
+		return nil, 0
+	}
+	li := m.textarea.LineInfo()
+	return []rune(lines[row]), li.StartColumn + li.ColumnOffset
+}
+
+// typePair handles r when it opens or closes an auto-paired character:
+// an opener is inserted with its closer and the cursor between them, and a
+// closer typed in front of the same character steps over it. It returns
+// false to let r be typed normally.
+func (m *EditorModel) typePair(r rune) bool {
+	line, col := m.cursorLineRunes()
+	var prev, next rune
+	if col > 0 && col <= len(line) {
+		prev = line[col-1]
+	}
+	if col < len(line) {
+		next = line[col]
+	}
+
+	if (r == ')' || r == '\'' || r == '"') && next == r {
+		m.textarea.SetCursor(col + 1)
+		return true
+	}
+	closer, ok := autoPairs[r]
+	if !ok || isWordRune(next) {
+		return false
+	}
+	// A quote after a word is most likely closing one, or an apostrophe.
+	if r != '(' && isWordRune(prev) {
+		return false
+	}
+	m.textarea.InsertString(string(r) + string(closer))
+	m.textarea.SetCursor(col + 1)
+	return true
+}
+
+// deletePair deletes both halves of an empty pair when backspacing between
+// them, reporting whether it did.
+func (m *EditorModel) deletePair() bool {
+	line, col := m.cursorLineRunes()
+	if col == 0 || col >= len(line) || autoPairs[line[col-1]] != line[col] {
+		return false
+	}
+	m.textarea, _ = m.textarea.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	m.textarea, _ = m.textarea.Update(tea.KeyMsg{Type: tea.KeyDelete})
+	return true
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// setCursorLine moves the cursor to the start of the given line, clamped to
+// the buffer.
+func (m *EditorModel) setCursorLine(line int) {
+	for m.textarea.Line() > line {
+		m.textarea.CursorUp()
+	}
+	for m.textarea.Line() < line && m.textarea.Line() < m.textarea.LineCount()-1 {
+		m.textarea.CursorDown()
+	}
+	m.textarea.CursorStart()
+}
+
+// updateGoToLine handles a key at the go-to-line prompt: digits build the
+// number, Enter jumps to that line and Esc gives up.
+func (m *EditorModel) updateGoToLine(key tea.KeyMsg) {
+	switch key.Type {
+	case tea.KeyEsc:
+		m.gotoLine = false
+	case tea.KeyEnter:
+		m.gotoLine = false
+		if n, err := strconv.Atoi(m.gotoInput); err == nil && n > 0 {
+			m.GoToLine(n)
+		}
+	case tea.KeyBackspace:
+		if m.gotoInput != "" {
+			m.gotoInput = m.gotoInput[:len(m.gotoInput)-1]
+		}
+	case tea.KeyRunes:
+		for _, r := range key.Runes {
+			if r >= '0' && r <= '9' && len(m.gotoInput) < 7 {
+				m.gotoInput += string(r)
+			}
+		}
+	}
+}
+
+// GoToLine moves the cursor to the start of 1-based line n, or the last
+// line if there are fewer.
+func (m *EditorModel) GoToLine(n int) {
+	m.setCursorLine(min(n, m.textarea.LineCount()) - 1)
+	m.clearGhost()
+}
+
+// paste inserts text that arrived as one bracketed paste as it is: no
+// brackets or quotes are closed and the completion is worked out once, for
+// where the paste ends. Windows line endings become single newlines, which
+// the textarea would otherwise count twice.
+func (m *EditorModel) paste(runes []rune) {
+	text := strings.ReplaceAll(string(runes), "\r\n", "\n")
+	if text == "" {
+		return
+	}
+	m.textarea.InsertString(text)
+	m.clearErrorMark()
+	m.updateGhost()
 }
 
 func (m *EditorModel) clearGhost() {
@@ -210,7 +506,7 @@ func (m *EditorModel) updateGhost() {
 
 	line := lines[cursorLine]
 	li := m.textarea.LineInfo()
-	col := li.ColumnOffset
+	col := li.StartColumn + li.ColumnOffset
 
 	if col == 0 || col > len(line) {
 		m.clearGhost()
@@ -296,22 +592,28 @@ func (m EditorModel) statementAtCursor() string {
 	for i := 0; i < cursorLine && i < len(lines); i++ {
 		offset += len(lines[i]) + 1
 	}
+	if cursorLine < len(lines) {
+		li := m.textarea.LineInfo()
+		runes := []rune(lines[cursorLine])
+		col := li.StartColumn + li.ColumnOffset
+		if col > len(runes) {
+			col = len(runes)
+		}
+		offset += len(string(runes[:col]))
+	}
 
-	segments := strings.Split(text, ";")
-	pos := 0
-	for _, seg := range segments {
-		segEnd := pos + len(seg)
-		if offset <= segEnd {
-			trimmed := strings.TrimSpace(seg)
+	spans := splitStatements(text)
+	for _, sp := range spans {
+		if offset <= sp.end {
+			trimmed := strings.TrimSpace(text[sp.start:sp.end])
 			if trimmed != "" {
 				return trimmed
 			}
 		}
-		pos = segEnd + 1
 	}
 
-	for i := len(segments) - 1; i >= 0; i-- {
-		trimmed := strings.TrimSpace(segments[i])
+	for i := len(spans) - 1; i >= 0; i-- {
+		trimmed := strings.TrimSpace(text[spans[i].start:spans[i].end])
 		if trimmed != "" {
 			return trimmed
 		}
@@ -320,6 +622,80 @@ func (m EditorModel) statementAtCursor() string {
 	return ""
 }
 
+// SplitStatements splits text into its non-empty statements, trimmed and
+// without their terminating semicolons.
+func SplitStatements(text string) []string {
+	var stmts []string
+	for _, span := range splitStatements(text) {
+		if stmt := strings.TrimSpace(text[span.start:span.end]); stmt != "" {
+			stmts = append(stmts, stmt)
+		}
+	}
+	return stmts
+}
+
+// stmtSpan is the byte range of one statement, excluding its terminating semicolon.
+type stmtSpan struct {
+	start int
+	end   int
+}
+
+// splitStatements splits text on semicolons that are outside string literals,
+// dollar-quoted bodies, quoted identifiers and comments.
+func splitStatements(text string) []stmtSpan {
+	var spans []stmtSpan
+	start := 0
+	i := 0
+	for i < len(text) {
+		c := text[i]
+		if end := blockCommentEnd(text, i); end != -1 {
+			i = end
+			continue
+		}
+		if end := DollarQuoteEnd(text, i); end != -1 {
+			i = end
+			continue
+		}
+		if end := StringLiteralEnd(text, i); end != -1 {
+			i = end
+			continue
+		}
+		switch {
+		case c == '-' && i+1 < len(text) && text[i+1] == '-':
+			end := strings.IndexByte(text[i:], '\n')
+			if end == -1 {
+				i = len(text)
+			} else {
+				i += end
+			}
+		case c == '"':
+			i++
+			for i < len(text) {
+				if text[i] == c {
+					if i+1 < len(text) && text[i+1] == c {
+						i += 2
+						continue
+					}
+					break
+				}
+				i++
+			}
+			i++
+		case c == ';':
+			spans = append(spans, stmtSpan{start: start, end: i})
+			start = i + 1
+			i++
+		default:
+			i++
+		}
+	}
+	if start > len(text) {
+		start = len(text)
+	}
+	spans = append(spans, stmtSpan{start: start, end: len(text)})
+	return spans
+}
+
 // View renders the editor pane.
 func (m EditorModel) View() string {
 	borderStyle := UnfocusedBorder
@@ -337,7 +713,11 @@ func (m EditorModel) View() string {
 	}
 
 	titleLeft := HeaderStyle.Render("SQL Editor")
-	titleRight := DimText.Render("Ctrl+J line | Ctrl+E all | Ctrl+O scripts")
+	titleRight := DimText.Render("Ctrl+J line | Ctrl+E all | Ctrl+L format | Ctrl+O scripts")
+	if m.gotoLine {
+		titleLeft = SearchLabel.Render("Go to line: ") + SearchInput.Render(m.gotoInput+"▏")
+		titleRight = DimText.Render(fmt.Sprintf("1-%d | Enter go | Esc cancel", m.textarea.LineCount()))
+	}
 	gap := innerW - lipgloss.Width(titleLeft) - lipgloss.Width(titleRight)
 	if gap < 1 {
 		gap = 1
@@ -358,10 +738,47 @@ func (m EditorModel) renderHighlightedText() string {
 	lines := strings.Split(text, "\n")
 	cursorLine := m.textarea.Line()
 	li := m.textarea.LineInfo()
-	cursorCol := li.ColumnOffset
+	cursorCol := li.StartColumn + li.ColumnOffset
+
+	// Block comments and dollar-quoted bodies can span lines, so find them
+	// over the whole buffer and highlight each line as a range of it.
+	spans := multiLineLiterals(text)
+	lineStarts := make([]int, len(lines))
+	for i := 1; i < len(lines); i++ {
+		lineStarts[i] = lineStarts[i-1] + len(lines[i-1]) + 1
+	}
+
+	// Pair the parenthesis under the cursor with its match.
+	cursorPos, matchPos := -1, -1
+	if m.focused && cursorLine < len(lines) {
+		if runes := []rune(lines[cursorLine]); cursorCol < len(runes) {
+			cursorPos = lineStarts[cursorLine] + len(string(runes[:cursorCol]))
+			matchPos = matchingBracket(text, cursorPos)
+		}
+	}
+	// bracket renders text[start:end], marking the matching parenthesis.
+	bracket := func(start, end int) string {
+		if matchPos < start || matchPos >= end {
+			return highlightRange(text, start, end, spans)
+		}
+		return highlightRange(text, start, matchPos, spans) +
+			BracketMatch.Render(text[matchPos:matchPos+1]) +
+			highlightRange(text, matchPos+1, end, spans)
+	}
+	// highlight does the same, underlining the token of a query error.
+	highlight := func(start, end int) string {
+		if m.errEnd == 0 || m.errEnd > len(text) || m.errEnd <= start || m.errStart >= end {
+			return bracket(start, end)
+		}
+		s, e := max(start, m.errStart), min(end, m.errEnd)
+		return bracket(start, s) + ErrorMark.Render(text[s:e]) + bracket(e, end)
+	}
 
 	var result strings.Builder
 	lineNumWidth := 4
+	// Lines are not wrapped: each shows textW runes after the line number
+	// and a two-column gutter marking text hidden to the left or right.
+	textW := max(1, max(10, m.width-2)-lineNumWidth-2)
 
 	startLine := 0
 	displayLines := m.height - 4
@@ -388,43 +805,60 @@ func (m EditorModel) renderHighlightedText() string {
 		lineNumStyled := DimText.Render(lineNum)
 
 		line := ""
+		lineStart := 0
 		if i < len(lines) {
 			line = lines[i]
+			lineStart = lineStarts[i]
 		}
+		runes := []rune(line)
+		isCursorLine := i == cursorLine && m.focused
 
-		if i == cursorLine && m.focused {
-			before := ""
-			cursorChar := " "
-			after := ""
+		// Only the cursor line scrolls, far enough to keep the cursor in view.
+		hOff := 0
+		if isCursorLine && cursorCol >= textW {
+			hOff = cursorCol - textW + 1
+		}
+		visEnd := min(len(runes), hOff+textW)
+		// byteAt converts a rune index in the line to an offset in text.
+		byteAt := func(r int) int {
+			return lineStart + len(string(runes[:r]))
+		}
 
-			runes := []rune(line)
+		gutter := []rune("  ")
+		if hOff > 0 {
+			gutter[0] = '‹'
+		}
+		if len(runes) > visEnd {
+			gutter[1] = '›'
+		}
+		result.WriteString(lineNumStyled)
+		result.WriteString(AccentText.Render(string(gutter)))
+
+		if isCursorLine {
+			cursorChar := " "
+			cursorEnd := min(cursorCol, len(runes))
+			afterStart := visEnd
 			if cursorCol < len(runes) {
-				before = string(runes[:cursorCol])
 				cursorChar = string(runes[cursorCol])
-				if cursorCol+1 < len(runes) {
-					after = string(runes[cursorCol+1:])
-				}
-			} else {
-				before = line
+				afterStart = min(cursorCol+1, visEnd)
 			}
 
-			highlightedBefore := HighlightSQL(before)
-			cursorStyled := lipgloss.NewStyle().Reverse(true).Render(cursorChar)
-			highlightedAfter := HighlightSQL(after)
-
-			result.WriteString(lineNumStyled)
-			result.WriteString("  ")
-			result.WriteString(highlightedBefore)
-			result.WriteString(cursorStyled)
-			result.WriteString(highlightedAfter)
-
-			if m.ghost != "" {
-				result.WriteString(GhostStyle.Render(m.ghost))
+			cursorStyle := lipgloss.NewStyle().Reverse(true)
+			if matchPos != -1 {
+				cursorStyle = BracketMatch.Reverse(true)
+			}
+			result.WriteString(highlight(byteAt(hOff), byteAt(cursorEnd)))
+			result.WriteString(cursorStyle.Render(cursorChar))
+			result.WriteString(highlight(byteAt(afterStart), byteAt(visEnd)))
+
+			// The ghost completion follows the cursor, so show what fits of it.
+			room := textW - (cursorCol - hOff) - 1
+			if m.ghost != "" && visEnd == len(runes) && room > 0 {
+				ghost := []rune(m.ghost)
+				result.WriteString(GhostStyle.Render(string(ghost[:min(len(ghost), room)])))
 			}
 		} else {
-			result.WriteString(lineNumStyled)
-			result.WriteString("  ")
-			result.WriteString(HighlightSQL(line))
+			result.WriteString(highlight(lineStart, byteAt(visEnd)))
 		}
 
 		if i < endLine-1 {
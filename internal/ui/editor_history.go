@@ -0,0 +1,105 @@
+package ui
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// editHistoryLimit is how many steps back the editor can undo.
+const editHistoryLimit = 200
+
+// editPause is how long typing has to stop for the next edit to be undone
+// separately; quicker keystrokes are undone together.
+const editPause = time.Second
+
+// editSnapshot is the editor text with the cursor position in it.
+type editSnapshot struct {
+	text      string
+	line, col int
+}
+
+// editHistory holds the editor's undo and redo steps. It is separate from
+// the results grid's ChangeTracker, which undoes staged cell edits.
+type editHistory struct {
+	undo []editSnapshot
+	redo []editSnapshot
+	last time.Time // of the last edit recorded
+}
+
+// record keeps before, the state an edit changed, unless the edit follows
+// the last one closely enough to be part of it. A significant edit, such as
+// a paste or formatting, always starts a step of its own.
+func (h *editHistory) record(before editSnapshot, now time.Time, significant bool) {
+	if significant || len(h.undo) == 0 || now.Sub(h.last) > editPause {
+		h.undo = append(h.undo, before)
+		if len(h.undo) > editHistoryLimit {
+			h.undo = h.undo[len(h.undo)-editHistoryLimit:]
+		}
+	}
+	h.last = now
+	if significant {
+		// Nothing typed next should be folded into it.
+		h.last = time.Time{}
+	}
+	h.redo = nil
+}
+
+// step pops the latest state off from, pushing current onto to, and
+// returns it.
+func step(from, to *[]editSnapshot, current editSnapshot) (editSnapshot, bool) {
+	if len(*from) == 0 {
+		return editSnapshot{}, false
+	}
+	s := (*from)[len(*from)-1]
+	*from = (*from)[:len(*from)-1]
+	*to = append(*to, current)
+	return s, true
+}
+
+// significantEdit reports whether msg changes the text in one go, rather
+// than as part of typing.
+func significantEdit(msg tea.Msg) bool {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return true
+	}
+	return key.Paste || KeyMatches(key, ActionFormat) || KeyMatches(key, ActionExecuteStatement) ||
+		KeyMatches(key, ActionExecuteAll) || KeyMatches(key, ActionAcceptCompletion)
+}
+
+// snapshot returns the text and cursor position.
+func (m EditorModel) snapshot() editSnapshot {
+	li := m.textarea.LineInfo()
+	return editSnapshot{text: m.textarea.Value(), line: m.textarea.Line(), col: li.StartColumn + li.ColumnOffset}
+}
+
+// restore puts back the text and cursor of s.
+func (m *EditorModel) restore(s editSnapshot) {
+	m.textarea.SetValue(s.text)
+	m.setCursorLine(s.line)
+	m.textarea.SetCursor(s.col)
+	m.clearGhost()
+	m.clearErrorMark()
+}
+
+// undo goes back to the text before the last edit, reporting whether there
+// was one.
+func (m *EditorModel) undo() bool {
+	s, ok := step(&m.history.undo, &m.history.redo, m.snapshot())
+	if ok {
+		m.restore(s)
+		m.history.last = time.Time{}
+	}
+	return ok
+}
+
+// redo reapplies the last edit undone, reporting whether there was one.
+func (m *EditorModel) redo() bool {
+	s, ok := step(&m.history.redo, &m.history.undo, m.snapshot())
+	if ok {
+		m.restore(s)
+		m.history.last = time.Time{}
+	}
+	return ok
+}
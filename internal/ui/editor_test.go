@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{
+			name: "plain",
+			text: "SELECT 1; SELECT 2;",
+			want: []string{"SELECT 1", "SELECT 2"},
+		},
+		{
+			name: "semicolon in a string literal",
+			text: "INSERT INTO t VALUES (';'); SELECT 2",
+			want: []string{"INSERT INTO t VALUES (';')", "SELECT 2"},
+		},
+		{
+			name: "doubled quote in a literal",
+			text: "SELECT 'it''s; fine'; SELECT 2",
+			want: []string{"SELECT 'it''s; fine'", "SELECT 2"},
+		},
+		{
+			name: "escape string",
+			text: `SELECT E'a\'; b'; SELECT 2`,
+			want: []string{`SELECT E'a\'; b'`, "SELECT 2"},
+		},
+		{
+			name: "line comment",
+			text: "SELECT 1 -- x; y\n; SELECT 2",
+			want: []string{"SELECT 1 -- x; y", "SELECT 2"},
+		},
+		{
+			name: "block comment",
+			text: "SELECT /* a; b */ 1; SELECT 2",
+			want: []string{"SELECT /* a; b */ 1", "SELECT 2"},
+		},
+		{
+			name: "dollar-quoted body",
+			text: "CREATE FUNCTION f() RETURNS int AS $$ SELECT 1; SELECT 2; $$ LANGUAGE sql; SELECT f()",
+			want: []string{"CREATE FUNCTION f() RETURNS int AS $$ SELECT 1; SELECT 2; $$ LANGUAGE sql", "SELECT f()"},
+		},
+		{
+			name: "tagged dollar quote",
+			text: "DO $fn$ BEGIN PERFORM 1; END $fn$; SELECT 2",
+			want: []string{"DO $fn$ BEGIN PERFORM 1; END $fn$", "SELECT 2"},
+		},
+		{
+			name: "quoted identifier",
+			text: `SELECT "a;b" FROM t; SELECT 2`,
+			want: []string{`SELECT "a;b" FROM t`, "SELECT 2"},
+		},
+		{
+			name: "empty statements dropped",
+			text: " ; ;SELECT 1;;",
+			want: []string{"SELECT 1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SplitStatements(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitStatements(%q)\n got  %q\n want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package ui
+
+import (
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// isIdentRune reports whether r can be part of an SQL name as the editor's
+// word motions see it: a dotted, schema-qualified name is one word.
+func isIdentRune(r rune) bool {
+	return isWordRune(r) || r == '.'
+}
+
+// runeClass sorts runes into spaces (0), name characters (1) and other
+// punctuation (2); a word is a run of one class other than spaces.
+func runeClass(r rune) int {
+	switch {
+	case unicode.IsSpace(r):
+		return 0
+	case isIdentRune(r):
+		return 1
+	}
+	return 2
+}
+
+// wordStartBefore returns the column of the start of the word before col
+// in line, skipping spaces first.
+func wordStartBefore(line []rune, col int) int {
+	col = min(col, len(line))
+	for col > 0 && runeClass(line[col-1]) == 0 {
+		col--
+	}
+	if col == 0 {
+		return 0
+	}
+	class := runeClass(line[col-1])
+	for col > 0 && runeClass(line[col-1]) == class {
+		col--
+	}
+	return col
+}
+
+// wordEndAfter returns the column just past the end of the word after col
+// in line, skipping spaces first.
+func wordEndAfter(line []rune, col int) int {
+	for col < len(line) && runeClass(line[col]) == 0 {
+		col++
+	}
+	if col == len(line) {
+		return col
+	}
+	class := runeClass(line[col])
+	for col < len(line) && runeClass(line[col]) == class {
+		col++
+	}
+	return col
+}
+
+// cursorLine returns the runes of the line the cursor is on and the
+// cursor's column in it.
+func (m EditorModel) cursorLine() ([]rune, int) {
+	s := m.snapshot()
+	lines := strings.Split(s.text, "\n")
+	if s.line >= len(lines) {
+		return nil, 0
+	}
+	return []rune(lines[s.line]), s.col
+}
+
+// wordLeft moves the cursor to the start of the word before it, or to the
+// end of the line above from the start of a line.
+func (m *EditorModel) wordLeft() {
+	line, col := m.cursorLine()
+	if col == 0 {
+		if m.textarea.Line() > 0 {
+			m.textarea.CursorUp()
+			m.textarea.CursorEnd()
+		}
+		return
+	}
+	m.textarea.SetCursor(wordStartBefore(line, col))
+}
+
+// wordRight moves the cursor past the end of the word after it, or to the
+// start of the line below from the end of a line.
+func (m *EditorModel) wordRight() {
+	line, col := m.cursorLine()
+	if col >= len(line) {
+		if m.textarea.Line() < m.textarea.LineCount()-1 {
+			m.setCursorLine(m.textarea.Line() + 1)
+		}
+		return
+	}
+	m.textarea.SetCursor(wordEndAfter(line, col))
+}
+
+// deleteWordBefore deletes back to the start of the word before the cursor;
+// at the start of a line it joins the line to the one above.
+func (m *EditorModel) deleteWordBefore() {
+	line, col := m.cursorLine()
+	n := 1
+	if col > 0 {
+		n = col - wordStartBefore(line, col)
+	}
+	for i := 0; i < n; i++ {
+		m.textarea, _ = m.textarea.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	}
+}
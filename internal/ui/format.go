@@ -0,0 +1,66 @@
+package ui
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DisplayFormat changes how the results grid shows timestamps and numbers,
+// and which columns it masks. It only affects the grid: edits, the preview,
+// search and copies all use the value as stored.
+type DisplayFormat struct {
+	// TimestampLayout is a Go time layout, such as "2006-01-02 15:04:05",
+	// for timestamp and timestamptz columns; "" keeps the full ISO form.
+	TimestampLayout string
+	// ThousandsSeparator groups the digits of integer and numeric columns.
+	ThousandsSeparator bool
+	// MaskColumns holds patterns of column names, such as "password" or
+	// "*_token", whose values the grid shows as dots.
+	MaskColumns []string
+}
+
+// plainNumber matches numbers without an exponent, which are the ones
+// thousands separators can go into.
+var plainNumber = regexp.MustCompile(`^-?\d+(\.\d+)?$`)
+
+// formatCell returns how the grid shows val, the displayed value of a cell.
+// Only values as loaded are reformatted; staged edits and inserted rows are
+// shown as typed.
+func (m ResultsModel) formatCell(row, col int, val string) string {
+	if m.isInsertedRow(row) || row >= len(m.rows) || col >= len(m.rows[row]) || val != m.rows[row][col] || col >= len(m.columnTypes) {
+		return val
+	}
+	switch t := m.columnTypes[col]; {
+	case m.format.TimestampLayout != "" && (t == "timestamp" || t == "timestamptz"):
+		if ts, ok := m.rawValue(row, col).(time.Time); ok {
+			return ts.Format(m.format.TimestampLayout)
+		}
+	case m.format.ThousandsSeparator && numericTypes[t] && t != "float4" && t != "float8":
+		if plainNumber.MatchString(val) {
+			return groupThousands(val)
+		}
+	}
+	return val
+}
+
+// groupThousands inserts commas between groups of three digits in the
+// integer part of a plain number.
+func groupThousands(s string) string {
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	frac := ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		s, frac = s[:i], s[i:]
+	}
+	var b strings.Builder
+	for i, c := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(c)
+	}
+	return sign + b.String() + frac
+}
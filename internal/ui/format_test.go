@@ -0,0 +1,57 @@
+package ui
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// TestFormatSQLGolden formats each testdata/*.sql file and compares the
+// result with the matching .golden file. Inputs named lower_*.sql are
+// formatted with lower-case keywords. Run with -update to regenerate.
+func TestFormatSQLGolden(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "*.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) == 0 {
+		t.Fatal("no testdata/*.sql inputs")
+	}
+	for _, in := range inputs {
+		name := strings.TrimSuffix(filepath.Base(in), ".sql")
+		t.Run(name, func(t *testing.T) {
+			src, err := os.ReadFile(in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			kc := KeywordUpper
+			if strings.HasPrefix(name, "lower_") {
+				kc = KeywordLower
+			}
+			got := FormatSQLWithCase(string(src), kc) + "\n"
+
+			golden := strings.TrimSuffix(in, ".sql") + ".golden"
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Errorf("FormatSQLWithCase mismatch\n got:\n%s\n want:\n%s", got, want)
+			}
+
+			// Formatting the output again must not change it.
+			if again := FormatSQLWithCase(got, kc) + "\n"; again != got {
+				t.Errorf("not idempotent\n first:\n%s\n second:\n%s", got, again)
+			}
+		})
+	}
+}
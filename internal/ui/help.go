@@ -0,0 +1,309 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// KeyBinding is one line of the help overlay. Keys is shown as given for
+// fixed keys; otherwise the keys currently bound to Action are listed.
+type KeyBinding struct {
+	Action Action
+	Keys   string
+	Desc   string
+}
+
+// keys returns the keys to display for kb.
+func (kb KeyBinding) keys() string {
+	if kb.Action != "" {
+		return KeyLabels(kb.Action)
+	}
+	return kb.Keys
+}
+
+// KeyGroup is a titled section of the help overlay.
+type KeyGroup struct {
+	Title    string
+	Bindings []KeyBinding
+}
+
+// Keybindings lists every key the app responds to, grouped by context.
+// Configurable keys are given by action so the overlay follows the keymap.
+var Keybindings = []KeyGroup{
+	{"Global", []KeyBinding{
+		{Action: ActionNextPane, Desc: "Next pane"},
+		{Action: ActionPrevPane, Desc: "Previous pane"},
+		{Action: ActionZoom, Desc: "Zoom the focused pane"},
+		{Action: ActionCommit, Desc: "Commit pending changes"},
+		{Action: ActionChangeScript, Desc: "Copy the SQL for the pending changes, or save it to a .sql file"},
+		{Action: ActionDiscardChanges, Desc: "Discard pending changes"},
+		{Action: ActionPendingChanges, Desc: "List pending changes by table, to review or discard single ones"},
+		{Action: ActionRefreshTable, Desc: "Reload the current table"},
+		{Action: ActionRefreshTables, Desc: "Reload the table list"},
+		{Action: ActionRerunQuery, Desc: "Run the last query again"},
+		{Action: ActionRecallQuery, Desc: "Put the last query in the editor"},
+		{Action: ActionRunUnlimited, Desc: "Run a capped query again without the automatic LIMIT"},
+		{Action: ActionMessages, Desc: "Show or hide the message log in the results pane"},
+		{Action: ActionCancel, Desc: "Cancel an export, row count, or database copy or drop"},
+		{Action: ActionConnections, Desc: "Open, switch to or close a connection"},
+		{Action: ActionNextConnection, Desc: "Switch to the next open connection"},
+		{Action: ActionDiffResults, Desc: "Run the last query on another connection and diff the rows"},
+		{Action: ActionActivity, Desc: "Server activity: running queries and lock waits"},
+		{Action: ActionReadOnly, Desc: "Toggle read-only mode (no edits or writing statements)"},
+		{Action: ActionReconnect, Desc: "Reconnect"},
+		{Action: ActionScripts, Desc: "Scripts"},
+		{Action: ActionHelp, Desc: "This help"},
+		{Action: ActionQuit, Desc: "Quit"},
+	}},
+	{"Sidebar", []KeyBinding{
+		{Action: ActionUp, Desc: "Up"},
+		{Action: ActionDown, Desc: "Down"},
+		{Action: ActionSelect, Desc: "Open table / switch database"},
+		{Action: ActionSearch, Desc: "Filter (starts from the last filter; Ctrl+U clears)"},
+		{Action: ActionLastFilter, Desc: "Re-apply the last filter, or clear the current one"},
+		{Action: ActionToggleDatabases, Desc: "Toggle tables and databases"},
+		{Action: ActionShowFavorites, Desc: "Toggle all tables and only the starred ones"},
+		{Action: ActionToggleFavorite, Desc: "Star or unstar the table (starred tables are listed first)"},
+		{Action: ActionGroupTables, Desc: "Group tables by name prefix or schema"},
+		{Action: ActionLeft, Desc: "Fold the group (grouped view)"},
+		{Action: ActionRight, Desc: "Open a folded group (or Enter)"},
+		{Action: ActionCollapseGroups, Desc: "Fold or open every group"},
+		{Action: ActionCopyDatabase, Desc: "Copy database"},
+		{Action: ActionDropDatabase, Desc: "Drop database"},
+		{Action: ActionImportCSV, Desc: "Load a CSV file into the table (header row names the columns)"},
+		{Action: ActionExportTable, Desc: "Export the whole table to a CSV or JSON file"},
+		{Action: ActionAlterTable, Desc: "ALTER TABLE ... ADD COLUMN template in the editor"},
+		{Action: ActionSelectTemplate, Desc: "SELECT listing every column in the editor, to trim or reorder"},
+		{Action: ActionDescribeTable, Desc: "Describe the columns and indexes, and build an index"},
+		{Action: ActionProfileTable, Desc: "Profile the columns: null and distinct counts"},
+		{Action: ActionCountRows, Desc: "Count the rows exactly, without opening the table"},
+	}},
+	{"Searching", []KeyBinding{
+		{Action: ActionSearchCase, Desc: "Toggle case-sensitive"},
+		{Action: ActionSearchRegex, Desc: "Toggle regex (default: literal text)"},
+		{Keys: "Enter", Desc: "Confirm"},
+		{Keys: "Esc", Desc: "Clear"},
+	}},
+	{"Editor", []KeyBinding{
+		{Action: ActionExecuteStatement, Desc: "Run the statement under the cursor"},
+		{Action: ActionExecuteAll, Desc: "Run everything"},
+		{Action: ActionFormat, Desc: "Format"},
+		{Action: ActionExplain, Desc: "Show the estimated plan of the statement, without running it"},
+		{Action: ActionGoToLine, Desc: "Go to line (type its number, Enter)"},
+		{Action: ActionWordLeft, Desc: "Back a word (dotted names are one word)"},
+		{Action: ActionWordRight, Desc: "Forward a word"},
+		{Action: ActionDeleteWord, Desc: "Delete the word before the cursor"},
+		{Action: ActionAcceptCompletion, Desc: "Accept completion"},
+		{Action: ActionUndo, Desc: "Undo the last edit (quick typing is one step)"},
+		{Action: ActionRedo, Desc: "Redo"},
+		{Keys: "↑ / ↓", Desc: "Cycle completions"},
+	}},
+	{"Results", []KeyBinding{
+		{Action: ActionUp, Desc: "Up"},
+		{Action: ActionDown, Desc: "Down"},
+		{Action: ActionLeft, Desc: "Left"},
+		{Action: ActionRight, Desc: "Right"},
+		{Action: ActionTop, Desc: "First row"},
+		{Action: ActionBottom, Desc: "Last row"},
+		{Action: ActionFirstColumn, Desc: "First column"},
+		{Action: ActionLastColumn, Desc: "Last column"},
+		{Action: ActionJumpColumn, Desc: "Jump to a column by name or number"},
+		{Action: ActionPageUp, Desc: "Page up"},
+		{Action: ActionPageDown, Desc: "Page down"},
+		{Action: ActionEditCell, Desc: "Edit cell"},
+		{Action: ActionAddRow, Desc: "Add row"},
+		{Action: ActionDeleteRow, Desc: "Delete row"},
+		{Action: ActionUndo, Desc: "Undo"},
+		{Action: ActionRedo, Desc: "Redo"},
+		{Action: ActionDiscardChange, Desc: "Discard the edit, deletion or inserted row under the cursor"},
+		{Action: ActionSearch, Desc: "Search rows"},
+		{Action: ActionFilterValue, Desc: "Only rows with this cell's value, or all again"},
+		{Action: ActionNextMatch, Desc: "Next match"},
+		{Action: ActionPrevMatch, Desc: "Previous match"},
+		{Action: ActionPreview, Desc: "Preview cell"},
+		{Action: ActionToggleStats, Desc: "Column statistics over the loaded rows"},
+		{Action: ActionTranspose, Desc: "List a single row down the page, or as a grid"},
+		{Action: ActionRevealCell, Desc: "Show this masked cell until the next key"},
+		{Action: ActionFollowReference, Desc: "Follow foreign key"},
+		{Action: ActionShowReferencing, Desc: "Rows referencing this one"},
+		{Action: ActionSaveAsTable, Desc: "Save the last query's output as a new table"},
+	}},
+	{"Editing a cell", []KeyBinding{
+		{Keys: "Enter / Tab", Desc: "Next column"},
+		{Keys: "Shift+Tab", Desc: "Previous column"},
+		{Action: ActionSetNull, Desc: "Set NULL"},
+		{Action: ActionSetDefault, Desc: "Restore default"},
+		{Keys: "Esc", Desc: "Cancel"},
+	}},
+	{"Preview", []KeyBinding{
+		{Action: ActionDown, Desc: "Scroll down"},
+		{Action: ActionUp, Desc: "Scroll up"},
+		{Action: ActionTop, Desc: "Top"},
+		{Action: ActionBottom, Desc: "Bottom"},
+		{Action: ActionSearch, Desc: "Search"},
+		{Action: ActionNextMatch, Desc: "Next match"},
+		{Action: ActionPrevMatch, Desc: "Previous match"},
+		{Action: ActionTogglePretty, Desc: "Toggle pretty-printed JSON"},
+		{Action: ActionEditCell, Desc: "Edit value (Ctrl+S save, Esc stop)"},
+		{Action: ActionPreview, Desc: "Close (or Esc)"},
+	}},
+	{"Result diff", []KeyBinding{
+		{Action: ActionUp, Desc: "Scroll up"},
+		{Action: ActionDown, Desc: "Scroll down"},
+		{Action: ActionLeft, Desc: "Scroll columns left"},
+		{Action: ActionRight, Desc: "Scroll columns right"},
+		{Keys: "c", Desc: "Show only the rows that differ"},
+		{Keys: "Esc", Desc: "Close"},
+	}},
+	{"Activity", []KeyBinding{
+		{Action: ActionUp, Desc: "Up"},
+		{Action: ActionDown, Desc: "Down"},
+		{Keys: "r", Desc: "Refresh now (it refreshes every few seconds)"},
+		{Keys: "c", Desc: "Cancel the backend's query, after confirming"},
+		{Keys: "x", Desc: "Terminate the backend, after confirming"},
+		{Keys: "Esc", Desc: "Close"},
+	}},
+	{"Columns", []KeyBinding{
+		{Action: ActionUp, Desc: "Up"},
+		{Action: ActionDown, Desc: "Down"},
+		{Keys: "Space", Desc: "Pick a column for the index, in order"},
+		{Keys: "u", Desc: "Toggle UNIQUE"},
+		{Keys: "m", Desc: "Cycle the index method (btree, hash, gin, gist, brin)"},
+		{Keys: "Enter", Desc: "Put the CREATE INDEX in the editor"},
+		{Keys: "c", Desc: "COMMENT ON the column in the editor"},
+		{Keys: "t", Desc: "COMMENT ON the table in the editor"},
+		{Keys: "Esc", Desc: "Close"},
+	}},
+	{"Messages", []KeyBinding{
+		{Action: ActionUp, Desc: "Scroll up"},
+		{Action: ActionDown, Desc: "Scroll down"},
+		{Action: ActionTop, Desc: "Oldest"},
+		{Action: ActionBottom, Desc: "Newest"},
+		{Action: ActionClearMessages, Desc: "Clear the log"},
+		{Action: ActionMessages, Desc: "Close (or Esc)"},
+	}},
+}
+
+// HelpModel is a full-screen overlay listing Keybindings.
+type HelpModel struct {
+	visible bool
+	scroll  int
+	width   int
+	height  int
+}
+
+func NewHelpModel() HelpModel {
+	return HelpModel{}
+}
+
+func (m *HelpModel) Open() {
+	m.visible = true
+	m.scroll = 0
+}
+
+func (m *HelpModel) Close() {
+	m.visible = false
+}
+
+func (m HelpModel) Visible() bool {
+	return m.visible
+}
+
+func (m *HelpModel) SetSize(w, h int) {
+	m.width = w
+	m.height = h
+}
+
+func (m HelpModel) Update(msg tea.Msg) (HelpModel, tea.Cmd) {
+	if !m.visible {
+		return m, nil
+	}
+
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case msg.String() == "esc" || msg.String() == "q" || KeyMatches(msg, ActionHelp):
+			m.Close()
+		case KeyMatches(msg, ActionUp):
+			if m.scroll > 0 {
+				m.scroll--
+			}
+		case KeyMatches(msg, ActionDown):
+			if m.scroll < m.maxScroll() {
+				m.scroll++
+			}
+		}
+	}
+	return m, nil
+}
+
+// lines renders Keybindings one entry per line.
+func (m HelpModel) lines() []string {
+	keyW := 0
+	for _, g := range Keybindings {
+		for _, kb := range g.Bindings {
+			keyW = max(keyW, lipgloss.Width(kb.keys()))
+		}
+	}
+
+	var lines []string
+	for i, g := range Keybindings {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, HeaderStyle.Render(g.Title))
+		for _, kb := range g.Bindings {
+			keys := kb.keys()
+			pad := strings.Repeat(" ", keyW-lipgloss.Width(keys))
+			lines = append(lines, "  "+AccentText.Render(keys)+pad+"  "+kb.Desc)
+		}
+	}
+	return lines
+}
+
+// bodyHeight is how many lines of bindings fit inside the modal.
+func (m HelpModel) bodyHeight() int {
+	// Border, padding, title and hint lines.
+	return max(1, m.height-8)
+}
+
+func (m HelpModel) maxScroll() int {
+	return max(0, len(m.lines())-m.bodyHeight())
+}
+
+func (m HelpModel) View() string {
+	if !m.visible {
+		return ""
+	}
+
+	modalW := 64
+	if m.width > 0 && modalW > m.width-4 {
+		modalW = m.width - 4
+	}
+
+	lines := m.lines()
+	h := m.bodyHeight()
+	start := min(m.scroll, m.maxScroll())
+	end := min(start+h, len(lines))
+
+	var b strings.Builder
+	b.WriteString(HeaderStyle.Render("Keybindings"))
+	b.WriteString("\n")
+	hint := "  Esc close"
+	if len(lines) > h {
+		hint = fmt.Sprintf("  %s/%s scroll [%d-%d of %d] | Esc close", KeyLabel(ActionDown), KeyLabel(ActionUp), start+1, end, len(lines))
+	}
+	b.WriteString(DimText.Render(hint))
+	b.WriteString("\n\n")
+	b.WriteString(strings.Join(lines[start:end], "\n"))
+
+	modalStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ColorAccent).
+		Padding(1, 2).
+		Width(modalW)
+
+	return centerModal(modalStyle.Render(b.String()), m.width, m.height)
+}
@@ -7,15 +7,25 @@ import (
 	"github.com/charmbracelet/lipgloss"
 )
 
+// Highlighting styles, set from the active theme by ApplyTheme.
 var (
-	KeywordStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#c678dd"))
-	FunctionStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#61afef"))
-	StringStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#98c379"))
-	NumberStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#d19a66"))
-	CommentStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#5c6370")).Italic(true)
-	OperatorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#56b6c2"))
+	KeywordStyle  lipgloss.Style
+	FunctionStyle lipgloss.Style
+	StringStyle   lipgloss.Style
+	NumberStyle   lipgloss.Style
+	CommentStyle  lipgloss.Style
+	OperatorStyle lipgloss.Style
 )
 
+func applyHighlightTheme(t Theme) {
+	KeywordStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(t.Keyword))
+	FunctionStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(t.Function))
+	StringStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(t.String))
+	NumberStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(t.Number))
+	CommentStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(t.Comment)).Italic(true)
+	OperatorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(t.Operator))
+}
+
 var sqlKeywords = []string{
 	"SELECT", "FROM", "WHERE", "INSERT", "INTO", "VALUES", "UPDATE", "SET",
 	"DELETE", "CREATE", "TABLE", "DROP", "ALTER", "ADD", "COLUMN",
@@ -46,6 +56,9 @@ var sqlKeywords = []string{
 	"TEMP", "TEMPORARY", "UNLOGGED",
 	"PARTITION", "PARTITIONED",
 	"ANALYZE", "EXPLAIN", "VACUUM",
+	"ILIKE", "SIMILAR", "WINDOW", "OVER", "FILTER", "LATERAL", "USING",
+	"CONFLICT", "DO", "NOTHING", "MATERIALIZED", "GENERATED", "IDENTITY",
+	"INTERVAL",
 }
 
 var sqlFunctions = []string{
@@ -63,6 +76,7 @@ var majorClauses = map[string]bool{
 	"SELECT": true, "FROM": true, "WHERE": true, "SET": true,
 	"HAVING": true, "RETURNING": true, "VALUES": true,
 	"UNION": true, "INTERSECT": true, "EXCEPT": true,
+	"WINDOW": true,
 }
 
 var indentClauses = map[string]bool{
@@ -74,7 +88,36 @@ var joinKeywords = map[string]bool{
 	"FULL": true, "CROSS": true, "OUTER": true,
 }
 
+// KeywordCase controls how FormatSQL renders recognized keywords.
+type KeywordCase int
+
+const (
+	KeywordUpper KeywordCase = iota
+	KeywordLower
+	KeywordPreserve
+)
+
+// ParseKeywordCase maps a config value ("upper", "lower", "preserve") to a
+// KeywordCase, defaulting to upper case.
+func ParseKeywordCase(s string) KeywordCase {
+	switch strings.ToLower(s) {
+	case "lower":
+		return KeywordLower
+	case "preserve":
+		return KeywordPreserve
+	default:
+		return KeywordUpper
+	}
+}
+
+// FormatSQL formats sql with upper-case keywords.
 func FormatSQL(sql string) string {
+	return FormatSQLWithCase(sql, KeywordUpper)
+}
+
+// FormatSQLWithCase formats sql, rendering keywords in the given case.
+// Identifiers are never recased.
+func FormatSQLWithCase(sql string, kc KeywordCase) string {
 	sql = strings.TrimSpace(sql)
 	if sql == "" {
 		return sql
@@ -108,6 +151,18 @@ func FormatSQL(sql string) string {
 			continue
 		}
 
+		if end := blockCommentEnd(sql, i); end != -1 {
+			segments = append(segments, segment{text: sql[i:end], isToken: false})
+			i = end
+			continue
+		}
+
+		if end := DollarQuoteEnd(sql, i); end != -1 {
+			segments = append(segments, segment{text: sql[i:end], isToken: false})
+			i = end
+			continue
+		}
+
 		if sql[i] == '\'' {
 			end := i + 1
 			for end < len(sql) {
@@ -127,10 +182,18 @@ func FormatSQL(sql string) string {
 		}
 
 		if sql[i] == ' ' || sql[i] == '\t' || sql[i] == '\n' || sql[i] == '\r' {
+			hasNewline := false
 			for i < len(sql) && (sql[i] == ' ' || sql[i] == '\t' || sql[i] == '\n' || sql[i] == '\r') {
+				hasNewline = hasNewline || sql[i] == '\n'
 				i++
 			}
-			segments = append(segments, segment{text: " ", isToken: false})
+			// A line break ending a -- comment is significant; any other
+			// run of whitespace collapses to a single space.
+			if hasNewline && len(segments) > 0 && strings.HasPrefix(segments[len(segments)-1].text, "--") {
+				segments = append(segments, segment{text: "\n", isToken: false})
+			} else {
+				segments = append(segments, segment{text: " ", isToken: false})
+			}
 			continue
 		}
 
@@ -142,7 +205,13 @@ func FormatSQL(sql string) string {
 			word := sql[i:end]
 			upper := strings.ToUpper(word)
 			if keywordSet[upper] {
-				segments = append(segments, segment{text: upper, isToken: true})
+				switch kc {
+				case KeywordLower:
+					word = strings.ToLower(word)
+				case KeywordUpper:
+					word = upper
+				}
+				segments = append(segments, segment{text: word, isToken: true})
 			} else {
 				segments = append(segments, segment{text: word, isToken: true})
 			}
@@ -167,16 +236,58 @@ func FormatSQL(sql string) string {
 	var result strings.Builder
 	indent := 0
 
+	// parens records, for each open parenthesis, whether it wraps a subquery.
+	// Subqueries are indented one level per nesting depth; any other
+	// parenthesised list (function args, window specs, IN lists) stays inline.
+	var parens []bool
+	subDepth := 0
+
+	nextWord := func(si int) string {
+		for j := si + 1; j < len(segments); j++ {
+			if segments[j].text == " " || segments[j].text == "\n" {
+				continue
+			}
+			return strings.ToUpper(segments[j].text)
+		}
+		return ""
+	}
+
 	prevWasNewline := false
+	breakLine := func() {
+		if prevWasNewline || result.Len() == 0 {
+			return
+		}
+		result.WriteString("\n")
+		result.WriteString(strings.Repeat("  ", subDepth))
+		prevWasNewline = true
+	}
+
 	for si, seg := range segments {
 		if !seg.isToken {
-			if seg.text == " " && prevWasNewline {
-				continue
-			}
-			if seg.text == "," {
-				result.WriteString(",")
-				prevWasNewline = false
+			switch seg.text {
+			case " ":
+				if prevWasNewline {
+					continue
+				}
+			case "\n":
+				breakLine()
 				continue
+			case "(":
+				isSub := nextWord(si) == "SELECT" || nextWord(si) == "WITH"
+				parens = append(parens, isSub)
+				if isSub {
+					subDepth++
+				}
+			case ")":
+				if len(parens) > 0 {
+					isSub := parens[len(parens)-1]
+					parens = parens[:len(parens)-1]
+					if isSub {
+						subDepth--
+						prevWasNewline = false
+						breakLine()
+					}
+				}
 			}
 			result.WriteString(seg.text)
 			prevWasNewline = false
@@ -184,61 +295,36 @@ func FormatSQL(sql string) string {
 		}
 
 		upper := strings.ToUpper(seg.text)
+		if len(parens) > 0 && !parens[len(parens)-1] {
+			result.WriteString(seg.text)
+			prevWasNewline = false
+			continue
+		}
+
+		lookahead := nextWord(si)
 
 		isJoinLine := false
 		if joinKeywords[upper] {
-			lookahead := ""
-			for j := si + 1; j < len(segments); j++ {
-				if segments[j].text == " " {
-					continue
-				}
-				lookahead = strings.ToUpper(segments[j].text)
-				break
-			}
 			if upper == "JOIN" || lookahead == "JOIN" || lookahead == "OUTER" {
 				isJoinLine = true
 			}
 		}
 
-		if upper == "ORDER" || upper == "GROUP" || upper == "LIMIT" || upper == "OFFSET" {
-			result.WriteString("\n")
-			result.WriteString(seg.text)
-			prevWasNewline = false
-			continue
-		}
-
-		if majorClauses[upper] && si > 0 {
-			if upper == "SELECT" || upper == "INSERT" || upper == "UPDATE" || upper == "DELETE" {
-				if si > 0 {
-					result.WriteString("\n")
-				}
-			} else {
-				result.WriteString("\n")
-			}
-			result.WriteString(seg.text)
-			prevWasNewline = false
+		switch {
+		case upper == "ON" && lookahead == "CONFLICT",
+			upper == "ORDER" || upper == "GROUP" || upper == "LIMIT" || upper == "OFFSET",
+			isJoinLine:
+			breakLine()
+		case majorClauses[upper]:
+			breakLine()
 			if upper == "SELECT" || upper == "FROM" || upper == "WHERE" || upper == "SET" || upper == "HAVING" {
 				indent = 1
 			}
-			continue
-		}
-
-		if isJoinLine {
-			result.WriteString("\n")
-			result.WriteString(seg.text)
-			prevWasNewline = false
-			continue
-		}
-
-		if indentClauses[upper] {
-			result.WriteString("\n")
+		case indentClauses[upper]:
+			breakLine()
 			result.WriteString(strings.Repeat("  ", indent))
-			result.WriteString(seg.text)
-			prevWasNewline = false
-			continue
 		}
 
-		_ = indent
 		result.WriteString(seg.text)
 		prevWasNewline = false
 	}
@@ -289,6 +375,16 @@ func HighlightSQL(sql string) string {
 		tokens = append(tokens, Token{Start: match[0], End: match[1], Style: StringStyle})
 	}
 
+	for i := 0; i < len(sql); i++ {
+		if end := blockCommentEnd(sql, i); end != -1 {
+			tokens = append(tokens, Token{Start: i, End: end, Style: CommentStyle})
+			i = end - 1
+		} else if end := DollarQuoteEnd(sql, i); end != -1 {
+			tokens = append(tokens, Token{Start: i, End: end, Style: StringStyle})
+			i = end - 1
+		}
+	}
+
 	for _, match := range numberRe.FindAllStringIndex(sql, -1) {
 		tokens = append(tokens, Token{Start: match[0], End: match[1], Style: NumberStyle})
 	}
@@ -365,3 +461,290 @@ func HighlightSQL(sql string) string {
 
 	return result.String()
 }
+
+func isIdentByte(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// DollarQuoteEnd returns the index just past the dollar-quoted string
+// ($$...$$ or $tag$...$tag$) opening at s[i], or -1 if none opens there.
+// An unterminated quote runs to the end of s. The app's SQL tokenizer uses
+// it too, so the two agree on where strings are.
+func DollarQuoteEnd(s string, i int) int {
+	if i >= len(s) || s[i] != '$' || (i > 0 && isIdentByte(s[i-1])) {
+		return -1
+	}
+	j := i + 1
+	for j < len(s) && isIdentByte(s[j]) {
+		if j == i+1 && s[j] >= '0' && s[j] <= '9' {
+			return -1 // positional parameter such as $1
+		}
+		j++
+	}
+	if j >= len(s) || s[j] != '$' {
+		return -1
+	}
+	tag := s[i : j+1]
+	end := strings.Index(s[j+1:], tag)
+	if end == -1 {
+		return len(s)
+	}
+	return j + 1 + end + len(tag)
+}
+
+// StringLiteralEnd returns the index just past the string literal opening
+// at s[i], or -1 if none opens there. A quote inside is written twice, and in
+// an escape string (E'...') may also follow a backslash. An unterminated
+// literal runs to the end of s.
+func StringLiteralEnd(s string, i int) int {
+	escapes := false
+	j := i
+	if j+1 < len(s) && (s[j] == 'E' || s[j] == 'e') && s[j+1] == '\'' && (j == 0 || !isIdentByte(s[j-1])) {
+		escapes = true
+		j++
+	}
+	if j >= len(s) || s[j] != '\'' {
+		return -1
+	}
+	for j++; j < len(s); j++ {
+		switch {
+		case escapes && s[j] == '\\':
+			j++
+		case s[j] == '\'' && j+1 < len(s) && s[j+1] == '\'':
+			j++
+		case s[j] == '\'':
+			return j + 1
+		}
+	}
+	return len(s)
+}
+
+// blockCommentEnd returns the index just past the /* */ comment opening at
+// s[i], or -1 if none opens there. Comments nest, as in PostgreSQL.
+func blockCommentEnd(s string, i int) int {
+	if i+1 >= len(s) || s[i] != '/' || s[i+1] != '*' {
+		return -1
+	}
+	depth := 0
+	for j := i; j+1 < len(s); j++ {
+		switch {
+		case s[j] == '/' && s[j+1] == '*':
+			depth++
+			j++
+		case s[j] == '*' && s[j+1] == '/':
+			depth--
+			j++
+			if depth == 0 {
+				return j + 1
+			}
+		}
+	}
+	return len(s)
+}
+
+// literalSpan is a block comment or dollar-quoted string that may cross lines.
+type literalSpan struct {
+	start int
+	end   int
+	style lipgloss.Style
+}
+
+// multiLineLiterals finds block comments and dollar-quoted strings in text,
+// skipping anything inside ordinary string literals and line comments.
+func multiLineLiterals(text string) []literalSpan {
+	var spans []literalSpan
+	i := 0
+	for i < len(text) {
+		c := text[i]
+		if end := blockCommentEnd(text, i); end != -1 {
+			spans = append(spans, literalSpan{start: i, end: end, style: CommentStyle})
+			i = end
+			continue
+		}
+		if end := DollarQuoteEnd(text, i); end != -1 {
+			spans = append(spans, literalSpan{start: i, end: end, style: StringStyle})
+			i = end
+			continue
+		}
+		switch {
+		case c == '-' && i+1 < len(text) && text[i+1] == '-':
+			end := strings.IndexByte(text[i:], '\n')
+			if end == -1 {
+				return spans
+			}
+			i += end
+		case c == '\'':
+			end := strings.IndexByte(text[i+1:], '\'')
+			if end == -1 {
+				return spans
+			}
+			i += end + 2
+		default:
+			i++
+		}
+	}
+	return spans
+}
+
+// highlightRange highlights text[start:end], rendering any part covered by a
+// multi-line literal with the literal's style instead of tokenizing it.
+func highlightRange(text string, start, end int, spans []literalSpan) string {
+	var b strings.Builder
+	pos := start
+	for _, sp := range spans {
+		if sp.end <= pos || sp.start >= end {
+			continue
+		}
+		if sp.start > pos {
+			b.WriteString(HighlightSQL(text[pos:sp.start]))
+			pos = sp.start
+		}
+		litEnd := sp.end
+		if litEnd > end {
+			litEnd = end
+		}
+		b.WriteString(sp.style.Render(text[pos:litEnd]))
+		pos = litEnd
+	}
+	if pos < end {
+		b.WriteString(HighlightSQL(text[pos:end]))
+	}
+	return b.String()
+}
+
+// jsonSpans tokenizes JSON text for highlighting: object keys, strings,
+// numbers, booleans and null. Text that isn't valid JSON is highlighted as far
+// as it can be.
+func jsonSpans(text string) []literalSpan {
+	var spans []literalSpan
+	i := 0
+	for i < len(text) {
+		c := text[i]
+		switch {
+		case c == '"':
+			end := i + 1
+			for end < len(text) && text[end] != '"' {
+				if text[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end < len(text) {
+				end++
+			}
+			style := StringStyle
+			next := end
+			for next < len(text) && strings.IndexByte(" \t\r\n", text[next]) != -1 {
+				next++
+			}
+			if next < len(text) && text[next] == ':' {
+				style = FunctionStyle
+			}
+			spans = append(spans, literalSpan{start: i, end: end, style: style})
+			i = end
+		case c == '-' || (c >= '0' && c <= '9'):
+			end := i + 1
+			for end < len(text) && strings.IndexByte("0123456789.eE+-", text[end]) != -1 {
+				end++
+			}
+			spans = append(spans, literalSpan{start: i, end: end, style: NumberStyle})
+			i = end
+		case strings.HasPrefix(text[i:], "true"), strings.HasPrefix(text[i:], "false"):
+			end := i + 4
+			if c == 'f' {
+				end++
+			}
+			spans = append(spans, literalSpan{start: i, end: end, style: KeywordStyle})
+			i = end
+		case strings.HasPrefix(text[i:], "null"):
+			spans = append(spans, literalSpan{start: i, end: i + 4, style: NullText})
+			i += 4
+		default:
+			i++
+		}
+	}
+	return spans
+}
+
+// styleRange renders text[start:end], styling the parts covered by spans and
+// leaving the rest plain.
+func styleRange(text string, start, end int, spans []literalSpan) string {
+	var b strings.Builder
+	pos := start
+	for _, sp := range spans {
+		if sp.end <= pos || sp.start >= end {
+			continue
+		}
+		if sp.start > pos {
+			b.WriteString(text[pos:sp.start])
+			pos = sp.start
+		}
+		spanEnd := sp.end
+		if spanEnd > end {
+			spanEnd = end
+		}
+		b.WriteString(sp.style.Render(text[pos:spanEnd]))
+		pos = spanEnd
+	}
+	if pos < end {
+		b.WriteString(text[pos:end])
+	}
+	return b.String()
+}
+
+// matchingBracket returns the offset in text of the parenthesis that pairs
+// with the one at pos, or -1 if pos isn't a parenthesis or it is unbalanced.
+// Parentheses inside strings, quoted identifiers and comments are ignored.
+func matchingBracket(text string, pos int) int {
+	if pos < 0 || pos >= len(text) || (text[pos] != '(' && text[pos] != ')') {
+		return -1
+	}
+	var stack []int
+	i := 0
+	for i < len(text) {
+		if end := blockCommentEnd(text, i); end != -1 {
+			i = end
+			continue
+		}
+		if end := DollarQuoteEnd(text, i); end != -1 {
+			i = end
+			continue
+		}
+		c := text[i]
+		switch {
+		case c == '-' && i+1 < len(text) && text[i+1] == '-':
+			end := strings.IndexByte(text[i:], '\n')
+			if end == -1 {
+				return -1
+			}
+			i += end
+			continue
+		case c == '\'' || c == '"':
+			end := strings.IndexByte(text[i+1:], c)
+			if end == -1 {
+				return -1
+			}
+			i += end + 2
+			continue
+		case c == '(':
+			stack = append(stack, i)
+		case c == ')':
+			if len(stack) == 0 {
+				if i == pos {
+					return -1
+				}
+				break
+			}
+			open := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if open == pos {
+				return i
+			}
+			if i == pos {
+				return open
+			}
+		}
+		i++
+	}
+	return -1
+}
@@ -0,0 +1,296 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Action is a logical command that one or more keys trigger. Its value is
+// the name used in the keymap file.
+type Action string
+
+const (
+	// Global
+	ActionQuit           Action = "quit"
+	ActionNextPane       Action = "next-pane"
+	ActionPrevPane       Action = "prev-pane"
+	ActionCommit         Action = "commit"
+	ActionChangeScript   Action = "change-script"
+	ActionPendingChanges Action = "pending-changes"
+	ActionDiscardChanges Action = "discard-changes"
+	ActionReconnect      Action = "reconnect"
+	ActionScripts        Action = "scripts"
+	ActionZoom           Action = "zoom"
+	ActionHelp           Action = "help"
+	ActionRefreshTable   Action = "refresh-table"
+	ActionRefreshTables  Action = "refresh-tables"
+	ActionRerunQuery     Action = "rerun-query"
+	ActionRecallQuery    Action = "recall-query"
+	ActionRunUnlimited   Action = "run-unlimited"
+	ActionMessages       Action = "messages"
+	ActionCancel         Action = "cancel"
+	ActionConnections    Action = "connections"
+	ActionNextConnection Action = "next-connection"
+	ActionDiffResults    Action = "diff-results"
+	ActionActivity       Action = "activity"
+	ActionReadOnly       Action = "read-only"
+
+	// Movement, shared by the sidebar, results and preview
+	ActionUp       Action = "up"
+	ActionDown     Action = "down"
+	ActionLeft     Action = "left"
+	ActionRight    Action = "right"
+	ActionTop      Action = "top"
+	ActionBottom   Action = "bottom"
+	ActionPageUp   Action = "page-up"
+	ActionPageDown Action = "page-down"
+	ActionSearch   Action = "search"
+
+	// While typing a sidebar or results search
+	ActionSearchCase  Action = "search-case"
+	ActionSearchRegex Action = "search-regex"
+
+	// Sidebar
+	ActionSelect          Action = "select"
+	ActionToggleDatabases Action = "toggle-databases"
+	ActionCopyDatabase    Action = "copy-database"
+	ActionDropDatabase    Action = "drop-database"
+	ActionImportCSV       Action = "import-csv"
+	ActionExportTable     Action = "export-table"
+	ActionAlterTable      Action = "alter-table"
+	ActionDescribeTable   Action = "describe-table"
+	ActionProfileTable    Action = "profile-table"
+	ActionCountRows       Action = "count-rows"
+	ActionSelectTemplate  Action = "select-template"
+	ActionToggleFavorite  Action = "toggle-favorite"
+	ActionShowFavorites   Action = "show-favorites"
+	ActionGroupTables     Action = "group-tables"
+	ActionCollapseGroups  Action = "collapse-groups"
+	ActionLastFilter      Action = "last-filter"
+
+	// Editor
+	ActionExecuteStatement Action = "execute-query"
+	ActionExecuteAll       Action = "execute-all"
+	ActionFormat           Action = "format"
+	ActionGoToLine         Action = "go-to-line"
+	ActionWordLeft         Action = "word-left"
+	ActionWordRight        Action = "word-right"
+	ActionDeleteWord       Action = "delete-word"
+	ActionExplain          Action = "explain"
+	ActionAcceptCompletion Action = "accept-completion"
+
+	// Results
+	ActionEditCell        Action = "edit-cell"
+	ActionDeleteRow       Action = "delete-row"
+	ActionAddRow          Action = "add-row"
+	ActionUndo            Action = "undo"
+	ActionRedo            Action = "redo"
+	ActionDiscardChange   Action = "discard-change"
+	ActionNextMatch       Action = "next-match"
+	ActionPrevMatch       Action = "prev-match"
+	ActionFollowReference Action = "follow-reference"
+	ActionShowReferencing Action = "show-referencing"
+	ActionPreview         Action = "preview"
+	ActionToggleStats     Action = "toggle-stats"
+	ActionFirstColumn     Action = "first-column"
+	ActionLastColumn      Action = "last-column"
+	ActionJumpColumn      Action = "jump-column"
+	ActionSaveAsTable     Action = "save-as-table"
+	ActionFilterValue     Action = "filter-value"
+	ActionTranspose       Action = "transpose"
+	ActionRevealCell      Action = "reveal-cell"
+
+	// Editing a cell
+	ActionSetNull    Action = "set-null"
+	ActionSetDefault Action = "set-default"
+
+	// Preview
+	ActionTogglePretty Action = "toggle-pretty"
+
+	// Messages
+	ActionClearMessages Action = "clear-messages"
+)
+
+// DefaultKeymap holds the built-in bindings. The first key of each action is
+// the one shown in hints.
+var DefaultKeymap = map[Action][]string{
+	ActionQuit:           {"ctrl+c"},
+	ActionNextPane:       {"tab"},
+	ActionPrevPane:       {"shift+tab"},
+	ActionCommit:         {"ctrl+s"},
+	ActionChangeScript:   {"alt+s"},
+	ActionPendingChanges: {"alt+p"},
+	ActionDiscardChanges: {"ctrl+x"},
+	ActionReconnect:      {"ctrl+r"},
+	ActionScripts:        {"ctrl+o"},
+	ActionZoom:           {"f11"},
+	ActionHelp:           {"?", "f1"},
+	// Terminals send Ctrl+Shift+R as Ctrl+R, so F5 is the only default.
+	ActionRefreshTable:   {"f5"},
+	ActionRefreshTables:  {"f6"},
+	ActionRerunQuery:     {"ctrl+g"},
+	ActionRecallQuery:    {"alt+g"},
+	ActionRunUnlimited:   {"L"},
+	ActionMessages:       {"M"},
+	ActionCancel:         {"ctrl+k"},
+	ActionConnections:    {"ctrl+t"},
+	ActionNextConnection: {"alt+t"},
+	ActionDiffResults:    {"alt+d"},
+	ActionActivity:       {"alt+a"},
+	ActionReadOnly:       {"alt+w"},
+
+	ActionUp:       {"k", "up"},
+	ActionDown:     {"j", "down"},
+	ActionLeft:     {"h", "left"},
+	ActionRight:    {"l", "right"},
+	ActionTop:      {"g"},
+	ActionBottom:   {"G"},
+	ActionPageUp:   {"pgup"},
+	ActionPageDown: {"pgdown"},
+	ActionSearch:   {"/"},
+
+	ActionSearchCase:  {"alt+c"},
+	ActionSearchRegex: {"alt+r"},
+
+	ActionSelect:          {"enter"},
+	ActionToggleDatabases: {"D"},
+	ActionCopyDatabase:    {"c"},
+	ActionDropDatabase:    {"x"},
+	ActionImportCSV:       {"i"},
+	ActionExportTable:     {"E"},
+	ActionAlterTable:      {"A"},
+	ActionDescribeTable:   {"C"},
+	ActionProfileTable:    {"I"},
+	ActionCountRows:       {"#"},
+	ActionSelectTemplate:  {"S"},
+	ActionToggleFavorite:  {"*"},
+	ActionShowFavorites:   {"F"},
+	ActionGroupTables:     {"P"},
+	ActionCollapseGroups:  {"Z"},
+	ActionLastFilter:      {"n"},
+
+	ActionExecuteStatement: {"ctrl+j"},
+	ActionExecuteAll:       {"ctrl+e"},
+	ActionFormat:           {"ctrl+l"},
+	ActionExplain:          {"ctrl+p"},
+	ActionGoToLine:         {"alt+l", "alt+:"},
+	ActionWordLeft:         {"ctrl+left", "alt+left", "alt+b"},
+	ActionWordRight:        {"ctrl+right", "alt+right", "alt+f"},
+	// Ctrl+W zooms the pane, so Alt+Backspace is the only default.
+	ActionDeleteWord:       {"alt+backspace", "ctrl+w"},
+	ActionAcceptCompletion: {"tab"},
+
+	ActionEditCell:        {"e"},
+	ActionDeleteRow:       {"d"},
+	ActionAddRow:          {"a"},
+	ActionUndo:            {"ctrl+z"},
+	ActionRedo:            {"ctrl+y"},
+	ActionDiscardChange:   {"u"},
+	ActionNextMatch:       {"n"},
+	ActionPrevMatch:       {"N"},
+	ActionFollowReference: {"f"},
+	ActionShowReferencing: {"r"},
+	ActionPreview:         {"v"},
+	ActionToggleStats:     {"s"},
+	ActionFirstColumn:     {"0"},
+	ActionLastColumn:      {"$"},
+	ActionJumpColumn:      {"|"},
+	ActionSaveAsTable:     {"T"},
+	ActionFilterValue:     {"="},
+	ActionTranspose:       {"t"},
+	ActionRevealCell:      {"R"},
+
+	ActionSetNull:    {"ctrl+n"},
+	ActionSetDefault: {"ctrl+d"},
+
+	ActionTogglePretty: {"p"},
+
+	ActionClearMessages: {"c"},
+}
+
+// keymap is the active set of bindings.
+var keymap = DefaultKeymap
+
+// SetKeymap replaces the bindings of the actions named in overrides, keeping
+// the defaults for the rest. Key strings are as Bubble Tea spells them, e.g.
+// "ctrl+j", "shift+tab", "G". An unknown action name is an error and nothing
+// is changed.
+func SetKeymap(overrides map[string][]string) error {
+	var unknown []string
+	for name := range overrides {
+		if _, ok := DefaultKeymap[Action(name)]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown keymap action: %s", strings.Join(unknown, ", "))
+	}
+
+	km := make(map[Action][]string, len(DefaultKeymap))
+	for a, keys := range DefaultKeymap {
+		km[a] = keys
+	}
+	for name, keys := range overrides {
+		km[Action(name)] = keys
+	}
+	keymap = km
+	return nil
+}
+
+// KeyMatches reports whether msg is one of the keys bound to a.
+func KeyMatches(msg tea.KeyMsg, a Action) bool {
+	s := msg.String()
+	for _, k := range keymap[a] {
+		if k == s {
+			return true
+		}
+	}
+	return false
+}
+
+// KeyLabel returns the first key bound to a, as shown in hints, or "" if
+// the action has been unbound.
+func KeyLabel(a Action) string {
+	if keys := keymap[a]; len(keys) > 0 {
+		return keyName(keys[0])
+	}
+	return ""
+}
+
+// KeyLabels returns every key bound to a, joined with " / ".
+func KeyLabels(a Action) string {
+	names := make([]string, len(keymap[a]))
+	for i, k := range keymap[a] {
+		names[i] = keyName(k)
+	}
+	return strings.Join(names, " / ")
+}
+
+var keyNames = map[string]string{
+	"up": "↑", "down": "↓", "left": "←", "right": "→",
+	"pgup": "PgUp", "pgdown": "PgDn", "enter": "Enter", "esc": "Esc",
+	"tab": "Tab", "backspace": "Backspace", "delete": "Del", "space": "Space",
+	"home": "Home", "end": "End",
+}
+
+// keyName renders a Bubble Tea key string for display: "ctrl+j" becomes
+// "Ctrl+J" while single characters such as "g" and "G" are left alone.
+func keyName(k string) string {
+	if len(k) == 1 {
+		return k
+	}
+	parts := strings.Split(k, "+")
+	for i, p := range parts {
+		switch {
+		case keyNames[p] != "":
+			parts[i] = keyNames[p]
+		case len(p) > 0:
+			parts[i] = strings.ToUpper(p[:1]) + p[1:]
+		}
+	}
+	return strings.Join(parts, "+")
+}
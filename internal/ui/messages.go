@@ -0,0 +1,190 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxLogLines bounds the message log; the oldest lines go first.
+const maxLogLines = 5000
+
+// LogKind classifies a message log entry, which decides its color.
+type LogKind int
+
+const (
+	LogQuery   LogKind = iota // a statement sent to the server
+	LogResult                 // a command tag or row count, with timing
+	LogInfo                   // something the client did, e.g. a refresh
+	LogNotice                 // a NOTICE or INFO from the server
+	LogWarning                // a WARNING from the server
+	LogError                  // a failed statement
+)
+
+type logLine struct {
+	time  time.Time
+	kind  LogKind
+	text  string
+	first bool // the first line of its entry, which shows the time
+}
+
+// MessagesModel is a running, timestamped log of the session: statements,
+// command tags, server notices and errors, in the manner of psql's output.
+// The app shows it in place of the results grid.
+type MessagesModel struct {
+	visible bool
+	focused bool
+	lines   []logLine
+	back    int // lines scrolled up from the end; 0 follows new entries
+	width   int
+	height  int
+}
+
+func NewMessagesModel() MessagesModel {
+	return MessagesModel{}
+}
+
+// Add appends an entry, which may span lines, stamped with the current time.
+func (m *MessagesModel) Add(kind LogKind, text string) {
+	now := time.Now()
+	for i, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		m.lines = append(m.lines, logLine{time: now, kind: kind, text: line, first: i == 0})
+		if m.back > 0 {
+			// Keep the view still while scrolled up.
+			m.back++
+		}
+	}
+	if over := len(m.lines) - maxLogLines; over > 0 {
+		m.lines = append(m.lines[:0:0], m.lines[over:]...)
+	}
+	m.back = min(m.back, m.maxBack())
+}
+
+// Clear empties the log.
+func (m *MessagesModel) Clear() {
+	m.lines = nil
+	m.back = 0
+}
+
+func (m *MessagesModel) Open() {
+	m.visible = true
+	m.back = 0
+}
+
+func (m *MessagesModel) Close() {
+	m.visible = false
+}
+
+func (m MessagesModel) Visible() bool {
+	return m.visible
+}
+
+func (m *MessagesModel) SetFocused(f bool) {
+	m.focused = f
+}
+
+func (m *MessagesModel) SetSize(w, h int) {
+	m.width = w
+	m.height = h
+}
+
+func (m MessagesModel) Update(msg tea.Msg) (MessagesModel, tea.Cmd) {
+	if !m.visible {
+		return m, nil
+	}
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		page := max(1, m.bodyHeight()-1)
+		switch {
+		case msg.String() == "esc" || KeyMatches(msg, ActionMessages):
+			m.Close()
+		case KeyMatches(msg, ActionClearMessages):
+			m.Clear()
+		case KeyMatches(msg, ActionUp):
+			m.back = min(m.back+1, m.maxBack())
+		case KeyMatches(msg, ActionDown):
+			m.back = max(m.back-1, 0)
+		case KeyMatches(msg, ActionPageUp):
+			m.back = min(m.back+page, m.maxBack())
+		case KeyMatches(msg, ActionPageDown):
+			m.back = max(m.back-page, 0)
+		case KeyMatches(msg, ActionTop):
+			m.back = m.maxBack()
+		case KeyMatches(msg, ActionBottom):
+			m.back = 0
+		}
+	case tea.MouseMsg:
+		switch msg.Button {
+		case tea.MouseButtonWheelUp:
+			m.back = min(m.back+3, m.maxBack())
+		case tea.MouseButtonWheelDown:
+			m.back = max(m.back-3, 0)
+		}
+	}
+	return m, nil
+}
+
+// bodyHeight is how many log lines fit under the title.
+func (m MessagesModel) bodyHeight() int {
+	// Border and title lines.
+	return max(1, m.height-3)
+}
+
+func (m MessagesModel) maxBack() int {
+	return max(0, len(m.lines)-m.bodyHeight())
+}
+
+func (m MessagesModel) View() string {
+	borderStyle := UnfocusedBorder
+	if m.focused {
+		borderStyle = FocusedBorder
+	}
+	innerW := max(10, m.width-2)
+	innerH := max(3, m.height-2)
+
+	h := m.bodyHeight()
+	end := len(m.lines) - min(m.back, m.maxBack())
+	start := max(0, end-h)
+
+	title := "Messages"
+	if m.back > 0 {
+		title += fmt.Sprintf(" [%d-%d of %d]", start+1, end, len(m.lines))
+	}
+	keys := joinHints(hint("clear", ActionClearMessages), hint("close", ActionMessages))
+	var b strings.Builder
+	b.WriteString(HeaderStyle.Render(title) + "  " + DimText.Render(keys))
+	if len(m.lines) == 0 {
+		b.WriteString("\n" + DimText.Render("Nothing yet: statements, command tags and server notices appear here"))
+	}
+	textW := max(1, innerW-9)
+	for _, l := range m.lines[start:end] {
+		stamp := "        "
+		if l.first {
+			stamp = l.time.Format("15:04:05")
+		}
+		text := truncateDisplay(sanitizeCell(l.text), textW)
+		b.WriteString("\n" + DimText.Render(stamp) + " " + logStyle(l.kind).Render(text))
+	}
+
+	return borderStyle.Width(innerW).Height(innerH).MaxHeight(innerH + 2).Render(b.String())
+}
+
+func logStyle(kind LogKind) lipgloss.Style {
+	switch kind {
+	case LogQuery:
+		return AccentText
+	case LogResult:
+		return SuccessText
+	case LogInfo:
+		return DimText
+	case LogWarning:
+		return ModifiedText
+	case LogError:
+		return ErrorText
+	}
+	return CellNormal
+}
@@ -0,0 +1,226 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"cli-sql/internal/editor"
+)
+
+// PendingChange is one staged change as the pending changes overlay lists
+// it.
+type PendingChange struct {
+	Type   editor.OpType
+	Table  string
+	PK     map[string]string // key of the edited or deleted row
+	Column string            // edited column
+	Old    string            // value of the edited cell as loaded
+	New    string            // value it is staged to take
+	Values map[string]string // inserted values, by column
+	Order  []string          // columns of Values in table order
+	Insert int               // which insert, for OpInsert, as the app numbers them
+}
+
+// DiscardChangeMsg asks the app to drop one staged change, leaving the rest.
+type DiscardChangeMsg struct {
+	Change PendingChange
+}
+
+// PendingModel is a modal listing every staged change, grouped by table,
+// from which single changes can be discarded.
+type PendingModel struct {
+	visible bool
+	changes []PendingChange
+	format  DisplayFormat // for the columns whose values are masked
+	cursor  int
+	scroll  int
+	width   int
+	height  int
+}
+
+func NewPendingModel() PendingModel {
+	return PendingModel{}
+}
+
+// Open lists changes, which are to be grouped by table already. Values of
+// columns format masks are shown as dots.
+func (m *PendingModel) Open(changes []PendingChange, format DisplayFormat) {
+	*m = PendingModel{visible: true, format: format, width: m.width, height: m.height}
+	m.SetChanges(changes)
+}
+
+// SetChanges lists changes in place of the ones shown, keeping the cursor
+// at the same place in the list.
+func (m *PendingModel) SetChanges(changes []PendingChange) {
+	m.changes = changes
+	m.cursor = min(m.cursor, max(0, len(changes)-1))
+	m.clampScroll()
+}
+
+func (m *PendingModel) Close() {
+	m.visible = false
+}
+
+func (m PendingModel) Visible() bool {
+	return m.visible
+}
+
+func (m *PendingModel) SetSize(w, h int) {
+	m.width = w
+	m.height = h
+}
+
+func (m PendingModel) Update(msg tea.Msg) (PendingModel, tea.Cmd) {
+	if !m.visible {
+		return m, nil
+	}
+
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch {
+	case key.String() == "esc" || key.String() == "q" || KeyMatches(key, ActionPendingChanges):
+		m.Close()
+	case KeyMatches(key, ActionUp):
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case KeyMatches(key, ActionDown):
+		if m.cursor < len(m.changes)-1 {
+			m.cursor++
+		}
+	case KeyMatches(key, ActionTop):
+		m.cursor = 0
+	case KeyMatches(key, ActionBottom):
+		m.cursor = max(0, len(m.changes)-1)
+	case key.String() == "d" || key.String() == "x" || key.String() == "delete":
+		if len(m.changes) == 0 {
+			return m, nil
+		}
+		c := m.changes[m.cursor]
+		return m, func() tea.Msg { return DiscardChangeMsg{Change: c} }
+	}
+	m.clampScroll()
+	return m, nil
+}
+
+// clampScroll keeps the cursor in sight. Table headers take lines of their
+// own, so it works on the lines the changes are drawn at.
+func (m *PendingModel) clampScroll() {
+	h := m.bodyHeight()
+	line := m.lineOf(m.cursor)
+	if line < m.scroll {
+		m.scroll = line
+		if m.cursor == 0 || m.changes[m.cursor-1].Table != m.changes[m.cursor].Table {
+			// Bring the table's header into sight with its first change.
+			m.scroll = max(0, line-1)
+		}
+	} else if line >= m.scroll+h {
+		m.scroll = line - h + 1
+	}
+}
+
+// lineOf returns the line of the list change i is drawn at.
+func (m PendingModel) lineOf(i int) int {
+	line := 0
+	for j := 0; j <= i && j < len(m.changes); j++ {
+		if j == 0 || m.changes[j-1].Table != m.changes[j].Table {
+			line++
+		}
+		if j < i {
+			line++
+		}
+	}
+	return line
+}
+
+// bodyHeight is how many lines of the list fit inside the modal.
+func (m PendingModel) bodyHeight() int {
+	// Border, padding, title, hint and blank lines.
+	return max(1, m.height-7)
+}
+
+// value shows the value v of column col, or dots if the column is masked.
+func (m PendingModel) value(col, v string) string {
+	if m.format.masks(col) && v != editor.NullValue && v != editor.DefaultValue {
+		return maskText
+	}
+	return sanitizeCell(cellLabel(v))
+}
+
+// describe is the line listing c.
+func (m PendingModel) describe(c PendingChange) string {
+	switch c.Type {
+	case editor.OpEdit:
+		return fmt.Sprintf("edit    %s: %s → %s  (%s)", c.Column, m.value(c.Column, c.Old), m.value(c.Column, c.New), editor.DescribePK(c.PK))
+	case editor.OpDelete:
+		return "delete  " + editor.DescribePK(c.PK)
+	}
+	parts := make([]string, 0, len(c.Order))
+	for _, col := range c.Order {
+		if v, ok := c.Values[col]; ok {
+			parts = append(parts, col+"="+m.value(col, v))
+		}
+	}
+	if len(parts) == 0 {
+		return "insert  (all defaults)"
+	}
+	return "insert  " + strings.Join(parts, ", ")
+}
+
+func (m PendingModel) View() string {
+	if !m.visible {
+		return ""
+	}
+
+	modalW := 100
+	if m.width > 0 && modalW > m.width-4 {
+		modalW = m.width - 4
+	}
+	textW := max(10, modalW-6)
+
+	var b strings.Builder
+	b.WriteString(HeaderStyle.Render(truncateDisplay(fmt.Sprintf("Pending changes (%d)", len(m.changes)), textW)))
+	b.WriteString("\n")
+	b.WriteString(DimText.Render(truncateDisplay("  d discard the selected change | Esc close", textW)))
+	b.WriteString("\n")
+
+	var lines []string
+	for i, c := range m.changes {
+		if i == 0 || m.changes[i-1].Table != c.Table {
+			lines = append(lines, SubHeaderStyle.Render(truncateDisplay(c.Table, textW)))
+		}
+		line := truncateDisplay(m.describe(c), textW-2)
+		switch {
+		case i == m.cursor:
+			line = AccentText.Bold(true).Render("▸ " + line)
+		case c.Type == editor.OpDelete:
+			line = DeletedText.Render("  " + line)
+		case c.Type == editor.OpInsert:
+			line = NewRowText.Render("  " + line)
+		default:
+			line = ModifiedText.Render("  " + line)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		lines = append(lines, DimText.Render("  Nothing is staged"))
+	}
+	end := min(m.scroll+m.bodyHeight(), len(lines))
+	for _, line := range lines[min(m.scroll, end):end] {
+		b.WriteString("\n")
+		b.WriteString(line)
+	}
+
+	modalStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ColorAccent).
+		Padding(1, 2).
+		Width(modalW)
+
+	return centerModal(modalStyle.Render(b.String()), m.width, m.height)
+}
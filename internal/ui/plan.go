@@ -0,0 +1,141 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ExplainQueryMsg asks the app to show the estimated plan of SQL without
+// running it.
+type ExplainQueryMsg struct {
+	SQL string
+}
+
+// planCost matches the estimate EXPLAIN prints on each plan node.
+var planCost = regexp.MustCompile(`\(cost=[\d.]+\.\.([\d.]+) rows=(\d+)`)
+
+// PlanSummary returns the total cost and row estimate of the top plan node,
+// as "cost 35.50, ~2550 rows", or "" if plan has none.
+func PlanSummary(plan string) string {
+	m := planCost.FindStringSubmatch(plan)
+	if m == nil {
+		return ""
+	}
+	return fmt.Sprintf("cost %s, ~%s rows", m[1], m[2])
+}
+
+// PlanModel is a modal showing the output of EXPLAIN for a statement.
+type PlanModel struct {
+	visible bool
+	sql     string
+	lines   []string
+	scroll  int
+	width   int
+	height  int
+}
+
+func NewPlanModel() PlanModel {
+	return PlanModel{}
+}
+
+func (m *PlanModel) Open(sql, plan string) {
+	m.visible = true
+	m.sql = sql
+	m.lines = strings.Split(plan, "\n")
+	m.scroll = 0
+}
+
+func (m *PlanModel) Close() {
+	m.visible = false
+}
+
+func (m PlanModel) Visible() bool {
+	return m.visible
+}
+
+func (m *PlanModel) SetSize(w, h int) {
+	m.width = w
+	m.height = h
+}
+
+func (m PlanModel) Update(msg tea.Msg) (PlanModel, tea.Cmd) {
+	if !m.visible {
+		return m, nil
+	}
+
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case msg.String() == "esc" || msg.String() == "q" || msg.String() == "enter":
+			m.Close()
+		case KeyMatches(msg, ActionUp):
+			if m.scroll > 0 {
+				m.scroll--
+			}
+		case KeyMatches(msg, ActionDown):
+			if m.scroll < m.maxScroll() {
+				m.scroll++
+			}
+		}
+	}
+	return m, nil
+}
+
+// bodyHeight is how many plan lines fit inside the modal.
+func (m PlanModel) bodyHeight() int {
+	// Border, padding, title, statement and hint lines.
+	return max(1, m.height-10)
+}
+
+func (m PlanModel) maxScroll() int {
+	return max(0, len(m.lines)-m.bodyHeight())
+}
+
+func (m PlanModel) View() string {
+	if !m.visible {
+		return ""
+	}
+
+	modalW := 100
+	if m.width > 0 && modalW > m.width-4 {
+		modalW = m.width - 4
+	}
+	textW := max(10, modalW-4)
+
+	h := m.bodyHeight()
+	start := min(m.scroll, m.maxScroll())
+	end := min(start+h, len(m.lines))
+
+	var b strings.Builder
+	title := "Estimated plan (not executed)"
+	if summary := PlanSummary(strings.Join(m.lines, "\n")); summary != "" {
+		title += ": " + summary
+	}
+	b.WriteString(HeaderStyle.Render(truncateDisplay(title, textW)))
+	b.WriteString("\n")
+	b.WriteString(DimText.Render(truncateDisplay(sanitizeCell(m.sql), textW)))
+	b.WriteString("\n")
+	hint := "  Esc close"
+	if len(m.lines) > h {
+		hint = fmt.Sprintf("  %s/%s scroll [%d-%d of %d] | Esc close", KeyLabel(ActionDown), KeyLabel(ActionUp), start+1, end, len(m.lines))
+	}
+	b.WriteString(DimText.Render(hint))
+	b.WriteString("\n\n")
+	for i := start; i < end; i++ {
+		b.WriteString(truncateDisplay(m.lines[i], textW))
+		if i < end-1 {
+			b.WriteString("\n")
+		}
+	}
+
+	modalStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ColorAccent).
+		Padding(1, 2).
+		Width(modalW)
+
+	return centerModal(modalStyle.Render(b.String()), m.width, m.height)
+}
@@ -0,0 +1,201 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"cli-sql/internal/db"
+)
+
+// ProfileTableMsg is sent when the user asks for the null and distinct
+// counts of a table's columns.
+type ProfileTableMsg struct {
+	Table string
+}
+
+// CountRowsMsg is sent when the user asks for the exact row count of a table.
+type CountRowsMsg struct {
+	Table string
+}
+
+// ProfileModel is a modal showing how complete and how varied each column
+// of a table is.
+type ProfileModel struct {
+	visible bool
+	table   string
+	profile *db.TableProfile
+	cursor  int
+	scroll  int
+	width   int
+	height  int
+}
+
+func NewProfileModel() ProfileModel {
+	return ProfileModel{}
+}
+
+func (m *ProfileModel) Open(table string, profile *db.TableProfile) {
+	*m = ProfileModel{visible: true, table: table, profile: profile, width: m.width, height: m.height}
+}
+
+func (m *ProfileModel) Close() {
+	m.visible = false
+}
+
+func (m ProfileModel) Visible() bool {
+	return m.visible
+}
+
+func (m *ProfileModel) SetSize(w, h int) {
+	m.width = w
+	m.height = h
+}
+
+func (m ProfileModel) Update(msg tea.Msg) (ProfileModel, tea.Cmd) {
+	if !m.visible {
+		return m, nil
+	}
+
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	n := len(m.profile.Columns)
+	switch {
+	case key.String() == "esc" || key.String() == "q":
+		m.Close()
+	case KeyMatches(key, ActionUp):
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case KeyMatches(key, ActionDown):
+		if m.cursor < n-1 {
+			m.cursor++
+		}
+	case KeyMatches(key, ActionTop):
+		m.cursor = 0
+	case KeyMatches(key, ActionBottom):
+		m.cursor = max(0, n-1)
+	}
+	h := m.bodyHeight()
+	if m.cursor < m.scroll {
+		m.scroll = m.cursor
+	} else if m.cursor >= m.scroll+h {
+		m.scroll = m.cursor - h + 1
+	}
+	return m, nil
+}
+
+// bodyHeight is how many columns fit inside the modal.
+func (m ProfileModel) bodyHeight() int {
+	// Border, padding, title, hint, blank and header lines.
+	return max(1, m.height-8)
+}
+
+// fillBar draws the share of non-null values as a bar of width cells.
+func fillBar(nonNull, total int64, width int) string {
+	filled := width
+	if total > 0 {
+		filled = int((nonNull*int64(width) + total/2) / total)
+	}
+	return strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+}
+
+// profileNote flags a column that is entirely null, holds a single value or
+// has no repeated values.
+func profileNote(c db.ColumnProfile, rows int64) string {
+	switch {
+	case rows == 0:
+		return ""
+	case c.Nulls == rows:
+		return "all null"
+	case c.Distinct == 1:
+		return "constant"
+	case c.Distinct == rows-c.Nulls:
+		return "unique"
+	}
+	return ""
+}
+
+func (m ProfileModel) View() string {
+	if !m.visible {
+		return ""
+	}
+
+	modalW := 100
+	if m.width > 0 && modalW > m.width-4 {
+		modalW = m.width - 4
+	}
+	textW := max(10, modalW-6)
+	p := m.profile
+	const barW = 10
+
+	nameW, typeW := len("column"), len("type")
+	for _, c := range p.Columns {
+		nameW = max(nameW, lipgloss.Width(c.Name))
+		typeW = max(typeW, lipgloss.Width(c.DataType))
+	}
+	nameW, typeW = min(nameW, 30), min(typeW, 20)
+	countW := max(len("distinct"), len(fmt.Sprint(p.Rows)))
+
+	title := fmt.Sprintf("Profile of %s (%d rows)", m.table, p.Rows)
+	if p.Sampled {
+		title = fmt.Sprintf("Profile of %s (sample of %d rows, approximate)", m.table, p.Rows)
+	}
+	var b strings.Builder
+	b.WriteString(HeaderStyle.Render(truncateDisplay(title, textW)))
+	b.WriteString("\n")
+	b.WriteString(DimText.Render(truncateDisplay("  bar: share of non-null values | distinct: non-null values, compared as text | Esc close", textW)))
+	b.WriteString("\n\n")
+
+	header := fmt.Sprintf("  %s  %s  %s  %s  %s  %s", padDisplay("column", nameW), padDisplay("type", typeW),
+		padDisplay("filled", barW+5), padLeft("nulls", countW), padLeft("distinct", countW), "note")
+	b.WriteString(HeaderStyle.Render(truncateDisplay(header, textW)))
+
+	h := m.bodyHeight()
+	end := min(m.scroll+h, len(p.Columns))
+	for i := m.scroll; i < end; i++ {
+		c := p.Columns[i]
+		pct := 100.0
+		if p.Rows > 0 {
+			pct = 100 * float64(p.Rows-c.Nulls) / float64(p.Rows)
+		}
+		note := profileNote(c, p.Rows)
+		line := fmt.Sprintf("%s  %s  %s %3.0f%%  %s  %s  %s",
+			padDisplay(truncateDisplay(c.Name, nameW), nameW),
+			padDisplay(truncateDisplay(c.DataType, typeW), typeW),
+			fillBar(p.Rows-c.Nulls, p.Rows, barW), pct,
+			padLeft(fmt.Sprint(c.Nulls), countW), padLeft(fmt.Sprint(c.Distinct), countW), note)
+		line = truncateDisplay(line, textW-2)
+		b.WriteString("\n")
+		switch {
+		case i == m.cursor:
+			b.WriteString(AccentText.Bold(true).Render("▸ " + line))
+		case note == "all null":
+			b.WriteString(ErrorText.Render("  " + line))
+		case c.Nulls > 0:
+			b.WriteString(ModifiedText.Render("  " + line))
+		default:
+			b.WriteString("  " + line)
+		}
+	}
+
+	modalStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ColorAccent).
+		Padding(1, 2).
+		Width(modalW)
+
+	return centerModal(modalStyle.Render(b.String()), m.width, m.height)
+}
+
+// padLeft right-aligns s in w cells.
+func padLeft(s string, w int) string {
+	if n := lipgloss.Width(s); n < w {
+		return strings.Repeat(" ", w-n) + s
+	}
+	return s
+}
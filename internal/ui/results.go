@@ -1,13 +1,19 @@
 package ui
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/textarea"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
+	"cli-sql/internal/db"
 	"cli-sql/internal/editor"
 )
 
@@ -16,19 +22,46 @@ type EditBlockedMsg struct {
 	Reason string
 }
 
+// FollowReferenceMsg asks the app to open the row referenced by the foreign
+// key on Column. Row holds the stored values of the cursor row; NULLs are nil.
+type FollowReferenceMsg struct {
+	TableName string
+	Column    string
+	Row       map[string]interface{}
+}
+
+// SaveAsTableMsg asks the app to store the output of the last query in a
+// new table called Name.
+type SaveAsTableMsg struct {
+	Name string
+}
+
+// ShowReferencingMsg asks the app to list the rows in other tables whose
+// foreign keys reference the cursor row through Column.
+type ShowReferencingMsg struct {
+	TableName string
+	Column    string
+	Row       map[string]interface{}
+}
+
 // ResultsModel is the interactive results table with CRUD support.
 type ResultsModel struct {
 	columns         []string
 	columnTypes     []string
 	rows            [][]string
+	rawRows         [][]interface{}
 	cursorRow       int
 	cursorCol       int
 	focused         bool
 	editing         bool
 	editValue       string
+	editMarker      string // editor.NullValue or editor.DefaultValue when the buffer holds one rather than text
 	changes         *editor.ChangeTracker
 	tableName       string
 	primaryKeys     []string
+	blockedReason   string            // why free-form results have no table to edit, if known
+	autoColumns     map[string]bool   // serial columns, left for the database to fill on insert
+	columnDefaults  map[string]string // default expressions, shown as hints on inserted rows
 	scrollOffset    int
 	colOffset       int
 	width           int
@@ -37,15 +70,35 @@ type ResultsModel struct {
 	errMsg          string
 	infoMsg         string
 	bannerMsg       string
-	insertedRows    int // count of locally inserted rows (at end of rows slice)
+	insertedRows    int        // count of locally inserted rows (at end of rows slice)
+	undoneRows      [][]string // added rows taken back by undo, most recent last, for redo
 	searching       bool
 	searchQuery     string
+	searchMode      SearchMode
+	colJumping      bool // typing a column name or number after "|"
+	colJumpQuery    string
+	colJumpFrom     int  // cursor column to return to if the jump is cancelled
+	naming          bool // typing the name of a table to save the results as
+	nameInput       string
 	filteredIndices []int
 	searchCursor    int
+	filter          *valueFilter // rows kept by value, nil to list them all
+	shownRows       []int        // rows the filter keeps, in order
 	previewing      bool
 	previewScroll   int
 	previewEditing  bool
 	previewTextarea textarea.Model
+	previewSearch   bool // typing a query in the preview overlay
+	previewQuery    string
+	previewMatches  []int // wrapped line indices containing previewQuery
+	previewMatchIdx int
+	previewRaw      bool // show json/jsonb values as stored instead of indented
+	showStats       bool // footer summarising the cursor column
+	noTranspose     bool // list a single row as a grid too
+	format          DisplayFormat
+	maskedCols      []bool // columns format.MaskColumns hides, nil if none
+	revealed        bool   // show the cursor cell of a masked column until the next key
+	readOnly        bool   // read-only mode: no edits, inserts or deletes
 }
 
 // NewResultsModel creates a new results model.
@@ -55,6 +108,16 @@ func NewResultsModel(changes *editor.ChangeTracker) ResultsModel {
 	}
 }
 
+// SetReadOnly turns read-only mode on or off.
+func (m *ResultsModel) SetReadOnly(on bool) {
+	m.readOnly = on
+}
+
+// readOnlyBlocked is the reply to an edit attempted in read-only mode.
+func readOnlyBlocked() tea.Msg {
+	return EditBlockedMsg{Reason: "Read-only mode: editing is turned off"}
+}
+
 // SetFocused sets focus state.
 func (m *ResultsModel) SetFocused(f bool) {
 	m.focused = f
@@ -65,34 +128,87 @@ func (m ResultsModel) Focused() bool {
 	return m.focused
 }
 
+// SetDisplayFormat sets how timestamps and numbers are shown in the grid.
+func (m *ResultsModel) SetDisplayFormat(f DisplayFormat) {
+	m.format = f
+	m.calcColWidths()
+}
+
 // SetSize sets the results dimensions.
 func (m *ResultsModel) SetSize(w, h int) {
 	m.width = w
 	m.height = h
 }
 
-// SetData populates the results table with query output.
-func (m *ResultsModel) SetData(columns []string, columnTypes []string, rows [][]string) {
+// SetData populates the results table with query output. rawRows holds the
+// undisplayed database values, used to address rows by primary key, and
+// nulls marks the cells that are SQL NULL; they are held as
+// editor.NullValue from here on.
+func (m *ResultsModel) SetData(columns []string, columnTypes []string, rows [][]string, rawRows [][]interface{}, nulls [][]bool) {
+	for ri, nullRow := range nulls {
+		for ci, isNull := range nullRow {
+			if isNull && ri < len(rows) && ci < len(rows[ri]) {
+				rows[ri][ci] = editor.NullValue
+			}
+		}
+	}
 	m.columns = columns
 	m.columnTypes = columnTypes
 	m.rows = rows
+	m.rawRows = rawRows
 	m.cursorRow = 0
 	m.cursorCol = 0
 	m.scrollOffset = 0
 	m.colOffset = 0
 	m.editing = false
 	m.editValue = ""
+	m.editMarker = ""
 	m.errMsg = ""
 	m.infoMsg = ""
 	m.bannerMsg = ""
 	m.insertedRows = 0
+	m.undoneRows = nil
+	m.filter = nil
+	m.shownRows = nil
+	m.revealed = false
 	m.calcColWidths()
 }
 
-// SetTableContext sets the current table name and PKs for CRUD.
-func (m *ResultsModel) SetTableContext(tableName string, pks []string) {
+// SetTableContext sets the current table name, PKs and column metadata for
+// CRUD. columns may be nil if the metadata couldn't be loaded.
+func (m *ResultsModel) SetTableContext(tableName string, pks []string, columns []db.ColumnInfo) {
 	m.tableName = tableName
 	m.primaryKeys = pks
+	m.blockedReason = ""
+	m.autoColumns = make(map[string]bool)
+	m.columnDefaults = make(map[string]string)
+	for _, col := range columns {
+		if col.IsAutoIncrement() {
+			m.autoColumns[col.Name] = true
+		}
+		if col.ColumnDefault != nil {
+			m.columnDefaults[col.Name] = *col.ColumnDefault
+		}
+	}
+}
+
+// SetEditBlockedReason records why the free-form results shown were left
+// without a table to edit, such as the primary key not being selected. It
+// is given as the reason an edit was refused, until the next SetTableContext.
+func (m *ResultsModel) SetEditBlockedReason(reason string) {
+	m.blockedReason = reason
+}
+
+// noKeyBlocked is the reply to an edit of a row that has no primary key to
+// address it by.
+func (m ResultsModel) noKeyBlocked() tea.Msg {
+	switch {
+	case m.tableName == "" && m.blockedReason != "":
+		return EditBlockedMsg{Reason: "Cannot edit: " + m.blockedReason}
+	case m.tableName == "":
+		return EditBlockedMsg{Reason: "Cannot edit free-form query results"}
+	}
+	return EditBlockedMsg{Reason: "Cannot edit: table has no primary key"}
 }
 
 // SetError shows an error message in the results pane.
@@ -119,18 +235,24 @@ func (m *ResultsModel) Clear() {
 	m.columns = nil
 	m.columnTypes = nil
 	m.rows = nil
+	m.rawRows = nil
 	m.cursorRow = 0
 	m.cursorCol = 0
 	m.scrollOffset = 0
 	m.colOffset = 0
 	m.editing = false
 	m.editValue = ""
+	m.editMarker = ""
 	m.errMsg = ""
 	m.infoMsg = ""
 	m.bannerMsg = ""
 	m.tableName = ""
 	m.primaryKeys = nil
+	m.blockedReason = ""
 	m.insertedRows = 0
+	m.undoneRows = nil
+	m.filter = nil
+	m.shownRows = nil
 }
 
 // ClearInsertedRows removes all locally inserted rows.
@@ -138,8 +260,16 @@ func (m *ResultsModel) ClearInsertedRows() {
 	if m.insertedRows > 0 {
 		m.rows = m.rows[:len(m.rows)-m.insertedRows]
 		m.insertedRows = 0
+		m.undoneRows = nil
+		if m.filter != nil {
+			m.shownRows = m.shownRows[:m.rowPos(len(m.rows))]
+			if len(m.shownRows) == 0 {
+				m.filter = nil
+				m.shownRows = nil
+			}
+		}
 		if m.cursorRow >= len(m.rows) && len(m.rows) > 0 {
-			m.cursorRow = len(m.rows) - 1
+			m.cursorRow = m.rowAt(m.shownCount() - 1)
 		}
 		if m.cursorRow < 0 {
 			m.cursorRow = 0
@@ -148,14 +278,241 @@ func (m *ResultsModel) ClearInsertedRows() {
 	}
 }
 
+// discardCursorChange drops the staged change under the cursor, leaving the
+// others: the cell's edit, else the row's deletion, or the row itself if it
+// was inserted here. It reports whether there was one.
+func (m *ResultsModel) discardCursorChange() bool {
+	if m.cursorRow >= len(m.rows) || m.cursorCol >= len(m.columns) {
+		return false
+	}
+	if m.isInsertedRow(m.cursorRow) {
+		m.RemoveInsertedRow(m.cursorRow - (len(m.rows) - m.insertedRows))
+		return true
+	}
+	if len(m.primaryKeys) == 0 {
+		return false
+	}
+	pkVals := m.pkValues(m.cursorRow)
+	if _, ok := m.changes.GetCellEdit(m.tableName, pkVals, m.columns[m.cursorCol]); ok {
+		m.changes.RemoveEdit(m.tableName, pkVals, m.columns[m.cursorCol])
+		return true
+	}
+	if m.changes.IsRowDeleted(m.tableName, pkVals) {
+		m.changes.RemoveDelete(m.tableName, pkVals)
+		return true
+	}
+	return false
+}
+
+// RemoveInsertedRow drops the i-th of the locally inserted rows, in the
+// order GetInsertedRowValues lists them.
+func (m *ResultsModel) RemoveInsertedRow(i int) {
+	if i < 0 || i >= m.insertedRows {
+		return
+	}
+	ri := len(m.rows) - m.insertedRows + i
+	m.rows = slices.Delete(m.rows, ri, ri+1)
+	m.insertedRows--
+	if m.filter != nil {
+		m.shownRows = slices.DeleteFunc(m.shownRows, func(r int) bool { return r == ri })
+		for j, r := range m.shownRows {
+			if r > ri {
+				m.shownRows[j]--
+			}
+		}
+		if len(m.shownRows) == 0 {
+			m.filter = nil
+			m.shownRows = nil
+		}
+	}
+	if m.cursorRow > ri || m.cursorRow >= len(m.rows) {
+		m.cursorRow = max(0, m.cursorRow-1)
+	}
+	if !m.rowShown(m.cursorRow) && m.shownCount() > 0 {
+		m.cursorRow = m.rowAt(min(m.rowPos(m.cursorRow), m.shownCount()-1))
+	}
+	m.ensureRowVisible()
+}
+
+// appendInsertedRow adds row after the others inserted here and moves the
+// cursor to it.
+func (m *ResultsModel) appendInsertedRow(row []string) {
+	m.rows = append(m.rows, row)
+	m.insertedRows++
+	if m.filter != nil {
+		m.shownRows = append(m.shownRows, len(m.rows)-1)
+	}
+	m.cursorRow = len(m.rows) - 1
+	m.cursorCol = 0
+	m.ensureRowVisible()
+}
+
+// undo takes back the last staged change or added row. A row added here
+// that has since gone, discarded or cleared away with its table, is passed
+// over for the operation before it.
+func (m *ResultsModel) undo() {
+	for {
+		op, ok := m.changes.Undo()
+		if !ok || op != editor.OpAddRow {
+			return
+		}
+		if m.insertedRows > 0 {
+			m.undoneRows = append(m.undoneRows, m.rows[len(m.rows)-1])
+			m.RemoveInsertedRow(m.insertedRows - 1)
+			return
+		}
+	}
+}
+
+// redo puts back what undo last took back.
+func (m *ResultsModel) redo() {
+	for {
+		op, ok := m.changes.Redo()
+		if !ok || op != editor.OpAddRow {
+			return
+		}
+		if n := len(m.undoneRows); n > 0 {
+			m.appendInsertedRow(m.undoneRows[n-1])
+			m.undoneRows = m.undoneRows[:n-1]
+			return
+		}
+	}
+}
+
+// SelectRow moves the cursor to the loaded row of tableName whose primary key
+// matches pkValues, and to column when given. Returns false if no such row is visible.
+func (m *ResultsModel) SelectRow(tableName string, pkValues map[string]string, column string) bool {
+	if tableName != m.tableName || len(m.primaryKeys) == 0 {
+		return false
+	}
+	for ri := 0; ri < len(m.rows)-m.insertedRows; ri++ {
+		vals := m.pkValues(ri)
+		if len(vals) != len(pkValues) {
+			continue
+		}
+		match := true
+		for k, v := range pkValues {
+			if vals[k] != v {
+				match = false
+				break
+			}
+		}
+		if !match {
+			continue
+		}
+		if !m.rowShown(ri) {
+			m.filter = nil
+			m.shownRows = nil
+		}
+		m.cursorRow = ri
+		m.ensureRowVisible()
+		for ci, col := range m.columns {
+			if col == column {
+				m.cursorCol = ci
+				m.ensureColVisible()
+				break
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// Position records where the cursor is in the grid so it can be put back
+// after the same table is reloaded.
+type Position struct {
+	tableName string
+	pkValues  map[string]string // nil when the row can't be addressed by PK
+	row, col  int
+	column    string
+	screenRow int // cursor row relative to the top of the viewport
+	colOffset int
+}
+
+// Position returns the current cursor position.
+func (m ResultsModel) Position() Position {
+	p := Position{
+		tableName: m.tableName,
+		row:       m.cursorRow,
+		col:       m.cursorCol,
+		screenRow: m.rowPos(m.cursorRow) - m.scrollOffset,
+		colOffset: m.colOffset,
+	}
+	if m.cursorCol < len(m.columns) {
+		p.column = m.columns[m.cursorCol]
+	}
+	if len(m.primaryKeys) > 0 && m.cursorRow < len(m.rows) && !m.isInsertedRow(m.cursorRow) {
+		p.pkValues = m.pkValues(m.cursorRow)
+	}
+	return p
+}
+
+// RestorePosition moves the cursor back to p after SetData and
+// SetTableContext have loaded the same table again. The row is found by
+// primary key when it has one, otherwise by index; the column by name.
+// It does nothing if a different table is loaded.
+func (m *ResultsModel) RestorePosition(p Position) {
+	if p.tableName == "" || p.tableName != m.tableName || len(m.rows) == 0 {
+		return
+	}
+	row := -1
+	if p.pkValues != nil && len(m.primaryKeys) > 0 {
+		for ri := 0; ri < len(m.rows)-m.insertedRows && row == -1; ri++ {
+			vals := m.pkValues(ri)
+			match := len(vals) == len(p.pkValues)
+			for k, v := range p.pkValues {
+				if vals[k] != v {
+					match = false
+					break
+				}
+			}
+			if match {
+				row = ri
+			}
+		}
+	}
+	if row == -1 {
+		// The row is gone or has no key; stay at the same place in the list.
+		row = min(p.row, len(m.rows)-1)
+	}
+	col := -1
+	for ci, c := range m.columns {
+		if c == p.column {
+			col = ci
+			break
+		}
+	}
+	if col == -1 {
+		col = min(p.col, max(0, len(m.columns)-1))
+	}
+
+	m.cursorRow = row
+	m.cursorCol = col
+	m.scrollOffset = max(0, row-p.screenRow)
+	m.colOffset = min(p.colOffset, col)
+	m.ensureRowVisible()
+	m.ensureColVisible()
+}
+
 // IsEditing returns whether we're in edit mode.
 func (m ResultsModel) IsEditing() bool {
 	return m.editing
 }
 
-// IsSearching returns whether we're in search mode.
+// IsSearching returns whether we're in search mode, or typing a column to
+// jump to or a table name, which take keys the same way.
 func (m ResultsModel) IsSearching() bool {
-	return m.searching
+	return m.searching || m.colJumping || m.naming
+}
+
+// IsJumpingColumn returns whether we're typing a column to jump to.
+func (m ResultsModel) IsJumpingColumn() bool {
+	return m.colJumping
+}
+
+// IsNamingTable returns whether we're typing a name to save the results as.
+func (m ResultsModel) IsNamingTable() bool {
+	return m.naming
 }
 
 // IsPreviewing returns whether we're in cell preview mode.
@@ -171,8 +528,15 @@ func (m *ResultsModel) applyRowFilter() {
 	}
 	m.filteredIndices = nil
 	for ri, row := range m.rows {
-		for _, cell := range row {
-			if FuzzyMatch(cell, m.searchQuery) {
+		if !m.rowShown(ri) {
+			continue
+		}
+		for ci := range row {
+			// Hidden values are not searched, or a match would give them away.
+			if m.isMaskedCell(ri, ci, m.displayValue(ri, ci)) {
+				continue
+			}
+			if m.searchMode.Match(m.cellText(ri, ci), m.searchQuery) {
 				m.filteredIndices = append(m.filteredIndices, ri)
 				break
 			}
@@ -185,6 +549,11 @@ func (m *ResultsModel) applyRowFilter() {
 	}
 }
 
+// LoadedRows returns how many rows were fetched, not counting added ones.
+func (m ResultsModel) LoadedRows() int {
+	return len(m.rows) - m.insertedRows
+}
+
 // HasPrimaryKey returns whether the current table has a PK.
 func (m ResultsModel) HasPrimaryKey() bool {
 	return len(m.primaryKeys) > 0
@@ -195,15 +564,17 @@ func (m *ResultsModel) calcColWidths() {
 		m.colWidths = nil
 		return
 	}
+	m.setMaskedColumns()
 	m.colWidths = make([]int, len(m.columns))
 	for i, col := range m.columns {
 		w := len(col)
 		if w < 10 {
 			w = 10
 		}
-		for _, row := range m.rows {
-			if i < len(row) && len(row[i]) > w {
-				w = len(row[i])
+		// A masked column is sized to the dots, giving away no lengths.
+		for ri, row := range m.rows {
+			if i < len(row) && !m.isMaskedColumn(i) {
+				w = max(w, len(cellLabel(m.formatCell(ri, i, row[i]))))
 			}
 		}
 		if w > 40 {
@@ -232,124 +603,227 @@ func (m ResultsModel) Update(msg tea.Msg) (ResultsModel, tea.Cmd) {
 		if m.searching {
 			return m.updateSearchMode(msg)
 		}
+		if m.colJumping {
+			return m.updateColJump(msg), nil
+		}
+		if m.naming {
+			return m.updateNaming(msg)
+		}
 		if m.editing {
 			return m.updateEditMode(msg)
 		}
 		return m.updateNavMode(msg)
+	case tea.MouseMsg:
+		return m.updateMouse(msg), nil
 	}
 	return m, nil
 }
 
-func (m ResultsModel) updateNavMode(msg tea.KeyMsg) (ResultsModel, tea.Cmd) {
-	if len(m.rows) == 0 && msg.String() != "a" {
-		return m, nil
+// updateMouse handles a click or wheel event. X and Y are relative to the
+// pane's top-left corner, border included.
+func (m ResultsModel) updateMouse(msg tea.MouseMsg) ResultsModel {
+	switch msg.Button {
+	case tea.MouseButtonWheelUp, tea.MouseButtonWheelDown:
+		step := 3
+		if msg.Button == tea.MouseButtonWheelUp {
+			step = -step
+		}
+		m.scrollBy(step)
+	case tea.MouseButtonLeft:
+		if msg.Action != tea.MouseActionPress || m.previewing || m.IsSearching() || m.editing {
+			break
+		}
+		m.revealed = false
+		if row, col, ok := m.cellAt(msg.X-1, msg.Y-1); ok {
+			m.cursorRow = row
+			m.cursorCol = col
+			m.ensureRowVisible()
+			m.ensureColVisible()
+		}
 	}
+	return m
+}
 
-	switch msg.String() {
-	case "up", "k":
-		if m.cursorRow > 0 {
-			m.cursorRow--
-			m.ensureRowVisible()
+// scrollBy moves the cursor (or the preview) by delta rows.
+func (m *ResultsModel) scrollBy(delta int) {
+	if m.previewing {
+		m.previewScroll += delta
+		if m.previewScroll < 0 {
+			m.previewScroll = 0
 		}
-	case "down", "j":
-		if m.cursorRow < len(m.rows)-1 {
-			m.cursorRow++
-			m.ensureRowVisible()
+		return
+	}
+	if m.editing || len(m.rows) == 0 {
+		return
+	}
+	if m.transposed() {
+		m.moveCursorCol(delta)
+		return
+	}
+	m.moveCursorRow(delta)
+}
+
+// cellAt maps a position inside the border to the row and column drawn
+// there by renderTable.
+func (m ResultsModel) cellAt(x, y int) (int, int, bool) {
+	if m.errMsg != "" || len(m.columns) == 0 || x < 0 || y < 0 {
+		return 0, 0, false
+	}
+	// Lines above the first row: search, banner, header and separator.
+	top, bottom := 2, 0
+	if m.searching || m.searchQuery != "" {
+		top++
+	}
+	if m.colJumping || m.naming {
+		top++
+	}
+	if m.filter != nil {
+		top++
+	}
+	if m.bannerMsg != "" {
+		top++
+	}
+	if m.showStats {
+		bottom++
+	}
+	innerW, innerH := m.width-2, m.height-2
+	if innerW < 10 {
+		innerW = 10
+	}
+	if innerH < 3 {
+		innerH = 3
+	}
+	// renderTable shows h-3 rows, where h is innerH less the optional lines.
+	visRows := max(1, innerH-(top-2)-bottom-3)
+	if m.transposed() {
+		ci := m.transposeStart(visRows) + y - top
+		if y < top || y-top >= visRows || ci >= len(m.columns) {
+			return 0, 0, false
 		}
-	case "left", "h":
+		return m.rowAt(0), ci, true
+	}
+	pos := m.scrollOffset + y - top
+	if y < top || y-top >= visRows || pos >= m.shownCount() {
+		return 0, 0, false
+	}
+	row := m.rowAt(pos)
+
+	left := 0
+	for _, ci := range m.visibleColumns(innerW) {
+		// A click on the " | " separator counts toward the column before it.
+		if x < left+m.colWidths[ci]+3 {
+			return row, ci, true
+		}
+		left += m.colWidths[ci] + 3
+	}
+	return 0, 0, false
+}
+
+func (m ResultsModel) updateNavMode(msg tea.KeyMsg) (ResultsModel, tea.Cmd) {
+	if len(m.rows) == 0 && !KeyMatches(msg, ActionAddRow) {
+		return m, nil
+	}
+	// A revealed cell is only shown until the next key.
+	wasRevealed := m.revealed
+	m.revealed = false
+
+	switch {
+	case KeyMatches(msg, ActionRevealCell):
+		m.revealed = !wasRevealed && m.isMaskedColumn(m.cursorCol)
+	case m.transposed() && KeyMatches(msg, ActionUp):
+		m.moveCursorCol(-1)
+	case m.transposed() && KeyMatches(msg, ActionDown):
+		m.moveCursorCol(1)
+	case m.transposed() && KeyMatches(msg, ActionTop):
+		m.moveCursorCol(-len(m.columns))
+	case m.transposed() && KeyMatches(msg, ActionBottom):
+		m.moveCursorCol(len(m.columns))
+	case KeyMatches(msg, ActionUp):
+		m.moveCursorRow(-1)
+	case KeyMatches(msg, ActionDown):
+		m.moveCursorRow(1)
+	case KeyMatches(msg, ActionLeft):
 		if m.cursorCol > 0 {
 			m.cursorCol--
 			m.ensureColVisible()
 		}
-	case "right", "l":
+	case KeyMatches(msg, ActionRight):
 		if m.cursorCol < len(m.columns)-1 {
 			m.cursorCol++
 			m.ensureColVisible()
 		}
-	case "e":
+	case m.readOnly && (KeyMatches(msg, ActionEditCell) || KeyMatches(msg, ActionDeleteRow) || KeyMatches(msg, ActionAddRow)):
+		return m, readOnlyBlocked
+	case KeyMatches(msg, ActionEditCell):
 		if len(m.primaryKeys) == 0 && !m.isInsertedRow(m.cursorRow) {
-			if m.tableName == "" {
-				return m, func() tea.Msg {
-					return EditBlockedMsg{Reason: "Cannot edit free-form query results"}
-				}
-			}
-			return m, func() tea.Msg {
-				return EditBlockedMsg{Reason: "Cannot edit: table has no primary key"}
-			}
+			return m, m.noKeyBlocked
 		}
 		if len(m.rows) > 0 {
 			m.editing = true
-			m.editValue = m.displayValue(m.cursorRow, m.cursorCol)
-			if m.editValue == "<NULL>" {
-				m.editValue = ""
-			}
+			m = m.moveToEditCell(m.cursorCol)
 		}
-	case "d":
+	case KeyMatches(msg, ActionDeleteRow):
 		if len(m.primaryKeys) == 0 {
 			return m, nil
 		}
 		if len(m.rows) > 0 && !m.isInsertedRow(m.cursorRow) {
 			pkVals := m.pkValues(m.cursorRow)
 			if m.changes.IsRowDeleted(m.tableName, pkVals) {
-				m.changes.UnstageDelete(m.tableName, pkVals)
+				m.changes.RemoveDelete(m.tableName, pkVals)
 			} else {
 				m.changes.StageDelete(editor.RowDelete{
 					TableName:   m.tableName,
 					RowPKValues: pkVals,
+					RowPKRaw:    m.pkRawValues(m.cursorRow),
+					PKColumns:   m.primaryKeys,
 				})
 			}
 		}
-	case "a":
+	case KeyMatches(msg, ActionDiscardChange):
+		if !m.discardCursorChange() {
+			return m, func() tea.Msg { return EditBlockedMsg{Reason: "Nothing is staged at the cursor"} }
+		}
+	case KeyMatches(msg, ActionAddRow):
 		if len(m.primaryKeys) == 0 && m.tableName != "" {
 			return m, nil
 		}
 		if len(m.columns) > 0 {
 			newRow := make([]string, len(m.columns))
-			for i := range newRow {
-				newRow[i] = ""
+			for i, col := range m.columns {
+				if _, ok := m.columnDefaults[col]; ok {
+					newRow[i] = editor.DefaultValue
+				} else {
+					newRow[i] = editor.NullValue
+				}
+			}
+			m.appendInsertedRow(newRow)
+			m.changes.MarkRowAdded()
+			m.undoneRows = nil
+			// Enter edit mode on the first column the user has to fill in
+			if col := m.nextEditCol(-1, 1); col != -1 {
+				m.editing = true
+				m = m.moveToEditCell(col)
 			}
-			m.rows = append(m.rows, newRow)
-			m.insertedRows++
-			m.cursorRow = len(m.rows) - 1
-			m.cursorCol = 0
-			m.ensureRowVisible()
-			// Enter edit mode on first cell
-			m.editing = true
-			m.editValue = ""
 		}
-	case "ctrl+z":
-		m.changes.Undo()
-	case "g":
-		m.cursorRow = 0
+	case KeyMatches(msg, ActionUndo):
+		m.undo()
+	case KeyMatches(msg, ActionRedo):
+		m.redo()
+	case KeyMatches(msg, ActionTop):
+		m.cursorRow = m.rowAt(0)
 		m.scrollOffset = 0
-	case "G":
-		if len(m.rows) > 0 {
-			m.cursorRow = len(m.rows) - 1
-			m.ensureRowVisible()
-		}
-	case "pgup":
-		visibleRows := m.visibleRowCount()
-		m.cursorRow -= visibleRows
-		if m.cursorRow < 0 {
-			m.cursorRow = 0
-		}
-		m.ensureRowVisible()
-	case "pgdown":
-		visibleRows := m.visibleRowCount()
-		m.cursorRow += visibleRows
-		if m.cursorRow >= len(m.rows) {
-			m.cursorRow = len(m.rows) - 1
-		}
-		if m.cursorRow < 0 {
-			m.cursorRow = 0
-		}
-		m.ensureRowVisible()
-	case "/":
+	case KeyMatches(msg, ActionBottom):
+		m.moveCursorRow(m.shownCount())
+	case KeyMatches(msg, ActionPageUp):
+		m.moveCursorRow(-m.visibleRowCount())
+	case KeyMatches(msg, ActionPageDown):
+		m.moveCursorRow(m.visibleRowCount())
+	case KeyMatches(msg, ActionSearch):
 		m.searching = true
 		m.searchQuery = ""
 		m.filteredIndices = nil
 		m.searchCursor = 0
-	case "n":
+	case KeyMatches(msg, ActionNextMatch):
 		if len(m.filteredIndices) > 0 {
 			m.searchCursor++
 			if m.searchCursor >= len(m.filteredIndices) {
@@ -358,7 +832,7 @@ func (m ResultsModel) updateNavMode(msg tea.KeyMsg) (ResultsModel, tea.Cmd) {
 			m.cursorRow = m.filteredIndices[m.searchCursor]
 			m.ensureRowVisible()
 		}
-	case "N":
+	case KeyMatches(msg, ActionPrevMatch):
 		if len(m.filteredIndices) > 0 {
 			m.searchCursor--
 			if m.searchCursor < 0 {
@@ -367,15 +841,71 @@ func (m ResultsModel) updateNavMode(msg tea.KeyMsg) (ResultsModel, tea.Cmd) {
 			m.cursorRow = m.filteredIndices[m.searchCursor]
 			m.ensureRowVisible()
 		}
-	case "v":
+	case KeyMatches(msg, ActionFollowReference):
+		if len(m.columns) > 0 && !m.isInsertedRow(m.cursorRow) {
+			msg := FollowReferenceMsg{
+				TableName: m.tableName,
+				Column:    m.columns[m.cursorCol],
+				Row:       m.rowValues(m.cursorRow),
+			}
+			return m, func() tea.Msg { return msg }
+		}
+	case KeyMatches(msg, ActionShowReferencing):
+		if len(m.columns) > 0 && !m.isInsertedRow(m.cursorRow) {
+			col := m.columns[m.cursorCol]
+			isPK := false
+			for _, pk := range m.primaryKeys {
+				isPK = isPK || pk == col
+			}
+			if !isPK {
+				return m, func() tea.Msg {
+					return EditBlockedMsg{Reason: "Move to a primary key column to show referencing rows"}
+				}
+			}
+			msg := ShowReferencingMsg{
+				TableName: m.tableName,
+				Column:    col,
+				Row:       m.rowValues(m.cursorRow),
+			}
+			return m, func() tea.Msg { return msg }
+		}
+	case KeyMatches(msg, ActionFirstColumn):
+		m.cursorCol = 0
+		m.ensureColVisible()
+	case KeyMatches(msg, ActionLastColumn):
+		if len(m.columns) > 0 {
+			m.cursorCol = len(m.columns) - 1
+			m.ensureColVisible()
+		}
+	case KeyMatches(msg, ActionJumpColumn):
+		if len(m.columns) > 0 {
+			m.colJumping = true
+			m.colJumpQuery = ""
+			m.colJumpFrom = m.cursorCol
+		}
+	case KeyMatches(msg, ActionSaveAsTable):
+		if len(m.columns) > 0 {
+			m.naming = true
+			m.nameInput = ""
+		}
+	case KeyMatches(msg, ActionFilterValue):
+		m.toggleValueFilter()
+	case KeyMatches(msg, ActionTranspose):
+		m.noTranspose = !m.noTranspose
+	case KeyMatches(msg, ActionToggleStats):
+		m.showStats = !m.showStats
+		m.ensureRowVisible()
+	case KeyMatches(msg, ActionPreview):
 		if len(m.rows) > 0 && len(m.columns) > 0 {
 			val := m.displayValue(m.cursorRow, m.cursorCol)
-			if val == "<NULL>" {
+			if val == editor.NullValue || val == editor.DefaultValue {
 				val = ""
 			}
 			m.previewing = true
 			m.previewScroll = 0
 			m.previewEditing = false
+			m.previewRaw = false
+			m.clearPreviewSearch()
 			ta := textarea.New()
 			ta.SetValue(val)
 			ta.CharLimit = 0
@@ -396,7 +926,95 @@ func (m ResultsModel) updateNavMode(msg tea.KeyMsg) (ResultsModel, tea.Cmd) {
 	return m, nil
 }
 
+// updateColJump handles typing after "|": a number jumps to that column
+// (counting from 1), anything else to the best-matching column name.
+func (m ResultsModel) updateColJump(msg tea.KeyMsg) ResultsModel {
+	switch msg.String() {
+	case "esc":
+		m.colJumping = false
+		m.cursorCol = m.colJumpFrom
+		m.ensureColVisible()
+		return m
+	case "enter":
+		m.colJumping = false
+		return m
+	case "backspace":
+		if len(m.colJumpQuery) > 0 {
+			m.colJumpQuery = m.colJumpQuery[:len(m.colJumpQuery)-1]
+		}
+	default:
+		if msg.Type == tea.KeyRunes {
+			m.colJumpQuery += string(msg.Runes)
+		} else if msg.Type == tea.KeySpace {
+			m.colJumpQuery += " "
+		}
+	}
+	if col := m.findColumn(m.colJumpQuery); col != -1 {
+		m.cursorCol = col
+		m.ensureColVisible()
+	}
+	return m
+}
+
+// updateNaming takes the name of the table to save the results as.
+func (m ResultsModel) updateNaming(msg tea.KeyMsg) (ResultsModel, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.naming = false
+	case "enter":
+		name := strings.TrimSpace(m.nameInput)
+		if name == "" {
+			return m, nil
+		}
+		m.naming = false
+		return m, func() tea.Msg { return SaveAsTableMsg{Name: name} }
+	case "backspace":
+		if len(m.nameInput) > 0 {
+			m.nameInput = m.nameInput[:len(m.nameInput)-1]
+		}
+	case "ctrl+u":
+		m.nameInput = ""
+	default:
+		if msg.Type == tea.KeyRunes {
+			m.nameInput += string(msg.Runes)
+		}
+	}
+	return m, nil
+}
+
+// findColumn returns the column a jump query names, or -1: a 1-based
+// number, else an exact name, a name prefix or a substring, ignoring case.
+func (m ResultsModel) findColumn(query string) int {
+	if query == "" {
+		return -1
+	}
+	if n, err := strconv.Atoi(query); err == nil {
+		if n >= 1 && n <= len(m.columns) {
+			return n - 1
+		}
+		return -1
+	}
+	q := strings.ToLower(query)
+	for _, match := range []func(name string) bool{
+		func(name string) bool { return name == q },
+		func(name string) bool { return strings.HasPrefix(name, q) },
+		func(name string) bool { return strings.Contains(name, q) },
+	} {
+		for i, c := range m.columns {
+			if match(strings.ToLower(c)) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
 func (m ResultsModel) updateSearchMode(msg tea.KeyMsg) (ResultsModel, tea.Cmd) {
+	if mode, ok := m.searchMode.toggle(msg); ok {
+		m.searchMode = mode
+		m.applyRowFilter()
+		return m, nil
+	}
 	switch msg.String() {
 	case "esc":
 		m.searching = false
@@ -435,6 +1053,7 @@ func (m ResultsModel) updatePreviewMode(msg tea.KeyMsg) (ResultsModel, tea.Cmd)
 			return m, nil
 		case "ctrl+s":
 			m.editValue = m.previewTextarea.Value()
+			m.editMarker = ""
 			m = m.commitCurrentCell()
 			m.previewing = false
 			m.previewEditing = false
@@ -445,38 +1064,176 @@ func (m ResultsModel) updatePreviewMode(msg tea.KeyMsg) (ResultsModel, tea.Cmd)
 		return m, cmd
 	}
 
-	switch msg.String() {
-	case "esc", "v":
+	if m.previewSearch {
+		return m.updatePreviewSearch(msg), nil
+	}
+
+	switch {
+	case msg.String() == "esc" || KeyMatches(msg, ActionPreview):
+		if msg.String() == "esc" && m.previewQuery != "" {
+			m.clearPreviewSearch()
+			return m, nil
+		}
 		m.previewing = false
 		m.previewScroll = 0
-	case "e":
+		m.clearPreviewSearch()
+	case KeyMatches(msg, ActionTogglePretty):
+		if m.isJSONColumn(m.cursorCol) {
+			m.previewRaw = !m.previewRaw
+			m.previewScroll = 0
+			m.applyPreviewSearch()
+		}
+	case KeyMatches(msg, ActionSearch):
+		m.previewSearch = true
+		m.previewQuery = ""
+		m.previewMatches = nil
+	case KeyMatches(msg, ActionNextMatch):
+		if len(m.previewMatches) > 0 {
+			m.previewMatchIdx = (m.previewMatchIdx + 1) % len(m.previewMatches)
+			m.previewScroll = m.previewMatches[m.previewMatchIdx]
+		}
+	case KeyMatches(msg, ActionPrevMatch):
+		if len(m.previewMatches) > 0 {
+			m.previewMatchIdx = (m.previewMatchIdx - 1 + len(m.previewMatches)) % len(m.previewMatches)
+			m.previewScroll = m.previewMatches[m.previewMatchIdx]
+		}
+	case KeyMatches(msg, ActionEditCell) && m.readOnly:
+		return m, readOnlyBlocked
+	case KeyMatches(msg, ActionEditCell):
 		if len(m.primaryKeys) == 0 && !m.isInsertedRow(m.cursorRow) {
-			if m.tableName == "" {
-				return m, func() tea.Msg {
-					return EditBlockedMsg{Reason: "Cannot edit free-form query results"}
-				}
-			}
-			return m, func() tea.Msg {
-				return EditBlockedMsg{Reason: "Cannot edit: table has no primary key"}
-			}
+			return m, m.noKeyBlocked
 		}
 		m.previewEditing = true
 		cmd := m.previewTextarea.Focus()
 		return m, cmd
-	case "j", "down":
+	case KeyMatches(msg, ActionDown):
 		m.previewScroll++
-	case "k", "up":
+	case KeyMatches(msg, ActionUp):
 		if m.previewScroll > 0 {
 			m.previewScroll--
 		}
-	case "G":
+	case KeyMatches(msg, ActionBottom):
 		m.previewScroll = 99999
-	case "g":
+	case KeyMatches(msg, ActionTop):
 		m.previewScroll = 0
 	}
 	return m, nil
 }
 
+func (m ResultsModel) updatePreviewSearch(msg tea.KeyMsg) ResultsModel {
+	switch msg.String() {
+	case "esc":
+		m.clearPreviewSearch()
+	case "enter":
+		m.previewSearch = false
+	case "backspace":
+		if len(m.previewQuery) > 0 {
+			m.previewQuery = m.previewQuery[:len(m.previewQuery)-1]
+			m.applyPreviewSearch()
+		}
+	default:
+		if len(msg.String()) == 1 || msg.Type == tea.KeySpace {
+			m.previewQuery += msg.String()
+			m.applyPreviewSearch()
+		} else if msg.Type == tea.KeyRunes {
+			m.previewQuery += string(msg.Runes)
+			m.applyPreviewSearch()
+		}
+	}
+	return m
+}
+
+func (m *ResultsModel) clearPreviewSearch() {
+	m.previewSearch = false
+	m.previewQuery = ""
+	m.previewMatches = nil
+	m.previewMatchIdx = 0
+}
+
+// applyPreviewSearch finds the preview lines containing previewQuery and
+// scrolls to the first one at or below the current scroll position.
+func (m *ResultsModel) applyPreviewSearch() {
+	m.previewMatches = nil
+	m.previewMatchIdx = 0
+	re := previewSearchRegexp(m.previewQuery)
+	if re == nil {
+		return
+	}
+	for i, line := range m.previewLines(m.previewWidth()) {
+		if re.MatchString(line) {
+			m.previewMatches = append(m.previewMatches, i)
+		}
+	}
+	if len(m.previewMatches) == 0 {
+		return
+	}
+	for i, line := range m.previewMatches {
+		if line >= m.previewScroll {
+			m.previewMatchIdx = i
+			break
+		}
+	}
+	m.previewScroll = m.previewMatches[m.previewMatchIdx]
+}
+
+// previewSearchRegexp matches query literally and case-insensitively, or
+// returns nil for an empty query.
+func previewSearchRegexp(query string) *regexp.Regexp {
+	if query == "" {
+		return nil
+	}
+	return regexp.MustCompile("(?i)" + regexp.QuoteMeta(query))
+}
+
+// previewWidth is the width the preview overlay wraps its value to; it
+// matches the inner width computed in View.
+func (m ResultsModel) previewWidth() int {
+	if m.width-2 < 10 {
+		return 10
+	}
+	return m.width - 2
+}
+
+// previewLines returns the previewed cell value wrapped to width w.
+func (m ResultsModel) previewLines(w int) []string {
+	return strings.Split(wordWrap(m.previewText(), w), "\n")
+}
+
+// previewText returns the previewed cell value, indented if it is a json or
+// jsonb value and the raw view isn't toggled on.
+func (m ResultsModel) previewText() string {
+	val := m.displayValue(m.cursorRow, m.cursorCol)
+	if val == editor.NullValue {
+		return cellLabel(val)
+	}
+	if m.previewRaw || !m.isJSONColumn(m.cursorCol) {
+		return val
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, []byte(val), "", "  "); err != nil {
+		return val
+	}
+	return out.String()
+}
+
+// isJSONColumn reports whether column col holds json or jsonb values.
+func (m ResultsModel) isJSONColumn(col int) bool {
+	if col >= len(m.columnTypes) {
+		return false
+	}
+	t := m.columnTypes[col]
+	return t == "json" || t == "jsonb"
+}
+
+// rawValue returns the decoded database value of a cell, or nil for inserted
+// rows and results without raw values.
+func (m ResultsModel) rawValue(rowIdx, colIdx int) interface{} {
+	if rowIdx >= len(m.rawRows) || m.isInsertedRow(rowIdx) || colIdx >= len(m.rawRows[rowIdx]) {
+		return nil
+	}
+	return m.rawRows[rowIdx][colIdx]
+}
+
 func (m ResultsModel) renderPreviewOverlay(w, h int) string {
 	var b strings.Builder
 
@@ -493,14 +1250,48 @@ func (m ResultsModel) renderPreviewOverlay(w, h int) string {
 		b.WriteString(m.previewTextarea.View())
 	} else {
 		title := HeaderStyle.Render(fmt.Sprintf("Preview: %s [row %d]", colName, m.cursorRow+1))
-		hint := DimText.Render("e edit | j/k scroll | Esc close")
+		hintText := "e edit | j/k scroll | / search | Esc close"
+		if m.isJSONColumn(m.cursorCol) {
+			if m.previewRaw {
+				hintText = "p pretty | " + hintText
+			} else {
+				hintText = "p raw | " + hintText
+			}
+		}
+		hint := DimText.Render(hintText)
 		b.WriteString(title + "  " + hint)
+		if m.previewSearch || m.previewQuery != "" {
+			searchDisp := SearchLabel.Render("/") + SearchInput.Render(m.previewQuery)
+			if m.previewSearch {
+				searchDisp += SearchInput.Render("█")
+			}
+			if len(m.previewMatches) > 0 {
+				searchDisp += DimText.Render(fmt.Sprintf(" [%d/%d] n/N", m.previewMatchIdx+1, len(m.previewMatches)))
+			} else if m.previewQuery != "" {
+				searchDisp += DimText.Render(" [no matches]")
+			}
+			b.WriteString("  " + searchDisp)
+		}
 		b.WriteString("\n")
 		b.WriteString(DimText.Render(strings.Repeat("─", w)))
 		b.WriteString("\n")
 
-		val := m.displayValue(m.cursorRow, m.cursorCol)
-		lines := strings.Split(wordWrap(val, w), "\n")
+		lines := m.previewLines(w)
+		re := previewSearchRegexp(m.previewQuery)
+
+		// Color pretty-printed JSON, except while search matches are highlighted.
+		var jsonText string
+		var jsonTokens []literalSpan
+		var lineStarts []int
+		if re == nil && !m.previewRaw && m.isJSONColumn(m.cursorCol) {
+			jsonText = strings.Join(lines, "\n")
+			jsonTokens = jsonSpans(jsonText)
+			pos := 0
+			for _, line := range lines {
+				lineStarts = append(lineStarts, pos)
+				pos += len(line) + 1
+			}
+		}
 
 		viewH := h - 4
 		if viewH < 1 {
@@ -522,7 +1313,15 @@ func (m ResultsModel) renderPreviewOverlay(w, h int) string {
 		}
 
 		for i := scroll; i < endLine; i++ {
-			b.WriteString(lines[i])
+			if re != nil {
+				b.WriteString(re.ReplaceAllStringFunc(lines[i], func(match string) string {
+					return SearchMatch.Render(match)
+				}))
+			} else if jsonTokens != nil {
+				b.WriteString(styleRange(jsonText, lineStarts[i], lineStarts[i]+len(lines[i]), jsonTokens))
+			} else {
+				b.WriteString(lines[i])
+			}
 			if i < endLine-1 {
 				b.WriteString("\n")
 			}
@@ -558,8 +1357,8 @@ func wordWrap(s string, width int) string {
 
 func (m ResultsModel) commitCurrentCell() ResultsModel {
 	newValue := m.editValue
-	if newValue == "" {
-		newValue = "<NULL>"
+	if m.editMarker != "" {
+		newValue = m.editMarker
 	}
 
 	if m.isInsertedRow(m.cursorRow) {
@@ -569,6 +1368,8 @@ func (m ResultsModel) commitCurrentCell() ResultsModel {
 		m.changes.StageEdit(editor.CellEdit{
 			TableName:   m.tableName,
 			RowPKValues: pkVals,
+			RowPKRaw:    m.pkRawValues(m.cursorRow),
+			PKColumns:   m.primaryKeys,
 			ColumnName:  m.columns[m.cursorCol],
 			OldValue:    m.rows[m.cursorRow][m.cursorCol],
 			NewValue:    newValue,
@@ -581,39 +1382,78 @@ func (m ResultsModel) moveToEditCell(col int) ResultsModel {
 	m.cursorCol = col
 	m.ensureColVisible()
 	val := m.displayValue(m.cursorRow, m.cursorCol)
-	if val == "<NULL>" {
+	m.editMarker = ""
+	if val == editor.NullValue || val == editor.DefaultValue {
+		m.editMarker = val
 		val = ""
 	}
 	m.editValue = val
 	return m
 }
 
+// defaultHint returns what to show in place of an inserted cell left at
+// DEFAULT: "auto" for serial columns, otherwise the default expression.
+func (m ResultsModel) defaultHint(rowIdx, colIdx int) string {
+	if !m.isInsertedRow(rowIdx) || m.displayValue(rowIdx, colIdx) != editor.DefaultValue {
+		return ""
+	}
+	col := m.columns[colIdx]
+	if m.autoColumns[col] {
+		return "auto"
+	}
+	return m.columnDefaults[col]
+}
+
+// nextEditCol returns the next column after from, moving by step, that the
+// edit cursor should visit, or -1 if there is none. Columns the database
+// fills in itself are skipped on inserted rows.
+func (m ResultsModel) nextEditCol(from, step int) int {
+	for col := from + step; col >= 0 && col < len(m.columns); col += step {
+		if !m.isInsertedRow(m.cursorRow) || !m.autoColumns[m.columns[col]] {
+			return col
+		}
+	}
+	return -1
+}
+
 func (m ResultsModel) updateEditMode(msg tea.KeyMsg) (ResultsModel, tea.Cmd) {
-	switch msg.String() {
-	case "enter", "tab":
+	switch {
+	case msg.String() == "enter" || msg.String() == "tab":
 		m = m.commitCurrentCell()
-		if m.cursorCol < len(m.columns)-1 {
-			m = m.moveToEditCell(m.cursorCol + 1)
+		if col := m.nextEditCol(m.cursorCol, 1); col != -1 {
+			m = m.moveToEditCell(col)
 		} else {
 			m.editing = false
 		}
-	case "shift+tab":
+	case msg.String() == "shift+tab":
 		m = m.commitCurrentCell()
-		if m.cursorCol > 0 {
-			m = m.moveToEditCell(m.cursorCol - 1)
+		if col := m.nextEditCol(m.cursorCol, -1); col != -1 {
+			m = m.moveToEditCell(col)
 		}
-	case "esc":
+	case msg.String() == "esc":
 		m.editing = false
 		m.editValue = ""
-	case "backspace":
+		m.editMarker = ""
+	case KeyMatches(msg, ActionSetNull):
+		m.editValue = ""
+		m.editMarker = editor.NullValue
+	case KeyMatches(msg, ActionSetDefault):
+		// Put an inserted cell back to its column default
+		if _, ok := m.columnDefaults[m.columns[m.cursorCol]]; ok && m.isInsertedRow(m.cursorRow) {
+			m.editValue = ""
+			m.editMarker = editor.DefaultValue
+		}
+	case msg.String() == "backspace":
 		if len(m.editValue) > 0 {
 			m.editValue = m.editValue[:len(m.editValue)-1]
 		}
 	default:
 		if len(msg.String()) == 1 || msg.Type == tea.KeySpace {
 			m.editValue += msg.String()
+			m.editMarker = ""
 		} else if msg.Type == tea.KeyRunes {
 			m.editValue += string(msg.Runes)
+			m.editMarker = ""
 		}
 	}
 	return m, nil
@@ -621,10 +1461,11 @@ func (m ResultsModel) updateEditMode(msg tea.KeyMsg) (ResultsModel, tea.Cmd) {
 
 func (m *ResultsModel) ensureRowVisible() {
 	visRows := m.visibleRowCount()
-	if m.cursorRow < m.scrollOffset {
-		m.scrollOffset = m.cursorRow
-	} else if m.cursorRow >= m.scrollOffset+visRows {
-		m.scrollOffset = m.cursorRow - visRows + 1
+	pos := m.rowPos(m.cursorRow)
+	if pos < m.scrollOffset {
+		m.scrollOffset = pos
+	} else if pos >= m.scrollOffset+visRows {
+		m.scrollOffset = pos - visRows + 1
 	}
 }
 
@@ -648,6 +1489,9 @@ func (m *ResultsModel) ensureColVisible() {
 func (m ResultsModel) visibleRowCount() int {
 	// Available height minus border (2) + header row (1) + separator (1)
 	h := m.height - 6
+	if m.showStats {
+		h--
+	}
 	if h < 1 {
 		h = 1
 	}
@@ -670,6 +1514,40 @@ func (m ResultsModel) pkValues(rowIdx int) map[string]string {
 	return vals
 }
 
+// pkRawValues returns the database values of the row's primary key columns,
+// or nil when raw values are unavailable (e.g. locally inserted rows).
+func (m ResultsModel) pkRawValues(rowIdx int) map[string]interface{} {
+	if rowIdx >= len(m.rawRows) || m.isInsertedRow(rowIdx) {
+		return nil
+	}
+	vals := make(map[string]interface{})
+	for _, pk := range m.primaryKeys {
+		for i, col := range m.columns {
+			if col == pk && i < len(m.rawRows[rowIdx]) {
+				vals[pk] = m.rawRows[rowIdx][i]
+			}
+		}
+	}
+	return vals
+}
+
+// rowValues returns the stored values of a row keyed by column, preferring
+// the raw database values. Staged edits are not applied.
+func (m ResultsModel) rowValues(rowIdx int) map[string]interface{} {
+	vals := make(map[string]interface{}, len(m.columns))
+	for i, col := range m.columns {
+		switch {
+		case rowIdx < len(m.rawRows) && i < len(m.rawRows[rowIdx]):
+			vals[col] = m.rawRows[rowIdx][i]
+		case i < len(m.rows[rowIdx]) && m.rows[rowIdx][i] != editor.NullValue:
+			vals[col] = m.rows[rowIdx][i]
+		default:
+			vals[col] = nil
+		}
+	}
+	return vals
+}
+
 func (m ResultsModel) displayValue(rowIdx, colIdx int) string {
 	if rowIdx >= len(m.rows) || colIdx >= len(m.rows[rowIdx]) {
 		return ""
@@ -694,18 +1572,16 @@ func (m ResultsModel) GetInsertedRowValues() []editor.RowInsert {
 	for i := startIdx; i < len(m.rows); i++ {
 		vals := make(map[string]string)
 		for j, col := range m.columns {
-			if j < len(m.rows[i]) {
-				val := m.rows[i][j]
-				if val == "" {
-					val = "<NULL>"
-				}
-				vals[col] = val
+			// Cells left at DEFAULT are omitted so the database fills them in.
+			if j < len(m.rows[i]) && m.rows[i][j] != editor.DefaultValue {
+				vals[col] = m.rows[i][j]
 			}
 		}
-		if len(vals) > 0 {
+		if len(m.columns) > 0 {
 			inserts = append(inserts, editor.RowInsert{
 				TableName: m.tableName,
 				Values:    vals,
+				Columns:   m.columns,
 			})
 		}
 	}
@@ -764,10 +1640,7 @@ func (m ResultsModel) renderTable(w, h int) string {
 	var b strings.Builder
 
 	if m.searching || m.searchQuery != "" {
-		searchDisp := SearchLabel.Render("/") + SearchInput.Render(m.searchQuery)
-		if m.searching {
-			searchDisp += SearchInput.Render("█")
-		}
+		searchDisp := searchPrompt(m.searchQuery, m.searching, true, m.searchMode)
 		if len(m.filteredIndices) > 0 {
 			searchDisp += DimText.Render(fmt.Sprintf(" [%d/%d]", m.searchCursor+1, len(m.filteredIndices)))
 		} else if m.searchQuery != "" {
@@ -778,11 +1651,42 @@ func (m ResultsModel) renderTable(w, h int) string {
 		h--
 	}
 
+	if m.colJumping {
+		jumpDisp := SearchLabel.Render("|") + SearchInput.Render(m.colJumpQuery+"█")
+		if m.cursorCol < len(m.columns) {
+			jumpDisp += DimText.Render(fmt.Sprintf(" → %s (%d/%d)", m.columns[m.cursorCol], m.cursorCol+1, len(m.columns)))
+		}
+		b.WriteString(jumpDisp)
+		b.WriteString("\n")
+		h--
+	}
+
+	if m.naming {
+		b.WriteString(SearchLabel.Render("Save as table: ") + SearchInput.Render(m.nameInput+"█") + DimText.Render(" (Enter save, Esc cancel)"))
+		b.WriteString("\n")
+		h--
+	}
+
+	if m.filter != nil {
+		b.WriteString(m.filterLine())
+		b.WriteString("\n")
+		h--
+	}
+
 	if m.bannerMsg != "" {
 		b.WriteString(BannerText.Render(m.bannerMsg))
 		b.WriteString("\n")
 		h--
 	}
+	if m.showStats {
+		h-- // footer, written after the rows
+	}
+
+	if m.transposed() {
+		b.WriteString(m.renderTransposed(w, h))
+		b.WriteString(m.statsFooter(w))
+		return b.String()
+	}
 
 	// Determine visible columns
 	visibleCols := m.visibleColumns(w)
@@ -792,7 +1696,7 @@ func (m ResultsModel) renderTable(w, h int) string {
 	for _, ci := range visibleCols {
 		colW := m.colWidths[ci]
 		name := m.columns[ci]
-		headerParts = append(headerParts, HeaderStyle.Width(colW).Render(truncate(name, colW)))
+		headerParts = append(headerParts, HeaderStyle.Width(colW).Align(m.columnAlign(ci)).Render(truncate(name, colW)))
 	}
 	b.WriteString(strings.Join(headerParts, " | "))
 	b.WriteString("\n")
@@ -813,75 +1717,122 @@ func (m ResultsModel) renderTable(w, h int) string {
 
 	startRow := m.scrollOffset
 	endRow := startRow + visRows
-	if endRow > len(m.rows) {
-		endRow = len(m.rows)
+	if endRow > m.shownCount() {
+		endRow = m.shownCount()
 	}
 
-	for ri := startRow; ri < endRow; ri++ {
+	for pos := startRow; pos < endRow; pos++ {
+		ri := m.rowAt(pos)
 		rowParts := make([]string, 0, len(visibleCols))
 		for _, ci := range visibleCols {
-			val := m.displayValue(ri, ci)
-			colW := m.colWidths[ci]
-			truncVal := truncate(sanitizeCell(val), colW)
+			rowParts = append(rowParts, m.renderCell(ri, ci, m.colWidths[ci]))
+		}
+		b.WriteString(strings.Join(rowParts, " | "))
+		if pos < endRow-1 {
+			b.WriteString("\n")
+		}
+	}
 
-			var style lipgloss.Style
+	// Scroll indicator
+	if m.shownCount() > visRows {
+		scrollInfo := fmt.Sprintf(" [%d-%d of %d]", startRow+1, endRow, m.shownCount())
+		b.WriteString("\n" + DimText.Render(scrollInfo))
+	}
 
-			// Determine cell style
-			isCursor := ri == m.cursorRow && ci == m.cursorCol && m.focused
+	b.WriteString(m.statsFooter(w))
+	return b.String()
+}
 
-			if m.editing && isCursor {
-				// Show edit buffer with cursor
-				editDisp := m.editValue + "█"
-				truncEdit := truncate(editDisp, colW)
-				style = CellEditing
-				rowParts = append(rowParts, style.Width(colW).Render(truncEdit))
-				continue
-			}
+// statsFooter is the line summarising the cursor column, when it is on.
+func (m ResultsModel) statsFooter(w int) string {
+	if !m.showStats || m.cursorCol >= len(m.columns) {
+		return ""
+	}
+	return "\n" + SubHeaderStyle.Render(truncateDisplay("Σ "+m.columnStats(m.cursorCol), w))
+}
 
-			isInserted := m.isInsertedRow(ri)
-			isDeleted := false
-			isModified := false
+// cellText is the text the grid draws for the cell at row ri, column ci,
+// before it is cut to the column width. The search matches against it.
+func (m ResultsModel) cellText(ri, ci int) string {
+	return sanitizeCell(cellLabel(m.formatCell(ri, ci, m.displayValue(ri, ci))))
+}
 
-			if !isInserted && len(m.primaryKeys) > 0 {
-				pkVals := m.pkValues(ri)
-				isDeleted = m.changes.IsRowDeleted(m.tableName, pkVals)
-				_, isModified = m.changes.GetCellEdit(m.tableName, pkVals, m.columns[ci])
-			}
+// renderCell draws the cell at row ri, column ci, colW cells wide.
+func (m ResultsModel) renderCell(ri, ci, colW int) string {
+	val := m.displayValue(ri, ci)
+	text := m.cellText(ri, ci)
+	truncVal := truncate(text, colW)
+	hint := m.defaultHint(ri, ci)
+	if hint != "" {
+		truncVal = truncate(sanitizeCell(hint), colW)
+	} else if m.isMaskedCell(ri, ci, val) {
+		truncVal = truncateDisplay(maskText, colW)
+		text = truncVal
+	}
 
-			isMatch := len(m.filteredIndices) > 0 && m.isMatchRow(ri)
+	var style lipgloss.Style
 
-			switch {
-			case isCursor:
-				style = CellSelected
-			case isDeleted:
-				style = DeletedText
-			case isInserted:
-				style = NewRowText
-			case isModified:
-				style = ModifiedText
-			case isMatch:
-				style = SearchInput
-			case val == "<NULL>":
-				style = NullText
-			default:
-				style = CellNormal
-			}
+	// Determine cell style
+	isCursor := ri == m.cursorRow && ci == m.cursorCol && m.focused
 
-			rowParts = append(rowParts, style.Width(colW).Render(truncVal))
-		}
-		b.WriteString(strings.Join(rowParts, " | "))
-		if ri < endRow-1 {
-			b.WriteString("\n")
+	if m.editing && isCursor {
+		// Show edit buffer with cursor
+		editDisp := m.editValue + "█"
+		if m.editMarker == editor.DefaultValue && hint != "" {
+			editDisp = "█ " + hint
+		} else if m.editMarker != "" {
+			editDisp = cellLabel(m.editMarker) + "█"
 		}
+		truncEdit := truncate(editDisp, colW)
+		style = CellEditing
+		return style.Width(colW).Render(truncEdit)
 	}
 
-	// Scroll indicator
-	if len(m.rows) > visRows {
-		scrollInfo := fmt.Sprintf(" [%d-%d of %d]", startRow+1, endRow, len(m.rows))
-		b.WriteString("\n" + DimText.Render(scrollInfo))
+	isInserted := m.isInsertedRow(ri)
+	isDeleted := false
+	isModified := false
+
+	if !isInserted && len(m.primaryKeys) > 0 {
+		pkVals := m.pkValues(ri)
+		isDeleted = m.changes.IsRowDeleted(m.tableName, pkVals)
+		_, isModified = m.changes.GetCellEdit(m.tableName, pkVals, m.columns[ci])
 	}
 
-	return b.String()
+	isMatch := len(m.filteredIndices) > 0 && m.isMatchRow(ri)
+
+	switch {
+	case isCursor:
+		style = CellSelected
+	case hint != "":
+		style = NullText
+	case isDeleted:
+		style = DeletedText
+	case isInserted:
+		style = NewRowText
+	case isModified:
+		style = ModifiedText
+	case isMatch:
+		style = SearchInput
+	case val == editor.NullValue || val == editor.DefaultValue:
+		style = NullText
+	default:
+		style = CellNormal
+	}
+
+	if isMatch && !isCursor && hint == "" {
+		return m.highlightMatches(text, truncVal, style, colW, m.columnAlign(ci))
+	}
+	return style.Width(colW).Align(m.columnAlign(ci)).Render(truncVal)
+}
+
+// columnAlign is how the grid aligns the cells of column col: numbers to
+// the right so their digits line up, everything else to the left. A single
+// row listed down the page is left-aligned throughout.
+func (m ResultsModel) columnAlign(col int) lipgloss.Position {
+	if col < len(m.columnTypes) && numericTypes[m.columnTypes[col]] && !m.transposed() {
+		return lipgloss.Right
+	}
+	return lipgloss.Left
 }
 
 func (m ResultsModel) visibleColumns(availWidth int) []int {
@@ -904,6 +1855,75 @@ func (m ResultsModel) visibleColumns(availWidth int) []int {
 	return cols
 }
 
+// highlightMatches renders s, the shown part of full, in style with the
+// parts matching the search in SearchMatch, padded to width w on the side
+// align leaves open. Matches are found in full; one cut off by the
+// truncation lights up the "..." in its place.
+func (m ResultsModel) highlightMatches(full, s string, style lipgloss.Style, w int, align lipgloss.Position) string {
+	spans := m.shownSpans(full, len(s))
+	if len(spans) == 0 {
+		return style.Width(w).Align(align).Render(s)
+	}
+	var b strings.Builder
+	pad := max(0, w-lipgloss.Width(s))
+	if align == lipgloss.Right {
+		b.WriteString(strings.Repeat(" ", pad))
+		pad = 0
+	}
+	last := 0
+	for _, sp := range spans {
+		if sp[0] > last {
+			b.WriteString(style.Render(s[last:sp[0]]))
+		}
+		b.WriteString(SearchMatch.Render(s[sp[0]:sp[1]]))
+		last = sp[1]
+	}
+	if last < len(s) {
+		b.WriteString(style.Render(s[last:]))
+	}
+	b.WriteString(strings.Repeat(" ", pad))
+	return b.String()
+}
+
+// shownSpans returns the byte ranges of the search matches in full that fall
+// within shown, the length truncate cut it to. A match running past the cut
+// is clipped, and the "..." standing in for the rest is added as a match.
+func (m ResultsModel) shownSpans(full string, shown int) [][]int {
+	cut := shown
+	if shown < len(full) && shown > 3 {
+		cut = shown - 3 // where the "..." starts
+	}
+	var spans [][]int
+	clipped := false
+	for _, sp := range m.searchMode.Spans(full, m.searchQuery) {
+		if sp[0] >= cut {
+			clipped = true
+			break
+		}
+		if sp[1] > cut {
+			sp = []int{sp[0], cut}
+			clipped = true
+		}
+		spans = append(spans, sp)
+	}
+	if clipped && cut < shown {
+		spans = append(spans, []int{cut, shown})
+	}
+	return spans
+}
+
+// cellLabel returns the text shown for a cell value: the NULL and DEFAULT
+// sentinels are spelled out, anything else is shown as is.
+func cellLabel(val string) string {
+	switch val {
+	case editor.NullValue:
+		return "<NULL>"
+	case editor.DefaultValue:
+		return "<DEFAULT>"
+	}
+	return val
+}
+
 func sanitizeCell(s string) string {
 	s = strings.ReplaceAll(s, "\r\n", "↵")
 	s = strings.ReplaceAll(s, "\n", "↵")
@@ -0,0 +1,104 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+
+	"cli-sql/internal/editor"
+)
+
+// valueFilter keeps the rows of the grid whose column col holds value, as
+// displayed. Rows added since are kept too, so they stay in sight.
+type valueFilter struct {
+	col   int
+	value string
+}
+
+// toggleValueFilter keeps only the rows holding the cursor cell's value in
+// its column, or shows them all again if a filter is already on. Which rows
+// pass is settled here: editing a cell afterwards does not hide its row.
+func (m *ResultsModel) toggleValueFilter() {
+	if m.filter != nil {
+		m.clearValueFilter()
+		return
+	}
+	if m.cursorRow >= len(m.rows) || m.cursorCol >= len(m.columns) {
+		return
+	}
+	value := m.rows[m.cursorRow][m.cursorCol]
+	m.filter = &valueFilter{col: m.cursorCol, value: value}
+	m.shownRows = nil
+	for ri, row := range m.rows {
+		if m.isInsertedRow(ri) || (m.cursorCol < len(row) && row[m.cursorCol] == value) {
+			m.shownRows = append(m.shownRows, ri)
+		}
+	}
+	m.scrollOffset = 0
+	m.ensureRowVisible()
+	m.applyRowFilter()
+}
+
+// clearValueFilter shows every row again, leaving the cursor where it is.
+func (m *ResultsModel) clearValueFilter() {
+	m.filter = nil
+	m.shownRows = nil
+	m.ensureRowVisible()
+	m.applyRowFilter()
+}
+
+// shownCount is how many rows the grid lists.
+func (m ResultsModel) shownCount() int {
+	if m.filter == nil {
+		return len(m.rows)
+	}
+	return len(m.shownRows)
+}
+
+// rowAt returns the row listed at position pos of the grid.
+func (m ResultsModel) rowAt(pos int) int {
+	if m.filter == nil {
+		return pos
+	}
+	return m.shownRows[pos]
+}
+
+// rowPos returns the position in the grid of row ri, or of the first row
+// listed after it when ri is filtered out.
+func (m ResultsModel) rowPos(ri int) int {
+	if m.filter == nil {
+		return ri
+	}
+	return sort.SearchInts(m.shownRows, ri)
+}
+
+// rowShown reports whether row ri is listed.
+func (m ResultsModel) rowShown(ri int) bool {
+	pos := m.rowPos(ri)
+	return pos < m.shownCount() && m.rowAt(pos) == ri
+}
+
+// moveCursorRow moves the cursor delta listed rows, stopping at either end.
+func (m *ResultsModel) moveCursorRow(delta int) {
+	n := m.shownCount()
+	if n == 0 {
+		return
+	}
+	pos := min(max(m.rowPos(m.cursorRow)+delta, 0), n-1)
+	m.cursorRow = m.rowAt(pos)
+	m.ensureRowVisible()
+}
+
+// filterLine describes the active filter, as
+// "Filter: status = active [12 of 340 rows]".
+func (m ResultsModel) filterLine() string {
+	col := m.columns[m.filter.col]
+	cond := col + " = " + sanitizeCell(m.filter.value)
+	switch m.filter.value {
+	case editor.NullValue:
+		cond = col + " is NULL"
+	case editor.DefaultValue:
+		cond = col + " is DEFAULT"
+	}
+	hint := fmt.Sprintf(" [%d of %d rows] (%s show all)", len(m.shownRows), len(m.rows), KeyLabel(ActionFilterValue))
+	return SearchLabel.Render("Filter: ") + SearchInput.Render(cond) + DimText.Render(hint)
+}
@@ -0,0 +1,52 @@
+package ui
+
+import (
+	"path"
+	"strings"
+
+	"cli-sql/internal/editor"
+)
+
+// maskText is shown in place of the values of a masked column.
+const maskText = "••••••"
+
+// masks reports whether column matches one of the MaskColumns patterns.
+// Patterns are shell globs, such as "*password*", matched without regard
+// to case; one that is malformed matches nothing.
+func (f DisplayFormat) masks(column string) bool {
+	column = strings.ToLower(column)
+	for _, p := range f.MaskColumns {
+		if ok, _ := path.Match(strings.ToLower(p), column); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// setMaskedColumns works out which columns the grid masks.
+func (m *ResultsModel) setMaskedColumns() {
+	m.maskedCols = nil
+	for i, col := range m.columns {
+		if m.format.masks(col) {
+			if m.maskedCols == nil {
+				m.maskedCols = make([]bool, len(m.columns))
+			}
+			m.maskedCols[i] = true
+		}
+	}
+}
+
+// isMaskedColumn reports whether the values of column col are hidden.
+func (m ResultsModel) isMaskedColumn(col int) bool {
+	return col < len(m.maskedCols) && m.maskedCols[col]
+}
+
+// isMaskedCell reports whether the grid hides the value of the cell at row
+// ri, column ci: it is in a masked column and hasn't been revealed. NULL and
+// DEFAULT are still shown as such.
+func (m ResultsModel) isMaskedCell(ri, ci int, val string) bool {
+	if !m.isMaskedColumn(ci) || val == editor.NullValue || val == editor.DefaultValue {
+		return false
+	}
+	return !m.revealed || ri != m.cursorRow || ci != m.cursorCol
+}
@@ -0,0 +1,120 @@
+package ui
+
+import (
+	"reflect"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"cli-sql/internal/editor"
+)
+
+func TestSearchSkipsMaskedCells(t *testing.T) {
+	m := NewResultsModel(editor.NewChangeTracker())
+	m.SetDisplayFormat(DisplayFormat{MaskColumns: []string{"password"}})
+	m.SetData([]string{"name", "password"}, []string{"text", "text"}, [][]string{
+		{"ann", "hunter2"},
+		{"bob", "swordfish"},
+		{"hunter", "x"},
+	}, nil, nil)
+
+	tests := []struct {
+		query    string
+		revealed bool
+		want     []int
+	}{
+		{query: "hunter", want: []int{2}},
+		{query: "fish"},
+		{query: "hunter2"},
+		// The cell under the cursor is shown while revealed, so it matches.
+		{query: "hunter2", revealed: true, want: []int{0}},
+	}
+	for _, tt := range tests {
+		m.cursorRow, m.cursorCol = 0, 1
+		m.revealed = tt.revealed
+		m.searchQuery = tt.query
+		m.applyRowFilter()
+		if !reflect.DeepEqual(m.filteredIndices, tt.want) {
+			t.Errorf("search %q (revealed %v) matched rows %v, want %v", tt.query, tt.revealed, m.filteredIndices, tt.want)
+		}
+	}
+}
+
+func TestSearchMatchesShownText(t *testing.T) {
+	m := NewResultsModel(editor.NewChangeTracker())
+	m.SetDisplayFormat(DisplayFormat{ThousandsSeparator: true})
+	m.SetData([]string{"n"}, []string{"int8"}, [][]string{{"1234567"}, {"42"}}, nil, nil)
+
+	for query, want := range map[string][]int{"1,234": {0}, "1234": nil, "42": {1}} {
+		m.searchQuery = query
+		m.applyRowFilter()
+		if !reflect.DeepEqual(m.filteredIndices, want) {
+			t.Errorf("search %q matched rows %v, want %v", query, m.filteredIndices, want)
+		}
+	}
+}
+
+func TestShownSpans(t *testing.T) {
+	full := "abcdefghijklmnop" // shown cut to 10 bytes as "abcdefg..."
+	tests := []struct {
+		query string
+		shown int
+		want  [][]int
+	}{
+		{query: "cd", shown: len(full), want: [][]int{{2, 4}}},
+		{query: "cd", shown: 10, want: [][]int{{2, 4}}},
+		{query: "fghi", shown: 10, want: [][]int{{5, 7}, {7, 10}}},
+		{query: "mno", shown: 10, want: [][]int{{7, 10}}},
+		{query: "xyz", shown: 10},
+	}
+	for _, tt := range tests {
+		m := ResultsModel{searchQuery: tt.query}
+		if got := m.shownSpans(full, tt.shown); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("shownSpans(%q, %d) for %q = %v, want %v", full, tt.shown, tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestUndoAddedRows(t *testing.T) {
+	ct := editor.NewChangeTracker()
+	m := NewResultsModel(ct)
+	m.SetData([]string{"id", "name"}, []string{"int4", "text"}, [][]string{{"1", "ann"}}, nil, nil)
+	m.SetTableContext("users", []string{"id"}, nil)
+	m.SetFocused(true)
+
+	press := func(keys ...tea.KeyMsg) {
+		for _, k := range keys {
+			m, _ = m.Update(k)
+		}
+	}
+	addRow := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")}
+	esc := tea.KeyMsg{Type: tea.KeyEsc}
+	undo := tea.KeyMsg{Type: tea.KeyCtrlZ}
+	redo := tea.KeyMsg{Type: tea.KeyCtrlY}
+
+	press(addRow, esc)
+	ct.StageEdit(editor.CellEdit{TableName: "users", RowPKValues: map[string]string{"id": "1"}, ColumnName: "name", OldValue: "ann", NewValue: "bob"})
+	press(addRow, esc)
+	if m.insertedRows != 2 {
+		t.Fatalf("added %d rows, want 2", m.insertedRows)
+	}
+
+	// Undo goes back through the rows and the edit in the order they came.
+	press(undo)
+	if m.insertedRows != 1 || len(ct.Edits) != 1 {
+		t.Fatalf("after one undo: %d rows, %d edits, want 1, 1", m.insertedRows, len(ct.Edits))
+	}
+	press(undo)
+	if m.insertedRows != 1 || len(ct.Edits) != 0 {
+		t.Fatalf("after two undos: %d rows, %d edits, want 1, 0", m.insertedRows, len(ct.Edits))
+	}
+	press(undo)
+	if m.insertedRows != 0 || len(m.rows) != 1 {
+		t.Fatalf("after three undos: %d rows added, %d in all, want 0, 1", m.insertedRows, len(m.rows))
+	}
+
+	press(redo, redo, redo)
+	if m.insertedRows != 2 || len(ct.Edits) != 1 {
+		t.Errorf("after redoing all: %d rows, %d edits, want 2, 1", m.insertedRows, len(ct.Edits))
+	}
+}
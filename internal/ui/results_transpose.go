@@ -0,0 +1,63 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// transposed reports whether the grid lists a single row, shown as one line
+// per column instead of one very wide line.
+func (m ResultsModel) transposed() bool {
+	return !m.noTranspose && m.shownCount() == 1 && len(m.columns) > 1
+}
+
+// transposeStart is the first column listed when visRows of them fit,
+// keeping the cursor column in sight.
+func (m ResultsModel) transposeStart(visRows int) int {
+	return max(0, m.cursorCol-visRows+1)
+}
+
+// moveCursorCol moves the cursor delta columns, stopping at either end.
+func (m *ResultsModel) moveCursorCol(delta int) {
+	if len(m.columns) == 0 {
+		return
+	}
+	m.cursorCol = min(max(m.cursorCol+delta, 0), len(m.columns)-1)
+	m.ensureColVisible()
+}
+
+// renderTransposed draws the single row as column names down the left with
+// their values beside them, in h lines of w cells.
+func (m ResultsModel) renderTransposed(w, h int) string {
+	ri := m.rowAt(0)
+	nameW := len("column")
+	for _, c := range m.columns {
+		nameW = max(nameW, lipgloss.Width(c))
+	}
+	nameW = min(nameW, 30)
+	valW := max(10, w-nameW-3)
+
+	var b strings.Builder
+	b.WriteString(HeaderStyle.Width(nameW).Render("column") + " | " + HeaderStyle.Width(valW).Render("value"))
+	b.WriteString("\n")
+	b.WriteString(DimText.Render(strings.Repeat("─", nameW) + "─┼─" + strings.Repeat("─", valW)))
+	b.WriteString("\n")
+
+	visRows := max(1, h-3) // header + sep + padding
+	start := m.transposeStart(visRows)
+	end := min(start+visRows, len(m.columns))
+	for ci := start; ci < end; ci++ {
+		b.WriteString(HeaderStyle.Width(nameW).Render(truncate(m.columns[ci], nameW)))
+		b.WriteString(" | ")
+		b.WriteString(m.renderCell(ri, ci, valW))
+		if ci < end-1 {
+			b.WriteString("\n")
+		}
+	}
+	if len(m.columns) > visRows {
+		b.WriteString("\n" + DimText.Render(fmt.Sprintf(" [%d-%d of %d columns]", start+1, end, len(m.columns))))
+	}
+	return b.String()
+}
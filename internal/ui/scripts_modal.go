@@ -300,31 +300,5 @@ func (m ScriptsModalModel) View() string {
 		Padding(1, 2).
 		Width(modalW)
 
-	rendered := modalStyle.Render(content)
-
-	if m.width > 0 && m.height > 0 {
-		renderedLines := strings.Split(rendered, "\n")
-		modalH := len(renderedLines)
-		topPad := (m.height - modalH) / 2
-		if topPad < 0 {
-			topPad = 0
-		}
-		leftPad := (m.width - lipgloss.Width(rendered)) / 2
-		if leftPad < 0 {
-			leftPad = 0
-		}
-
-		var out strings.Builder
-		for i := 0; i < topPad; i++ {
-			out.WriteString("\n")
-		}
-		for _, line := range renderedLines {
-			out.WriteString(strings.Repeat(" ", leftPad))
-			out.WriteString(line)
-			out.WriteString("\n")
-		}
-		return out.String()
-	}
-
-	return rendered
+	return centerModal(modalStyle.Render(content), m.width, m.height)
 }
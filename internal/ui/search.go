@@ -0,0 +1,101 @@
+package ui
+
+import (
+	"regexp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// SearchMode selects how the sidebar and results searches match their
+// query. The zero value is a case-insensitive literal substring search.
+type SearchMode struct {
+	CaseSensitive bool
+	Regex         bool
+}
+
+// compile returns the regexp for query under the mode, or nil if query is
+// empty or not a valid regexp.
+func (sm SearchMode) compile(query string) *regexp.Regexp {
+	if query == "" {
+		return nil
+	}
+	if !sm.Regex {
+		query = regexp.QuoteMeta(query)
+	}
+	if !sm.CaseSensitive {
+		query = "(?i)" + query
+	}
+	re, err := regexp.Compile(query)
+	if err != nil {
+		return nil
+	}
+	return re
+}
+
+// Match reports whether target contains a match for query.
+func (sm SearchMode) Match(target, query string) bool {
+	re := sm.compile(query)
+	return re != nil && re.MatchString(target)
+}
+
+// Spans returns the byte ranges of the non-empty matches of query in target.
+func (sm SearchMode) Spans(target, query string) [][]int {
+	re := sm.compile(query)
+	if re == nil {
+		return nil
+	}
+	var spans [][]int
+	for _, loc := range re.FindAllStringIndex(target, -1) {
+		if loc[1] > loc[0] {
+			spans = append(spans, loc)
+		}
+	}
+	return spans
+}
+
+// toggle flips the case or regex setting if msg is bound to one. The second
+// result reports whether it was.
+func (sm SearchMode) toggle(msg tea.KeyMsg) (SearchMode, bool) {
+	switch {
+	case KeyMatches(msg, ActionSearchCase):
+		sm.CaseSensitive = !sm.CaseSensitive
+	case KeyMatches(msg, ActionSearchRegex):
+		sm.Regex = !sm.Regex
+	default:
+		return sm, false
+	}
+	return sm, true
+}
+
+// label describes the mode for the search prompt.
+func (sm SearchMode) label() string {
+	parts := []string{"literal"}
+	if sm.Regex {
+		parts[0] = "regex"
+	}
+	if sm.CaseSensitive {
+		parts = append(parts, "case")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// searchPrompt renders the "/query" line shared by the sidebar and results,
+// with the cursor while typing and the mode after it. withKeys adds the
+// toggle keys while typing, where there is room for them.
+func searchPrompt(query string, typing, withKeys bool, mode SearchMode) string {
+	disp := SearchLabel.Render("/") + SearchInput.Render(query)
+	if typing {
+		disp += SearchInput.Render("█")
+	}
+	disp += DimText.Render(" [" + mode.label() + "]")
+	if mode.Regex && query != "" && mode.compile(query) == nil {
+		disp += ErrorText.Render(" invalid")
+	}
+	if typing && withKeys {
+		if keys := joinHints(hint("case", ActionSearchCase), hint("regex", ActionSearchRegex)); keys != "" {
+			disp += DimText.Render(" " + keys)
+		}
+	}
+	return disp
+}
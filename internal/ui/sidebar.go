@@ -2,6 +2,7 @@ package ui
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -24,17 +25,50 @@ type CopyDatabaseMsg struct {
 	Target string
 }
 
+// ImportCSVMsg is sent when the user asks to load a CSV file into a table.
+type ImportCSVMsg struct {
+	Table string
+	Path  string
+}
+
+// ExportTableMsg is sent when the user asks to write a whole table to a
+// file.
+type ExportTableMsg struct {
+	Table string
+	Path  string
+}
+
+// AlterTableMsg is sent when the user asks for an ALTER TABLE template for
+// a table in the editor.
+type AlterTableMsg struct {
+	Table string
+}
+
+// SelectTemplateMsg is sent when the user asks for a SELECT listing every
+// column of a table in the editor, to trim or reorder before running.
+type SelectTemplateMsg struct {
+	Table string
+}
+
+// FavoritesChangedMsg is sent when the user stars or unstars a table. Tables
+// is the whole starred set, sorted.
+type FavoritesChangedMsg struct {
+	Tables []string
+}
+
 // DeleteDatabaseMsg is sent when the user confirms deleting a database.
 type DeleteDatabaseMsg struct {
 	Name string
 }
 
-// SidebarMode tracks whether the sidebar shows tables or databases.
+// SidebarMode tracks whether the sidebar shows tables, only the starred
+// ones, or databases.
 type SidebarMode int
 
 const (
 	SidebarTables SidebarMode = iota
 	SidebarDatabases
+	SidebarFavorites
 )
 
 // SidebarModel is the table browser sidebar.
@@ -50,12 +84,23 @@ type SidebarModel struct {
 	focused           bool
 	searching         bool
 	searchQuery       string
+	searchMode        SearchMode
+	lastSearch        map[SidebarMode]string // the filter last used in each mode
 	scrollOffset      int
 	copying           bool
 	copySource        string
 	copyInput         string
 	confirmDelete     bool
 	deleteTarget      string
+	pathPrompt        Action // ActionImportCSV or ActionExportTable while asking for a file
+	pathTable         string
+	pathInput         string
+	comments          map[string]string // table comments, shown for the table under the cursor
+	favorites         map[string]bool   // starred tables, listed first
+	grouped           bool              // tables are shown under prefix or schema headers
+	groupDelimiter    string
+	collapsed         map[string]bool // groups whose tables are hidden
+	rows              []sidebarRow    // the grouped list, headers included
 	width             int
 	height            int
 }
@@ -65,6 +110,7 @@ func NewSidebarModel(tables []string) SidebarModel {
 	return SidebarModel{
 		tables:         tables,
 		filteredTables: tables,
+		groupDelimiter: DefaultGroupDelimiter,
 	}
 }
 
@@ -90,6 +136,58 @@ func (m *SidebarModel) SetTables(tables []string) {
 	m.applyFilter()
 }
 
+// Tables returns the table list.
+func (m SidebarModel) Tables() []string {
+	return m.tables
+}
+
+// SetComments sets the table comments, keyed by table name.
+func (m *SidebarModel) SetComments(comments map[string]string) {
+	m.comments = comments
+}
+
+// SetFavorites sets the starred tables.
+func (m *SidebarModel) SetFavorites(tables []string) {
+	m.favorites = make(map[string]bool, len(tables))
+	for _, t := range tables {
+		m.favorites[t] = true
+	}
+	m.applyFilter()
+}
+
+// Favorites returns the starred tables, sorted.
+func (m SidebarModel) Favorites() []string {
+	tables := make([]string, 0, len(m.favorites))
+	for t := range m.favorites {
+		tables = append(tables, t)
+	}
+	sort.Strings(tables)
+	return tables
+}
+
+// toggleFavorite stars or unstars the table under the cursor, keeping the
+// cursor on it as the list reorders where it can.
+func (m *SidebarModel) toggleFavorite() tea.Cmd {
+	table := m.cursorTable()
+	if m.favorites == nil {
+		m.favorites = map[string]bool{}
+	}
+	if m.favorites[table] {
+		delete(m.favorites, table)
+	} else {
+		m.favorites[table] = true
+	}
+	m.applyFilter()
+	m.cursorTo(table)
+	tables := m.Favorites()
+	return func() tea.Msg { return FavoritesChangedMsg{Tables: tables} }
+}
+
+// showsTables reports whether the sidebar lists tables, all or starred.
+func (m SidebarModel) showsTables() bool {
+	return m.mode != SidebarDatabases
+}
+
 // SetDatabases updates the database list.
 func (m *SidebarModel) SetDatabases(databases []string) {
 	m.databases = databases
@@ -101,12 +199,30 @@ func (m *SidebarModel) SetActiveDatabase(name string) {
 	m.activeDatabase = name
 }
 
-// IsSearching returns whether the sidebar is in an input mode (search, copy, or delete confirm).
+// IsSearching returns whether the sidebar is in an input mode (search, copy,
+// import, or delete confirm).
 func (m SidebarModel) IsSearching() bool {
-	return m.searching || m.copying || m.confirmDelete
+	return m.searching || m.copying || m.pathPrompt != "" || m.confirmDelete
 }
 
 func (m *SidebarModel) ensureVisible() {
+	availLines := m.visibleItems()
+	top := m.cursor
+	if m.grouping() && top > 0 && top < len(m.rows) && m.rows[top-1].table == "" {
+		top-- // keep the group's header in sight
+	}
+	if top < m.scrollOffset {
+		m.scrollOffset = top
+	} else if m.cursor >= m.scrollOffset+availLines {
+		m.scrollOffset = m.cursor - availLines + 1
+	}
+	if m.scrollOffset < 0 {
+		m.scrollOffset = 0
+	}
+}
+
+// visibleItems returns how many list entries fit below the header lines.
+func (m SidebarModel) visibleItems() int {
 	innerH := m.height - 2
 	if innerH < 1 {
 		innerH = 1
@@ -118,26 +234,22 @@ func (m *SidebarModel) ensureVisible() {
 		} else if m.confirmDelete {
 			headerLines = 3
 		}
+	} else {
+		if m.pathPrompt != "" {
+			headerLines = 4
+		}
+		if len(m.comments) > 0 {
+			headerLines++ // the comment line below the list
+		}
 	}
 	availLines := innerH - headerLines
-	listLen := len(m.filteredTables)
-	if m.mode == SidebarDatabases {
-		listLen = len(m.filteredDatabases)
-	}
-	if listLen > availLines {
+	if m.listLen() > availLines {
 		availLines--
 	}
 	if availLines < 1 {
 		availLines = 1
 	}
-	if m.cursor < m.scrollOffset {
-		m.scrollOffset = m.cursor
-	} else if m.cursor >= m.scrollOffset+availLines {
-		m.scrollOffset = m.cursor - availLines + 1
-	}
-	if m.scrollOffset < 0 {
-		m.scrollOffset = 0
-	}
+	return availLines
 }
 
 func (m *SidebarModel) applyFilter() {
@@ -147,7 +259,7 @@ func (m *SidebarModel) applyFilter() {
 		} else {
 			m.filteredDatabases = nil
 			for _, d := range m.databases {
-				if FuzzyMatch(d, m.searchQuery) {
+				if m.searchMode.Match(d, m.searchQuery) {
 					m.filteredDatabases = append(m.filteredDatabases, d)
 				}
 			}
@@ -159,20 +271,32 @@ func (m *SidebarModel) applyFilter() {
 		return
 	}
 
-	if m.searchQuery == "" {
-		m.filteredTables = m.tables
-	} else {
-		m.filteredTables = nil
+	// Starred tables come first, or alone in the favorites list.
+	m.filteredTables = nil
+	for pass := 0; pass < 2; pass++ {
+		if pass == 1 && m.mode == SidebarFavorites {
+			break
+		}
 		for _, t := range m.tables {
-			if FuzzyMatch(t, m.searchQuery) {
+			if m.favorites[t] != (pass == 0) {
+				continue
+			}
+			if m.searchQuery == "" || m.searchMode.Match(t, m.searchQuery) {
 				m.filteredTables = append(m.filteredTables, t)
 			}
 		}
 	}
-	if m.cursor >= len(m.filteredTables) {
-		m.cursor = max(0, len(m.filteredTables)-1)
+	m.buildRows()
+	m.settleCursor()
+}
+
+// SelectTable marks name as the selected table and moves the cursor onto it,
+// as though the user had picked it.
+func (m *SidebarModel) SelectTable(name string) {
+	m.selected = name
+	if m.showsTables() {
+		m.cursorTo(name)
 	}
-	m.ensureVisible()
 }
 
 // Selected returns the currently selected table name.
@@ -199,27 +323,42 @@ func (m SidebarModel) Update(msg tea.Msg) (SidebarModel, tea.Cmd) {
 		if m.copying {
 			return m.updateCopyMode(msg)
 		}
+		if m.pathPrompt != "" {
+			return m.updatePathPrompt(msg)
+		}
 		if m.searching {
 			return m.updateSearchMode(msg)
 		}
 
-		listLen := len(m.filteredTables)
-		if m.mode == SidebarDatabases {
-			listLen = len(m.filteredDatabases)
-		}
-
-		switch msg.String() {
-		case "up", "k":
-			if m.cursor > 0 {
-				m.cursor--
-				m.ensureVisible()
+		switch {
+		case KeyMatches(msg, ActionUp):
+			m.moveCursor(-1)
+		case KeyMatches(msg, ActionDown):
+			m.moveCursor(1)
+		case KeyMatches(msg, ActionGroupTables):
+			if m.showsTables() {
+				table := m.cursorTable()
+				m.grouped = !m.grouped
+				m.applyFilter()
+				m.cursorTo(table)
+			}
+		case KeyMatches(msg, ActionCollapseGroups):
+			if m.grouping() {
+				m.toggleAllGroups()
+			}
+		case KeyMatches(msg, ActionLeft):
+			if group, ok := m.cursorGroup(); ok && !m.groupCollapsed(group) {
+				m.setCollapsed(group, true)
+			}
+		case KeyMatches(msg, ActionRight):
+			if group, ok := m.cursorGroup(); ok && m.groupCollapsed(group) {
+				m.setCollapsed(group, false)
 			}
-		case "down", "j":
-			if m.cursor < listLen-1 {
-				m.cursor++
-				m.ensureVisible()
+		case KeyMatches(msg, ActionSelect) && m.cursorTable() == "" && m.grouping():
+			if group, ok := m.cursorGroup(); ok {
+				m.setCollapsed(group, false)
 			}
-		case "enter":
+		case KeyMatches(msg, ActionSelect):
 			if m.mode == SidebarDatabases {
 				if len(m.filteredDatabases) > 0 {
 					selected := m.filteredDatabases[m.cursor]
@@ -228,29 +367,76 @@ func (m SidebarModel) Update(msg tea.Msg) (SidebarModel, tea.Cmd) {
 					}
 				}
 			} else {
-				if len(m.filteredTables) > 0 {
-					m.selected = m.filteredTables[m.cursor]
+				if table := m.cursorTable(); table != "" {
+					m.selected = table
 					return m, func() tea.Msg {
 						return TableSelectedMsg{Name: m.selected}
 					}
 				}
 			}
-		case "c":
+		case KeyMatches(msg, ActionCopyDatabase):
 			if m.mode == SidebarDatabases && len(m.filteredDatabases) > 0 {
 				m.copying = true
 				m.copySource = m.filteredDatabases[m.cursor]
 				m.copyInput = m.copySource + "_copy"
 			}
-		case "x":
+		case KeyMatches(msg, ActionImportCSV):
+			if table := m.cursorTable(); m.showsTables() && table != "" {
+				m.pathPrompt = ActionImportCSV
+				m.pathTable = table
+				m.pathInput = ""
+			}
+		case KeyMatches(msg, ActionExportTable):
+			if table := m.cursorTable(); m.showsTables() && table != "" {
+				m.pathPrompt = ActionExportTable
+				m.pathTable = table
+				m.pathInput = m.pathTable + ".csv"
+			}
+		case KeyMatches(msg, ActionDescribeTable):
+			if table := m.cursorTable(); m.showsTables() && table != "" {
+				return m, func() tea.Msg { return DescribeTableMsg{Table: table} }
+			}
+		case KeyMatches(msg, ActionCountRows):
+			if table := m.cursorTable(); m.showsTables() && table != "" {
+				return m, func() tea.Msg { return CountRowsMsg{Table: table} }
+			}
+		case KeyMatches(msg, ActionProfileTable):
+			if table := m.cursorTable(); m.showsTables() && table != "" {
+				return m, func() tea.Msg { return ProfileTableMsg{Table: table} }
+			}
+		case KeyMatches(msg, ActionSelectTemplate):
+			if table := m.cursorTable(); m.showsTables() && table != "" {
+				return m, func() tea.Msg { return SelectTemplateMsg{Table: table} }
+			}
+		case KeyMatches(msg, ActionAlterTable):
+			if table := m.cursorTable(); m.showsTables() && table != "" {
+				return m, func() tea.Msg { return AlterTableMsg{Table: table} }
+			}
+		case KeyMatches(msg, ActionToggleFavorite):
+			if m.showsTables() && m.cursorTable() != "" {
+				return m, m.toggleFavorite()
+			}
+		case KeyMatches(msg, ActionShowFavorites):
+			m.cursor = 0
+			m.scrollOffset = 0
+			m.searching = false
+			m.clearSearch()
+			if m.mode == SidebarFavorites {
+				m.mode = SidebarTables
+			} else {
+				m.mode = SidebarFavorites
+			}
+			m.applyFilter()
+		case KeyMatches(msg, ActionDropDatabase):
 			if m.mode == SidebarDatabases && len(m.filteredDatabases) > 0 {
 				m.confirmDelete = true
 				m.deleteTarget = m.filteredDatabases[m.cursor]
 			}
-		case "D":
+		case KeyMatches(msg, ActionToggleDatabases):
 			m.cursor = 0
 			m.scrollOffset = 0
 			m.searching = false
-			m.searchQuery = ""
+			m.clearSearch()
 			if m.mode == SidebarDatabases {
 				m.mode = SidebarTables
 				m.applyFilter()
@@ -258,9 +444,68 @@ func (m SidebarModel) Update(msg tea.Msg) (SidebarModel, tea.Cmd) {
 				m.mode = SidebarDatabases
 				m.applyFilter()
 			}
-		case "/":
+		case KeyMatches(msg, ActionSearch):
+			// Start from the filter in force, or else the last one.
 			m.searching = true
-			m.searchQuery = ""
+			if m.searchQuery == "" {
+				m.searchQuery = m.lastSearch[m.mode]
+				m.applyFilter()
+			}
+		case KeyMatches(msg, ActionLastFilter):
+			if m.searchQuery != "" {
+				m.clearSearch()
+			} else {
+				m.searchQuery = m.lastSearch[m.mode]
+			}
+			m.applyFilter()
+		}
+	case tea.MouseMsg:
+		return m.updateMouse(msg)
+	}
+	return m, nil
+}
+
+// updateMouse handles a click or wheel event. X and Y are relative to the
+// pane's top-left corner, border included. Clicking a table opens it;
+// clicking a database only moves the cursor, since switching is disruptive.
+func (m SidebarModel) updateMouse(msg tea.MouseMsg) (SidebarModel, tea.Cmd) {
+	if m.IsSearching() {
+		return m, nil
+	}
+	listLen := m.listLen()
+	if listLen == 0 {
+		return m, nil
+	}
+
+	switch msg.Button {
+	case tea.MouseButtonWheelUp, tea.MouseButtonWheelDown:
+		if msg.Button == tea.MouseButtonWheelUp {
+			m.moveCursor(-3)
+		} else {
+			m.moveCursor(3)
+		}
+	case tea.MouseButtonLeft:
+		if msg.Action != tea.MouseActionPress {
+			break
+		}
+		// Border, then the header and the schema or search line.
+		idx := m.scrollOffset + msg.Y - 3
+		if msg.Y < 3 || msg.Y-3 >= m.visibleItems() || idx >= listLen {
+			break
+		}
+		if m.grouping() && m.rows[idx].table == "" {
+			// A click on a header folds or opens its group.
+			group := m.rows[idx].group
+			m.setCollapsed(group, !m.groupCollapsed(group))
+			break
+		}
+		m.cursor = idx
+		m.ensureVisible()
+		if m.showsTables() {
+			m.selected = m.cursorTable()
+			return m, func() tea.Msg {
+				return TableSelectedMsg{Name: m.selected}
+			}
 		}
 	}
 	return m, nil
@@ -300,6 +545,45 @@ func (m SidebarModel) updateCopyMode(msg tea.KeyMsg) (SidebarModel, tea.Cmd) {
 	return m, nil
 }
 
+// updatePathPrompt takes the file name for an import into or export of
+// pathTable.
+func (m SidebarModel) updatePathPrompt(msg tea.KeyMsg) (SidebarModel, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.pathPrompt = ""
+		m.pathTable = ""
+		m.pathInput = ""
+	case "enter":
+		path := strings.TrimSpace(m.pathInput)
+		if path == "" {
+			return m, nil
+		}
+		action, table := m.pathPrompt, m.pathTable
+		m.pathPrompt = ""
+		m.pathTable = ""
+		m.pathInput = ""
+		return m, func() tea.Msg {
+			if action == ActionExportTable {
+				return ExportTableMsg{Table: table, Path: path}
+			}
+			return ImportCSVMsg{Table: table, Path: path}
+		}
+	case "backspace":
+		if len(m.pathInput) > 0 {
+			m.pathInput = m.pathInput[:len(m.pathInput)-1]
+		}
+	case "ctrl+u":
+		m.pathInput = ""
+	default:
+		if len(msg.String()) == 1 || msg.Type == tea.KeySpace {
+			m.pathInput += msg.String()
+		} else if msg.Type == tea.KeyRunes {
+			m.pathInput += string(msg.Runes)
+		}
+	}
+	return m, nil
+}
+
 func (m SidebarModel) updateDeleteConfirm(msg tea.KeyMsg) (SidebarModel, tea.Cmd) {
 	switch msg.String() {
 	case "y", "Y":
@@ -317,15 +601,21 @@ func (m SidebarModel) updateDeleteConfirm(msg tea.KeyMsg) (SidebarModel, tea.Cmd
 }
 
 func (m SidebarModel) updateSearchMode(msg tea.KeyMsg) (SidebarModel, tea.Cmd) {
+	if mode, ok := m.searchMode.toggle(msg); ok {
+		m.searchMode = mode
+		m.applyFilter()
+		return m, nil
+	}
 	switch msg.String() {
 	case "esc":
 		m.searching = false
-		m.searchQuery = ""
+		m.clearSearch()
 		m.applyFilter()
 	case "enter":
 		m.searching = false
-		if len(m.filteredTables) > 0 {
-			m.selected = m.filteredTables[m.cursor]
+		m.rememberSearch()
+		if table := m.cursorTable(); table != "" {
+			m.selected = table
 			return m, func() tea.Msg {
 				return TableSelectedMsg{Name: m.selected}
 			}
@@ -335,16 +625,13 @@ func (m SidebarModel) updateSearchMode(msg tea.KeyMsg) (SidebarModel, tea.Cmd) {
 			m.searchQuery = m.searchQuery[:len(m.searchQuery)-1]
 			m.applyFilter()
 		}
+	case "ctrl+u":
+		m.searchQuery = ""
+		m.applyFilter()
 	case "up":
-		if m.cursor > 0 {
-			m.cursor--
-			m.ensureVisible()
-		}
+		m.moveCursor(-1)
 	case "down":
-		if m.cursor < len(m.filteredTables)-1 {
-			m.cursor++
-			m.ensureVisible()
-		}
+		m.moveCursor(1)
 	default:
 		if len(msg.String()) == 1 || msg.Type == tea.KeySpace {
 			m.searchQuery += msg.String()
@@ -357,6 +644,24 @@ func (m SidebarModel) updateSearchMode(msg tea.KeyMsg) (SidebarModel, tea.Cmd) {
 	return m, nil
 }
 
+// rememberSearch keeps the filter in force as the one to bring back later.
+func (m *SidebarModel) rememberSearch() {
+	if m.searchQuery == "" {
+		return
+	}
+	if m.lastSearch == nil {
+		m.lastSearch = map[SidebarMode]string{}
+	}
+	m.lastSearch[m.mode] = m.searchQuery
+}
+
+// clearSearch drops the filter, remembering it first. The caller reapplies
+// the filter.
+func (m *SidebarModel) clearSearch() {
+	m.rememberSearch()
+	m.searchQuery = ""
+}
+
 func truncateDisplay(s string, maxWidth int) string {
 	if lipgloss.Width(s) <= maxWidth {
 		return s
@@ -418,11 +723,8 @@ func (m SidebarModel) View() string {
 			b.WriteString("\n")
 			linesUsed++
 		} else if m.searching || m.searchQuery != "" {
-			searchDisp := SearchLabel.Render("/") + SearchInput.Render(m.searchQuery)
-			if m.searching {
-				searchDisp += SearchInput.Render("█")
-			}
-			b.WriteString(searchDisp)
+			searchDisp := searchPrompt(m.searchQuery, m.searching, false, m.searchMode)
+			b.WriteString(lipgloss.NewStyle().MaxWidth(innerW).Render(searchDisp))
 			b.WriteString("\n")
 			linesUsed++
 		} else {
@@ -478,16 +780,35 @@ func (m SidebarModel) View() string {
 	} else {
 		// Header
 		header := HeaderStyle.Render("Tables")
+		if m.mode == SidebarFavorites {
+			header = HeaderStyle.Render("Favorites")
+		}
 		b.WriteString(header)
 		b.WriteString("\n")
 		linesUsed++
 
-		if m.searching || m.searchQuery != "" {
-			searchDisp := SearchLabel.Render("/") + SearchInput.Render(m.searchQuery)
-			if m.searching {
-				searchDisp += SearchInput.Render("█")
+		if m.pathPrompt != "" {
+			prompt, confirm := fmt.Sprintf("  Load CSV into %s from:", m.pathTable), "load"
+			if m.pathPrompt == ActionExportTable {
+				prompt, confirm = fmt.Sprintf("  Export all of %s to (.csv/.json):", m.pathTable), "export"
 			}
-			b.WriteString(searchDisp)
+			b.WriteString(AccentText.Render(truncateDisplay(prompt, innerW)))
+			b.WriteString("\n")
+			linesUsed++
+			// Show the end of a long path, where the file name is.
+			input := []rune(m.pathInput)
+			if over := len(input) - (innerW - 3); over > 0 {
+				input = input[over:]
+			}
+			b.WriteString("  " + SearchInput.Render(string(input)) + SearchInput.Render("█"))
+			b.WriteString("\n")
+			linesUsed++
+			b.WriteString(DimText.Render("  Enter " + confirm + " | Esc cancel"))
+			b.WriteString("\n")
+			linesUsed++
+		} else if m.searching || m.searchQuery != "" {
+			searchDisp := searchPrompt(m.searchQuery, m.searching, false, m.searchMode)
+			b.WriteString(lipgloss.NewStyle().MaxWidth(innerW).Render(searchDisp))
 			b.WriteString("\n")
 			linesUsed++
 		} else {
@@ -495,24 +816,40 @@ func (m SidebarModel) View() string {
 			if dbName == "" {
 				dbName = "public"
 			}
-			schema := SubHeaderStyle.Render(fmt.Sprintf("  %s | D databases", dbName))
+			other := "F favorites"
+			if m.mode == SidebarFavorites {
+				other = "F all tables"
+			}
+			line := fmt.Sprintf("  %s | D databases | %s", dbName, other)
+			if last := m.lastSearch[m.mode]; last != "" {
+				line += fmt.Sprintf(" | %s /%s", KeyLabel(ActionLastFilter), last)
+			}
+			schema := SubHeaderStyle.Render(truncateDisplay(line, innerW))
 			b.WriteString(schema)
 			b.WriteString("\n")
 			linesUsed++
 		}
 
 		tables := m.filteredTables
+		lines := m.listLen()
+		nested := m.grouping() && len(m.rows) > 0 && m.rows[0].table == ""
+		if len(m.comments) > 0 {
+			innerH-- // keep the last line for the comment
+		}
 
 		if len(tables) == 0 {
-			if m.searchQuery != "" {
+			switch {
+			case m.searchQuery != "":
 				b.WriteString(DimText.Render("  No matches"))
-			} else {
+			case m.mode == SidebarFavorites:
+				b.WriteString(DimText.Render(fmt.Sprintf("  No favorites (%s to star a table)", KeyLabel(ActionToggleFavorite))))
+			default:
 				b.WriteString(DimText.Render("  No tables found"))
 			}
 			linesUsed++
 		} else {
 			availLines := innerH - linesUsed
-			if len(tables) > availLines {
+			if lines > availLines {
 				availLines--
 			}
 			if availLines < 1 {
@@ -520,15 +857,34 @@ func (m SidebarModel) View() string {
 			}
 			startIdx := m.scrollOffset
 			endIdx := startIdx + availLines
-			if endIdx > len(tables) {
-				endIdx = len(tables)
+			if endIdx > lines {
+				endIdx = lines
 			}
 			for i := startIdx; i < endIdx; i++ {
-				t := tables[i]
-				label := truncateDisplay(fmt.Sprintf("T %s", t), innerW-1)
+				var t, label string
+				if m.grouping() {
+					t = m.rows[i].table
+				} else {
+					t = tables[i]
+				}
+				if t == "" {
+					label = m.groupHeader(m.rows[i])
+				} else {
+					icon := "T"
+					if m.favorites[t] {
+						icon = "★"
+					}
+					label = fmt.Sprintf("%s %s", icon, t)
+					if nested {
+						label = "  " + label
+					}
+				}
+				label = truncateDisplay(label, innerW-1)
 				var line string
 				if i == m.cursor && m.focused {
 					line = SidebarCursorItem.Width(innerW).MaxHeight(1).Render(label)
+				} else if t == "" {
+					line = SidebarTableItem.Foreground(ColorDim).Bold(true).Width(innerW).MaxHeight(1).Render(label)
 				} else if t == m.selected {
 					line = SidebarActiveItem.Width(innerW).MaxHeight(1).Render(label)
 				} else {
@@ -540,12 +896,22 @@ func (m SidebarModel) View() string {
 				}
 				linesUsed++
 			}
-			if len(tables) > availLines {
+			if lines > availLines {
 				b.WriteString("\n")
-				b.WriteString(DimText.Render(fmt.Sprintf(" [%d-%d of %d]", startIdx+1, endIdx, len(tables))))
+				b.WriteString(DimText.Render(fmt.Sprintf(" [%d-%d of %d]", startIdx+1, endIdx, lines)))
 				linesUsed++
 			}
 		}
+		if len(m.comments) > 0 {
+			for linesUsed < innerH {
+				b.WriteString("\n")
+				linesUsed++
+			}
+			comment := m.comments[m.cursorTable()]
+			b.WriteString("\n" + DimText.Render(truncateDisplay(" "+sanitizeCell(comment), innerW)))
+			innerH++
+			linesUsed++
+		}
 	}
 
 	// Pad remaining lines
@@ -0,0 +1,254 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultGroupDelimiter splits a table name into its group prefix and the
+// rest when no other delimiter is configured.
+const DefaultGroupDelimiter = "_"
+
+// sidebarRow is one line of the grouped table list: the header of group
+// when table is "", otherwise one of its tables.
+type sidebarRow struct {
+	group string
+	table string
+	count int // tables in the group, on a header
+}
+
+// tableGroup returns the group of table: its schema when it is qualified,
+// else the part before the first delimiter, or "" when it has neither.
+func tableGroup(table, delimiter string) string {
+	if i := strings.IndexByte(table, '.'); i > 0 {
+		return table[:i]
+	}
+	if delimiter == "" {
+		return ""
+	}
+	if i := strings.Index(table, delimiter); i > 0 {
+		return table[:i]
+	}
+	return ""
+}
+
+// SetGroupDelimiter sets what separates a table's group prefix from the
+// rest of its name; empty means DefaultGroupDelimiter.
+func (m *SidebarModel) SetGroupDelimiter(delimiter string) {
+	if delimiter == "" {
+		delimiter = DefaultGroupDelimiter
+	}
+	m.groupDelimiter = delimiter
+	m.applyFilter()
+}
+
+// grouping reports whether tables are shown under group headers.
+func (m SidebarModel) grouping() bool {
+	return m.grouped && m.showsTables()
+}
+
+// groupCollapsed reports whether group's tables are hidden. A search shows
+// every group open so no match is out of sight.
+func (m SidebarModel) groupCollapsed(group string) bool {
+	return m.collapsed[group] && m.searchQuery == ""
+}
+
+// buildRows lays out filteredTables under their group headers, groups in
+// name order with the tables that share no prefix last. A prefix only one
+// table has makes no group.
+func (m *SidebarModel) buildRows() {
+	m.rows = nil
+	if !m.grouping() {
+		return
+	}
+	sizes := map[string]int{}
+	for _, t := range m.tables {
+		sizes[tableGroup(t, m.groupDelimiter)]++
+	}
+	members := map[string][]string{}
+	for _, t := range m.filteredTables {
+		g := tableGroup(t, m.groupDelimiter)
+		if sizes[g] < 2 {
+			g = ""
+		}
+		members[g] = append(members[g], t)
+	}
+	groups := make([]string, 0, len(members))
+	for g := range members {
+		if g != "" {
+			groups = append(groups, g)
+		}
+	}
+	sort.Strings(groups)
+	if len(members[""]) > 0 {
+		groups = append(groups, "")
+	}
+
+	for _, g := range groups {
+		if g == "" && len(groups) == 1 {
+			// Nothing shares a prefix: a plain list.
+			for _, t := range members[g] {
+				m.rows = append(m.rows, sidebarRow{table: t})
+			}
+			break
+		}
+		m.rows = append(m.rows, sidebarRow{group: g, count: len(members[g])})
+		if m.groupCollapsed(g) {
+			continue
+		}
+		for _, t := range members[g] {
+			m.rows = append(m.rows, sidebarRow{group: g, table: t})
+		}
+	}
+}
+
+// groupHeader renders the header line of a group, as "▾ billing (12)".
+func (m SidebarModel) groupHeader(r sidebarRow) string {
+	fold := "▾"
+	if m.groupCollapsed(r.group) {
+		fold = "▸"
+	}
+	name := r.group
+	if name == "" {
+		name = "other"
+	}
+	return fmt.Sprintf("%s %s (%d)", fold, name, r.count)
+}
+
+// listLen is how many lines the list has.
+func (m SidebarModel) listLen() int {
+	switch {
+	case m.mode == SidebarDatabases:
+		return len(m.filteredDatabases)
+	case m.grouping():
+		return len(m.rows)
+	}
+	return len(m.filteredTables)
+}
+
+// cursorTable returns the table under the cursor, or "" on a group header
+// or in an empty list.
+func (m SidebarModel) cursorTable() string {
+	if m.grouping() {
+		if m.cursor < len(m.rows) {
+			return m.rows[m.cursor].table
+		}
+		return ""
+	}
+	if m.cursor < len(m.filteredTables) {
+		return m.filteredTables[m.cursor]
+	}
+	return ""
+}
+
+// selectable reports whether the cursor may rest on line i: any table, or
+// the header of a collapsed group, which stands in for its hidden tables.
+func (m SidebarModel) selectable(i int) bool {
+	if !m.grouping() {
+		return true
+	}
+	r := m.rows[i]
+	return r.table != "" || m.groupCollapsed(r.group)
+}
+
+// moveCursor moves the cursor delta lines, on past the headers of open
+// groups, and back if there is nothing to land on that way.
+func (m *SidebarModel) moveCursor(delta int) {
+	n := m.listLen()
+	if n == 0 {
+		return
+	}
+	target := min(max(m.cursor+delta, 0), n-1)
+	step := 1
+	if delta < 0 {
+		step = -1
+	}
+	for _, dir := range []int{step, -step} {
+		for i := target; i >= 0 && i < n; i += dir {
+			if m.selectable(i) {
+				m.cursor = i
+				m.ensureVisible()
+				return
+			}
+		}
+	}
+}
+
+// settleCursor keeps the cursor inside the list and off open headers.
+func (m *SidebarModel) settleCursor() {
+	n := m.listLen()
+	m.cursor = min(m.cursor, max(0, n-1))
+	if n > 0 && !m.selectable(m.cursor) {
+		m.moveCursor(1)
+	}
+	m.ensureVisible()
+}
+
+// cursorTo moves the cursor onto table, reporting whether it is listed.
+func (m *SidebarModel) cursorTo(table string) bool {
+	if m.grouping() {
+		for i, r := range m.rows {
+			if r.table == table {
+				m.cursor = i
+				m.ensureVisible()
+				return true
+			}
+		}
+		return false
+	}
+	for i, t := range m.filteredTables {
+		if t == table {
+			m.cursor = i
+			m.ensureVisible()
+			return true
+		}
+	}
+	return false
+}
+
+// cursorGroup returns the group of the line under the cursor, if the list
+// has any.
+func (m SidebarModel) cursorGroup() (string, bool) {
+	if !m.grouping() || m.cursor >= len(m.rows) || m.rows[0].table != "" {
+		return "", false
+	}
+	return m.rows[m.cursor].group, true
+}
+
+// setCollapsed folds or unfolds group, leaving the cursor on its header
+// when folded and on its first table when opened.
+func (m *SidebarModel) setCollapsed(group string, collapsed bool) {
+	if m.collapsed == nil {
+		m.collapsed = map[string]bool{}
+	}
+	m.collapsed[group] = collapsed
+	m.buildRows()
+	for i, r := range m.rows {
+		if r.group == group && r.table == "" {
+			m.cursor = i
+			break
+		}
+	}
+	m.settleCursor()
+}
+
+// toggleAllGroups folds every group, or opens them all if they already are.
+func (m *SidebarModel) toggleAllGroups() {
+	group, _ := m.cursorGroup()
+	fold := false
+	for _, r := range m.rows {
+		if r.table == "" && !m.collapsed[r.group] {
+			fold = true
+		}
+	}
+	for _, r := range m.rows {
+		if r.table == "" {
+			if m.collapsed == nil {
+				m.collapsed = map[string]bool{}
+			}
+			m.collapsed[r.group] = fold
+		}
+	}
+	m.setCollapsed(group, fold)
+}
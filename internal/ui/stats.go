@@ -0,0 +1,90 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"cli-sql/internal/editor"
+)
+
+// numericTypes are the column types columnStats sums and averages.
+var numericTypes = map[string]bool{
+	"int2": true, "int4": true, "int8": true,
+	"float4": true, "float8": true, "numeric": true,
+}
+
+// columnStats summarises column col over the rows held in the model, as
+// displayed (pending edits included). Numeric columns get min, max, sum and
+// mean; others the distinct count and most common value.
+func (m ResultsModel) columnStats(col int) string {
+	total, nonNull := len(m.rows), 0
+	numeric := col < len(m.columnTypes) && numericTypes[m.columnTypes[col]]
+
+	var sum, lo, hi float64
+	nums := 0
+	counts := make(map[string]int)
+	for ri := range m.rows {
+		val := m.displayValue(ri, col)
+		if val == editor.NullValue || val == editor.DefaultValue {
+			continue
+		}
+		nonNull++
+		if !numeric {
+			counts[val]++
+			continue
+		}
+		f, ok := numericValue(val)
+		if !ok {
+			// An edit that isn't a number yet; leave it out of the maths.
+			continue
+		}
+		if nums == 0 {
+			lo, hi = f, f
+		}
+		lo, hi = math.Min(lo, f), math.Max(hi, f)
+		sum += f
+		nums++
+	}
+
+	parts := []string{
+		fmt.Sprintf("%s over %d loaded rows", m.columns[col], total),
+		fmt.Sprintf("non-null %d", nonNull),
+	}
+	switch {
+	case m.isMaskedColumn(col):
+		parts = append(parts, "values masked")
+	case numeric && nums > 0:
+		parts = append(parts,
+			"min "+formatStat(lo),
+			"max "+formatStat(hi),
+			"sum "+formatStat(sum),
+			"mean "+formatStat(sum/float64(nums)))
+	case !numeric && nonNull > 0:
+		common, n := "", 0
+		for v, c := range counts {
+			if c > n || (c == n && v < common) {
+				common, n = v, c
+			}
+		}
+		parts = append(parts,
+			fmt.Sprintf("distinct %d", len(counts)),
+			fmt.Sprintf("most common %q ×%d", truncateDisplay(sanitizeCell(common), 30), n))
+	}
+	return strings.Join(parts, " | ")
+}
+
+// numericValue parses val, the displayed value of a cell.
+func numericValue(val string) (float64, bool) {
+	f, err := strconv.ParseFloat(val, 64)
+	return f, err == nil
+}
+
+// formatStat prints whole numbers in full and others to six significant digits.
+func formatStat(f float64) string {
+	if f == math.Trunc(f) && math.Abs(f) < 1e15 {
+		return strconv.FormatFloat(f, 'f', 0, 64)
+	}
+	return strconv.FormatFloat(f, 'g', 6, 64)
+}
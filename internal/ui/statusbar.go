@@ -30,9 +30,13 @@ type StatusBarModel struct {
 	searchMode     bool
 	queryTime      time.Duration
 	rowCount       int
+	earlierTimes   []time.Duration // of the queries before the last, oldest first
+	slowQuery      time.Duration   // a query taking longer is flagged; 0 never flags
+	notices        int
 	width          int
 	copyingDB      bool
 	copyingDBLabel string
+	countingTable  string // table whose rows are being counted, or ""
 	spinnerFrame   int
 }
 
@@ -53,6 +57,11 @@ func (m *StatusBarModel) SetMessage(msg string, t MessageType) {
 	m.messageTime = time.Now()
 }
 
+// Message returns the status message and its type.
+func (m StatusBarModel) Message() (string, MessageType) {
+	return m.message, m.messageType
+}
+
 // SetPendingChanges updates the pending changes count.
 func (m *StatusBarModel) SetPendingChanges(count int) {
 	m.pendingChanges = count
@@ -73,12 +82,43 @@ func (m *StatusBarModel) SetSearchMode(searching bool) {
 	m.searchMode = searching
 }
 
-// SetQueryInfo updates the last query stats.
+// queryTimeHistory is how many earlier query times the status bar keeps
+// showing beside the last one.
+const queryTimeHistory = 3
+
+// SetQueryInfo updates the last query stats. The time it replaces joins the
+// earlier ones.
 func (m *StatusBarModel) SetQueryInfo(elapsed time.Duration, rowCount int) {
+	if m.queryTime > 0 {
+		m.earlierTimes = append(m.earlierTimes, m.queryTime)
+		if len(m.earlierTimes) > queryTimeHistory {
+			m.earlierTimes = m.earlierTimes[len(m.earlierTimes)-queryTimeHistory:]
+		}
+	}
 	m.queryTime = elapsed
 	m.rowCount = rowCount
 }
 
+// SetSlowQuery sets how long a query may take before its time is shown in
+// the error color; 0 never flags one.
+func (m *StatusBarModel) SetSlowQuery(d time.Duration) {
+	m.slowQuery = d
+}
+
+// queryTimeLabel renders d rounded to the millisecond, flagged if it is slow.
+func (m StatusBarModel) queryTimeLabel(d time.Duration) string {
+	label := d.Round(time.Millisecond).String()
+	if m.slowQuery > 0 && d > m.slowQuery {
+		return StatusErrorStyle.UnsetPadding().Render(label)
+	}
+	return label
+}
+
+// SetNotices sets how many server messages the last query produced.
+func (m *StatusBarModel) SetNotices(n int) {
+	m.notices = n
+}
+
 // SetCopyingDB sets or clears the background database copy indicator.
 func (m *StatusBarModel) SetCopyingDB(active bool, label string) {
 	m.copyingDB = active
@@ -86,6 +126,26 @@ func (m *StatusBarModel) SetCopyingDB(active bool, label string) {
 	m.spinnerFrame = 0
 }
 
+// SetCounting shows the spinner for counting the rows of table, or hides it
+// when table is "".
+func (m *StatusBarModel) SetCounting(table string) {
+	m.countingTable = table
+}
+
+// KeepProgress takes the copy and count indicators from other. They follow
+// work that belongs to the whole app rather than to one connection, so they
+// outlast a status bar put back after updating a background connection.
+func (m *StatusBarModel) KeepProgress(other StatusBarModel) {
+	m.copyingDB = other.copyingDB
+	m.copyingDBLabel = other.copyingDBLabel
+	m.countingTable = other.countingTable
+}
+
+// Spinning reports whether the spinner is shown and needs ticking.
+func (m StatusBarModel) Spinning() bool {
+	return m.copyingDB || m.countingTable != ""
+}
+
 // AdvanceSpinner moves to the next spinner frame.
 func (m *StatusBarModel) AdvanceSpinner() {
 	m.spinnerFrame = (m.spinnerFrame + 1) % len(spinnerFrames)
@@ -112,13 +172,32 @@ func (m StatusBarModel) View() string {
 	var rightParts []string
 	if m.copyingDB {
 		frame := spinnerFrames[m.spinnerFrame%len(spinnerFrames)]
-		rightParts = append(rightParts, fmt.Sprintf("%s Copying %s…", frame, m.copyingDBLabel))
+		rightParts = append(rightParts, fmt.Sprintf("%s Copying %s… (%s cancel)", frame, m.copyingDBLabel, KeyLabel(ActionCancel)))
+	}
+	if m.countingTable != "" {
+		frame := spinnerFrames[m.spinnerFrame%len(spinnerFrames)]
+		rightParts = append(rightParts, fmt.Sprintf("%s Counting %s… (%s cancel)", frame, m.countingTable, KeyLabel(ActionCancel)))
 	}
 	if m.pendingChanges > 0 {
 		rightParts = append(rightParts, fmt.Sprintf("Pending: %d | Ctrl+S commit | Ctrl+X clear", m.pendingChanges))
 	}
+	if m.notices > 0 {
+		label := "notices"
+		if m.notices == 1 {
+			label = "notice"
+		}
+		rightParts = append(rightParts, fmt.Sprintf("%d %s (%s)", m.notices, label, KeyLabel(ActionMessages)))
+	}
+	earlier := ""
 	if m.queryTime > 0 {
-		rightParts = append(rightParts, fmt.Sprintf("%d rows in %s", m.rowCount, m.queryTime.Round(time.Millisecond)))
+		rightParts = append(rightParts, fmt.Sprintf("%d rows in %s", m.rowCount, m.queryTimeLabel(m.queryTime)))
+		if len(m.earlierTimes) > 0 {
+			labels := make([]string, len(m.earlierTimes))
+			for i, d := range m.earlierTimes {
+				labels[len(labels)-1-i] = m.queryTimeLabel(d)
+			}
+			earlier = " (before: " + strings.Join(labels, ", ") + ")"
+		}
 	}
 	right := strings.Join(rightParts, " | ")
 
@@ -141,6 +220,10 @@ func (m StatusBarModel) View() string {
 	if w < 20 {
 		w = 20
 	}
+	// The earlier query times are the first thing to go when space is short.
+	if lipgloss.Width(hints)+lipgloss.Width(right+earlier)+3 <= w {
+		right += earlier
+	}
 	gap := w - lipgloss.Width(hints) - lipgloss.Width(right) - 2
 	if gap < 1 {
 		gap = 1
@@ -152,21 +235,64 @@ func (m StatusBarModel) View() string {
 
 func (m StatusBarModel) contextHints() string {
 	if m.editMode {
-		return "Type to edit | Tab/Enter Next col | Shift+Tab Prev col | Esc Cancel"
+		return joinHints("Type to edit", "Tab/Enter Next col", "Shift+Tab Prev col",
+			hint("NULL", ActionSetNull), hint("Default", ActionSetDefault), "Esc Cancel")
 	}
 
 	if m.searchMode {
-		return "Type to filter | Enter Confirm | Esc Cancel"
+		return joinHints("Type to filter", hint("Case", ActionSearchCase), hint("Regex", ActionSearchRegex),
+			"Enter Confirm", "Esc Cancel")
 	}
 
 	switch m.activePane {
 	case 0: // sidebar
-		return "j/k Navigate | Enter Select | / Search | D Databases | Tab Switch pane"
+		return joinHints(hint("Navigate", ActionDown, ActionUp), hint("Select", ActionSelect),
+			hint("Search", ActionSearch), hint("Databases", ActionToggleDatabases),
+			hint("Refresh", ActionRefreshTables), hint("Zoom", ActionZoom), hint("Help", ActionHelp))
 	case 1: // editor
-		return "Ctrl+J Line | Ctrl+E All | Ctrl+O Scripts | Tab Switch pane"
+		return joinHints(hint("Line", ActionExecuteStatement), hint("All", ActionExecuteAll),
+			hint("Format", ActionFormat), hint("Explain", ActionExplain), hint("Re-run", ActionRerunQuery), hint("Scripts", ActionScripts),
+			hint("Zoom", ActionZoom), editorHelpHint())
 	case 2: // results
-		return "hjkl Navigate | e Edit | d Delete | a Add | / Search | n/N Next/Prev match"
+		return joinHints(hint("Navigate", ActionLeft, ActionDown, ActionUp, ActionRight),
+			hint("Edit", ActionEditCell), hint("Delete", ActionDeleteRow), hint("Add", ActionAddRow),
+			hint("Follow FK", ActionFollowReference), hint("Referencing rows", ActionShowReferencing),
+			hint("Search", ActionSearch), hint("Refresh", ActionRefreshTable), hint("Help", ActionHelp))
 	default:
-		return "Tab Switch pane | Ctrl+C Quit"
+		return joinHints(hint("Switch pane", ActionNextPane), hint("Help", ActionHelp), hint("Quit", ActionQuit))
+	}
+}
+
+// hint renders "keys desc" from the first key bound to each action, joined
+// with "/", or "" if any of them is unbound.
+func hint(desc string, actions ...Action) string {
+	labels := make([]string, len(actions))
+	for i, a := range actions {
+		if labels[i] = KeyLabel(a); labels[i] == "" {
+			return ""
+		}
+	}
+	return strings.Join(labels, "/") + " " + desc
+}
+
+// editorHelpHint names a help key that works while typing in the editor,
+// where printable keys insert text.
+func editorHelpHint() string {
+	for _, k := range keymap[ActionHelp] {
+		if len([]rune(k)) > 1 {
+			return keyName(k) + " Help"
+		}
+	}
+	return ""
+}
+
+// joinHints joins the non-empty hints with " | ".
+func joinHints(hints ...string) string {
+	var parts []string
+	for _, h := range hints {
+		if h != "" {
+			parts = append(parts, h)
+		}
 	}
+	return strings.Join(parts, " | ")
 }
@@ -1,125 +1,227 @@
 package ui
 
 import (
-	"regexp"
-	"strings"
-
 	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
 )
 
-func FuzzyMatch(target, query string) bool {
-	re, err := regexp.Compile("(?i)" + query)
-	if err == nil {
-		return re.MatchString(target)
-	}
-	target = strings.ToLower(target)
-	query = strings.ToLower(query)
-	qi := 0
-	for i := 0; i < len(target) && qi < len(query); i++ {
-		if target[i] == query[qi] {
-			qi++
+// Color palette, set from the active theme by ApplyTheme.
+var (
+	ColorAccent    lipgloss.Color
+	ColorDanger    lipgloss.Color
+	ColorModified  lipgloss.Color
+	ColorDim       lipgloss.Color
+	ColorSuccess   lipgloss.Color
+	ColorError     lipgloss.Color
+	ColorNewRow    lipgloss.Color
+	ColorDeleteRow lipgloss.Color
+)
+
+// Styles, likewise rebuilt by ApplyTheme.
+var (
+	// Border styles
+	FocusedBorder   lipgloss.Style
+	UnfocusedBorder lipgloss.Style
+
+	// Text styles
+	AccentText   lipgloss.Style
+	DimText      lipgloss.Style
+	ErrorText    lipgloss.Style
+	SuccessText  lipgloss.Style
+	ModifiedText lipgloss.Style
+	DeletedText  lipgloss.Style
+	NewRowText   lipgloss.Style
+	NullText     lipgloss.Style
+	BannerText   lipgloss.Style
+
+	// Header styles
+	HeaderStyle    lipgloss.Style
+	SubHeaderStyle lipgloss.Style
+
+	// Table cell styles
+	CellNormal   lipgloss.Style
+	CellSelected lipgloss.Style
+	CellEditing  lipgloss.Style
+
+	// Status bar
+	StatusBarStyle     lipgloss.Style
+	StatusErrorStyle   lipgloss.Style
+	StatusSuccessStyle lipgloss.Style
+
+	// Sidebar styles
+	SidebarTableItem  lipgloss.Style
+	SidebarActiveItem lipgloss.Style
+	SidebarCursorItem lipgloss.Style
+
+	// Search styles
+	SearchInput lipgloss.Style
+	SearchLabel lipgloss.Style
+	SearchMatch lipgloss.Style
+
+	// Top bar style
+	TopBarStyle lipgloss.Style
+
+	// Connection indicator styles. They repeat the top bar background
+	// because they are rendered inside it.
+	TopBarText            lipgloss.Style
+	ConnectedIndicator    lipgloss.Style
+	DisconnectedIndicator lipgloss.Style
+	ReadOnlyBadge         lipgloss.Style
+)
+
+// ConnectionColor is a color a saved connection can be marked with, so that
+// production looks nothing like development.
+type ConnectionColor struct {
+	Name string
+	Bar  lipgloss.Color // top bar background and focused border
+	Text lipgloss.Color // top bar text, readable on Bar
+}
+
+// ConnectionColors are the colors on offer, in the order the connection form
+// cycles through them.
+var ConnectionColors = []ConnectionColor{
+	{"red", "#c0392b", "#ffffff"},
+	{"orange", "#e67e22", "#000000"},
+	{"yellow", "#f1c40f", "#000000"},
+	{"green", "#27ae60", "#000000"},
+	{"blue", "#2e86de", "#ffffff"},
+	{"purple", "#8e44ad", "#ffffff"},
+}
+
+// themeBarBg and themeBarFg are the theme's top bar colors, restored when
+// the shown connection has no color.
+var themeBarBg, themeBarFg lipgloss.Color
+
+// FindConnectionColor returns the connection color called name.
+func FindConnectionColor(name string) (ConnectionColor, bool) {
+	for _, c := range ConnectionColors {
+		if c.Name == name {
+			return c, true
 		}
 	}
-	return qi == len(query)
+	return ConnectionColor{}, false
 }
 
-// Color palette
-var (
-	ColorAccent    = lipgloss.Color("#4ecca3")
-	ColorDanger    = lipgloss.Color("#e94560")
-	ColorModified  = lipgloss.Color("#f0a500")
-	ColorDim       = lipgloss.Color("#555555")
-	ColorSuccess   = lipgloss.Color("#4ecca3")
-	ColorError     = lipgloss.Color("#e94560")
-	ColorNewRow    = lipgloss.Color("#4ecca3")
-	ColorDeleteRow = lipgloss.Color("#e94560")
-)
+// TintConnection colors the top bar and the focused border with the
+// connection color called name, or back to the theme's when there is no
+// such color. The connection indicators drop their own colors on a tinted
+// bar, where green or red could vanish into it.
+func TintConnection(name string) {
+	c, tinted := FindConnectionColor(name)
+	bg, fg, border := themeBarBg, themeBarFg, ColorAccent
+	if tinted {
+		bg, fg, border = c.Bar, c.Text, c.Bar
+	}
+	FocusedBorder = FocusedBorder.BorderForeground(border)
+	TopBarStyle = TopBarStyle.Background(bg).Foreground(fg)
+	TopBarText = lipgloss.NewStyle().Background(bg).Foreground(fg)
+	ConnectedIndicator = TopBarText.Foreground(ColorSuccess)
+	DisconnectedIndicator = TopBarText.Foreground(ColorError)
+	if tinted {
+		ConnectedIndicator = TopBarText.Bold(true)
+		DisconnectedIndicator = TopBarText.Bold(true).Underline(true)
+	}
+}
 
-// Border styles
-var (
-	FocusedBorder = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(ColorAccent)
+// ApplyTheme rebuilds every color and style from t. Call it before the
+// first render; views pick the styles up from the package variables.
+//
+// lipgloss drops all colors when NO_COLOR is set, so in that case the few
+// styles that are told apart only by color get a text attribute instead.
+func ApplyTheme(t Theme) {
+	noColor := lipgloss.ColorProfile() == termenv.Ascii
+
+	ColorAccent = lipgloss.Color(t.Accent)
+	ColorDanger = lipgloss.Color(t.Danger)
+	ColorModified = lipgloss.Color(t.Modified)
+	ColorDim = lipgloss.Color(t.Dim)
+	ColorSuccess = lipgloss.Color(t.Success)
+	ColorError = lipgloss.Color(t.Error)
+	ColorNewRow = lipgloss.Color(t.NewRow)
+	ColorDeleteRow = lipgloss.Color(t.DeletedRow)
+	barBg := lipgloss.Color(t.BarBackground)
+	barFg := lipgloss.Color(t.BarForeground)
+	themeBarBg, themeBarFg = barBg, barFg
 
+	FocusedBorder = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ColorAccent)
 	UnfocusedBorder = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(ColorDim)
-)
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ColorDim)
 
-// Text styles
-var (
 	AccentText = lipgloss.NewStyle().Foreground(ColorAccent)
-	DimText    = lipgloss.NewStyle().Foreground(ColorDim)
-	ErrorText  = lipgloss.NewStyle().Foreground(ColorError)
+	DimText = lipgloss.NewStyle().Foreground(ColorDim)
+	ErrorText = lipgloss.NewStyle().Foreground(ColorError)
 	SuccessText = lipgloss.NewStyle().Foreground(ColorSuccess)
 	ModifiedText = lipgloss.NewStyle().Foreground(ColorModified)
-	DeletedText  = lipgloss.NewStyle().Foreground(ColorDeleteRow).Faint(true)
-	NewRowText   = lipgloss.NewStyle().Foreground(ColorNewRow)
-	NullText     = lipgloss.NewStyle().Foreground(ColorDim).Italic(true)
-	BannerText   = lipgloss.NewStyle().Foreground(ColorSuccess).Bold(true)
-)
+	DeletedText = lipgloss.NewStyle().Foreground(ColorDeleteRow).Faint(true)
+	NewRowText = lipgloss.NewStyle().Foreground(ColorNewRow)
+	NullText = lipgloss.NewStyle().Foreground(ColorDim).Italic(true)
+	BannerText = lipgloss.NewStyle().Foreground(ColorSuccess).Bold(true)
 
-// Header styles
-var (
 	HeaderStyle = lipgloss.NewStyle().
-			Foreground(ColorAccent).
-			Bold(true)
-
+		Foreground(ColorAccent).
+		Bold(true)
 	SubHeaderStyle = lipgloss.NewStyle().
-			Foreground(ColorDim)
-)
+		Foreground(ColorDim)
 
-// Table cell styles
-var (
-	CellNormal   = lipgloss.NewStyle()
+	CellNormal = lipgloss.NewStyle()
 	CellSelected = lipgloss.NewStyle().Reverse(true)
-	CellEditing  = lipgloss.NewStyle().
-			Background(lipgloss.Color("#1a3a2a")).
-			Foreground(ColorAccent).
-			Bold(true)
-)
+	CellEditing = lipgloss.NewStyle().
+		Background(lipgloss.Color(t.EditingBackground)).
+		Foreground(ColorAccent).
+		Bold(true)
 
-// Status bar
-var (
 	StatusBarStyle = lipgloss.NewStyle().
-			Background(lipgloss.Color("#333333")).
-			Foreground(lipgloss.Color("#cccccc")).
-			Padding(0, 1)
-
+		Background(barBg).
+		Foreground(barFg).
+		Padding(0, 1)
 	StatusErrorStyle = lipgloss.NewStyle().
-				Background(lipgloss.Color("#333333")).
-				Foreground(ColorError).
-				Padding(0, 1)
-
+		Background(barBg).
+		Foreground(ColorError).
+		Padding(0, 1)
 	StatusSuccessStyle = lipgloss.NewStyle().
-				Background(lipgloss.Color("#333333")).
-				Foreground(ColorSuccess).
-				Padding(0, 1)
-)
+		Background(barBg).
+		Foreground(ColorSuccess).
+		Padding(0, 1)
 
-// Sidebar styles
-var (
 	SidebarTableItem = lipgloss.NewStyle().PaddingLeft(1)
 	SidebarActiveItem = lipgloss.NewStyle().
-				PaddingLeft(1).
-				Foreground(ColorAccent).
-				Bold(true)
+		PaddingLeft(1).
+		Foreground(ColorAccent).
+		Bold(true)
 	SidebarCursorItem = lipgloss.NewStyle().
-				PaddingLeft(1).
-				Reverse(true)
-)
+		PaddingLeft(1).
+		Reverse(true)
 
-// Search styles
-var (
 	SearchInput = lipgloss.NewStyle().
-			Foreground(ColorAccent).
-			Bold(true)
+		Foreground(ColorAccent).
+		Bold(true)
 	SearchLabel = lipgloss.NewStyle().
-			Foreground(ColorAccent)
-)
+		Foreground(ColorAccent)
+	SearchMatch = lipgloss.NewStyle().
+		Background(ColorAccent).
+		Foreground(lipgloss.Color(t.MatchForeground))
+
+	TopBarStyle = lipgloss.NewStyle().
+		Background(barBg).
+		Foreground(barFg).
+		Padding(0, 1)
+	TopBarText = lipgloss.NewStyle().Background(barBg).Foreground(barFg)
+	ConnectedIndicator = TopBarText.Foreground(ColorSuccess)
+	DisconnectedIndicator = TopBarText.Foreground(ColorError)
+	ReadOnlyBadge = lipgloss.NewStyle().Background(ColorModified).Foreground(barBg).Bold(true)
+
+	if noColor {
+		CellEditing = CellEditing.Underline(true)
+		ModifiedText = ModifiedText.Underline(true)
+		NewRowText = NewRowText.Italic(true)
+		SearchMatch = SearchMatch.Reverse(true)
+	}
 
-// Top bar style
-var TopBarStyle = lipgloss.NewStyle().
-	Background(lipgloss.Color("#333333")).
-	Foreground(lipgloss.Color("#cccccc")).
-	Padding(0, 1)
+	applyHighlightTheme(t)
+	GhostStyle = lipgloss.NewStyle().Foreground(ColorDim)
+	BracketMatch = lipgloss.NewStyle().Foreground(ColorAccent).Bold(true).Underline(true)
+	ErrorMark = lipgloss.NewStyle().Foreground(ColorError).Underline(true)
+}
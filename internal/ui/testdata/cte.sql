@@ -0,0 +1 @@
+with recent as (select * from orders where created_at > now() - interval '1 day'), big as (select * from recent where total > 100) select count(*) from big
@@ -0,0 +1 @@
+select * from (select id, name from users) s join (select user_id from orders group by user_id) o on o.user_id = s.id
@@ -0,0 +1 @@
+WITH t AS (SELECT Id FROM "Users" WHERE name = 'select from') Select * From t
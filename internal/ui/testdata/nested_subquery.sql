@@ -0,0 +1 @@
+select a, b from t where x = 1 and y in (select id from u where z > 2 and w in (select w from v)) order by a
@@ -0,0 +1,150 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Theme holds every color the UI uses, as hex strings. The JSON names are
+// the keys accepted in a user theme file.
+type Theme struct {
+	Accent            string `json:"accent"`
+	Danger            string `json:"danger"`
+	Modified          string `json:"modified"`
+	Dim               string `json:"dim"`
+	Success           string `json:"success"`
+	Error             string `json:"error"`
+	NewRow            string `json:"new_row"`
+	DeletedRow        string `json:"deleted_row"`
+	EditingBackground string `json:"editing_background"`
+	BarBackground     string `json:"bar_background"`
+	BarForeground     string `json:"bar_foreground"`
+	MatchForeground   string `json:"match_foreground"`
+
+	// SQL and JSON highlighting
+	Keyword  string `json:"keyword"`
+	Function string `json:"function"`
+	String   string `json:"string"`
+	Number   string `json:"number"`
+	Comment  string `json:"comment"`
+	Operator string `json:"operator"`
+}
+
+// Themes are the built-in presets, selectable by name in the config.
+var Themes = map[string]Theme{
+	"dark": {
+		Accent:            "#4ecca3",
+		Danger:            "#e94560",
+		Modified:          "#f0a500",
+		Dim:               "#555555",
+		Success:           "#4ecca3",
+		Error:             "#e94560",
+		NewRow:            "#4ecca3",
+		DeletedRow:        "#e94560",
+		EditingBackground: "#1a3a2a",
+		BarBackground:     "#333333",
+		BarForeground:     "#cccccc",
+		MatchForeground:   "#000000",
+		Keyword:           "#c678dd",
+		Function:          "#61afef",
+		String:            "#98c379",
+		Number:            "#d19a66",
+		Comment:           "#5c6370",
+		Operator:          "#56b6c2",
+	},
+	"light": {
+		Accent:            "#00875f",
+		Danger:            "#c0392b",
+		Modified:          "#b36b00",
+		Dim:               "#8a8a8a",
+		Success:           "#00875f",
+		Error:             "#c0392b",
+		NewRow:            "#00875f",
+		DeletedRow:        "#c0392b",
+		EditingBackground: "#d7f5e6",
+		BarBackground:     "#e4e4e4",
+		BarForeground:     "#333333",
+		MatchForeground:   "#ffffff",
+		Keyword:           "#a626a4",
+		Function:          "#4078f2",
+		String:            "#50a14f",
+		Number:            "#986801",
+		Comment:           "#a0a1a7",
+		Operator:          "#0184bc",
+	},
+	"solarized": {
+		Accent:            "#2aa198",
+		Danger:            "#dc322f",
+		Modified:          "#b58900",
+		Dim:               "#586e75",
+		Success:           "#859900",
+		Error:             "#dc322f",
+		NewRow:            "#859900",
+		DeletedRow:        "#dc322f",
+		EditingBackground: "#073642",
+		BarBackground:     "#073642",
+		BarForeground:     "#93a1a1",
+		MatchForeground:   "#002b36",
+		Keyword:           "#859900",
+		Function:          "#268bd2",
+		String:            "#2aa198",
+		Number:            "#d33682",
+		Comment:           "#586e75",
+		Operator:          "#cb4b16",
+	},
+}
+
+// DefaultTheme is the preset in effect until ApplyTheme is called, and the
+// fallback for an unknown name.
+const DefaultTheme = "dark"
+
+// AutoTheme is the config value that picks a preset from the terminal
+// background; an empty theme means the same.
+const AutoTheme = "auto"
+
+// DetectTheme returns "light" when the terminal reports a light background,
+// either in answer to an OSC 11 query or through COLORFGBG, and "dark"
+// otherwise. It must run before the TUI starts reading the terminal.
+func DetectTheme() string {
+	if lipgloss.HasDarkBackground() {
+		return "dark"
+	}
+	return "light"
+}
+
+// ThemeNames returns the names of the built-in presets, sorted.
+func ThemeNames() []string {
+	names := make([]string, 0, len(Themes))
+	for name := range Themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ResolveTheme returns the preset called name (detected from the terminal
+// when empty or "auto") with the colors in overrides, a JSON object using
+// Theme's keys, laid on top. overrides may be nil.
+func ResolveTheme(name string, overrides []byte) (Theme, error) {
+	if name == "" || name == AutoTheme {
+		name = DetectTheme()
+	}
+	t, ok := Themes[name]
+	if !ok {
+		return Themes[DefaultTheme], fmt.Errorf("unknown theme %q", name)
+	}
+	if len(overrides) > 0 {
+		// Unmarshalling into the preset only replaces the keys present.
+		if err := json.Unmarshal(overrides, &t); err != nil {
+			return Themes[name], fmt.Errorf("parse theme file: %w", err)
+		}
+	}
+	return t, nil
+}
+
+func init() {
+	ApplyTheme(Themes[DefaultTheme])
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
@@ -13,6 +14,7 @@ import (
 	"cli-sql/internal/app"
 	"cli-sql/internal/config"
 	"cli-sql/internal/db"
+	"cli-sql/internal/export"
 	"cli-sql/internal/ui"
 )
 
@@ -27,6 +29,8 @@ type pickerModel struct {
 	connecting bool
 	done       bool
 	newConn    bool
+	fromEnv    bool
+	color      string // of the connection opened
 	db         *db.DB
 	tables     []string
 	databases  []string
@@ -92,8 +96,17 @@ func (m pickerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, nil
 			}
 			m.connecting = true
+			m.fromEnv = false
 			m.err = ""
-			return m, m.connectSaved()
+			return m, connectSaved(m.cfg, m.cfg.Connections[m.cursor])
+		case "e":
+			if !config.HasEnvConnection() {
+				return m, nil
+			}
+			m.connecting = true
+			m.fromEnv = true
+			m.err = ""
+			return m, connectSaved(m.cfg, config.DefaultsFromEnv())
 		}
 
 	case connectResultMsg:
@@ -102,8 +115,11 @@ func (m pickerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.err = msg.err.Error()
 			return m, nil
 		}
-		m.cfg.TouchLastUsed(m.cursor)
-		m.cfg.Save()
+		if !m.fromEnv {
+			m.color = m.cfg.Connections[m.cursor].Color
+			m.cfg.TouchLastUsed(m.cursor)
+			m.cfg.Save()
+		}
 		m.done = true
 		m.db = msg.db
 		m.tables = msg.tables
@@ -127,6 +143,9 @@ func (m pickerModel) View() string {
 
 	for i, conn := range m.cfg.Connections {
 		display := conn.Name
+		if c, ok := ui.FindConnectionColor(conn.Color); ok {
+			display = lipgloss.NewStyle().Foreground(c.Bar).Render("■ ") + display
+		}
 		if conn.URI != "" {
 			display += ui.DimText.Render("  " + conn.URI)
 		} else {
@@ -150,6 +169,8 @@ func (m pickerModel) View() string {
 
 	if m.connecting {
 		b.WriteString(ui.DimText.Render("  Connecting..."))
+	} else if config.HasEnvConnection() {
+		b.WriteString(ui.DimText.Render("  Enter to connect | e connect from PG* environment | n new connection | d delete | Ctrl+C quit"))
 	} else {
 		b.WriteString(ui.DimText.Render("  Enter to connect | n new connection | d delete | Ctrl+C quit"))
 	}
@@ -158,33 +179,25 @@ func (m pickerModel) View() string {
 	return b.String()
 }
 
-func (m pickerModel) connectSaved() tea.Cmd {
-	conn := m.cfg.Connections[m.cursor]
+func connectSaved(cfg *config.Config, conn config.SavedConnection) tea.Cmd {
+	conn.ReadOnly = cfg.ReadOnlyFor(conn)
 	return func() tea.Msg {
-		var d *db.DB
-		var err error
-		if conn.URI != "" {
-			d, err = db.ConnectURI(conn.URI)
-		} else {
-			d, err = db.Connect(conn.Host, conn.Port, conn.User, conn.Password, conn.Database)
-		}
+		d, tables, databases, err := app.Connect(conn)
 		if err != nil {
 			return connectResultMsg{err: err}
 		}
-		tables, err := d.ListTables()
-		if err != nil {
-			d.Close()
-			return connectResultMsg{err: fmt.Errorf("failed to list tables: %w", err)}
-		}
-		databases, err := d.ListDatabases()
-		if err != nil {
-			d.Close()
-			return connectResultMsg{err: fmt.Errorf("failed to list databases: %w", err)}
-		}
 		return connectResultMsg{db: d, tables: tables, databases: databases}
 	}
 }
 
+// connOptions converts saved timeouts in seconds to db.Options.
+func connOptions(connectTimeout, statementTimeout int) db.Options {
+	return db.Options{
+		ConnectTimeout:   time.Duration(connectTimeout) * time.Second,
+		StatementTimeout: time.Duration(statementTimeout) * time.Second,
+	}
+}
+
 // ---------------------------------------------------------------------------
 // connectionModel – new connection form (URI or individual fields)
 // ---------------------------------------------------------------------------
@@ -209,7 +222,9 @@ const (
 type connectionModel struct {
 	inputs     []textinput.Model
 	uriInput   textinput.Model
+	options    []textinput.Model
 	nameInput  textinput.Model
+	color      int // into ui.ConnectionColors, -1 for none
 	mode       connMode
 	phase      connPhase
 	cursor     int
@@ -242,7 +257,18 @@ const (
 
 var fieldLabels = []string{"Host", "Port", "Username", "Password", "Database"}
 
+// Optional inputs, shown below the URI or the individual fields.
+const (
+	optConnectTimeout = iota
+	optStatementTimeout
+	optApplicationName
+	optSearchPath
+)
+
+var optionLabels = []string{"Connect timeout (seconds)", "Statement timeout (seconds)", "Application name", "Search path"}
+
 func newConnectionModel(cfg *config.Config) connectionModel {
+	env := config.DefaultsFromEnv()
 	inputs := make([]textinput.Model, 5)
 
 	for i := range inputs {
@@ -253,18 +279,21 @@ func newConnectionModel(cfg *config.Config) connectionModel {
 		switch i {
 		case fieldHost:
 			t.Placeholder = "localhost"
-			t.SetValue("localhost")
+			t.SetValue(env.Host)
 		case fieldPort:
 			t.Placeholder = "5432"
-			t.SetValue("5432")
+			t.SetValue(env.Port)
 		case fieldUser:
 			t.Placeholder = "postgres"
+			t.SetValue(env.User)
 		case fieldPassword:
 			t.Placeholder = ""
 			t.EchoMode = textinput.EchoPassword
 			t.EchoCharacter = '*'
+			t.SetValue(env.Password)
 		case fieldDatabase:
 			t.Placeholder = "mydb"
+			t.SetValue(env.Database)
 		}
 		inputs[i] = t
 	}
@@ -275,6 +304,28 @@ func newConnectionModel(cfg *config.Config) connectionModel {
 	uriInput.Width = 60
 	uriInput.Focus()
 
+	options := make([]textinput.Model, len(optionLabels))
+	for i := range options {
+		t := textinput.New()
+		t.CharLimit = 6
+		t.Width = 10
+		switch i {
+		case optConnectTimeout:
+			t.Placeholder = strconv.Itoa(int(db.DefaultConnectTimeout.Seconds()))
+		case optStatementTimeout:
+			t.Placeholder = strconv.Itoa(int(db.DefaultStatementTimeout.Seconds()))
+		case optApplicationName:
+			t.CharLimit = 64
+			t.Width = 40
+			t.Placeholder = db.DefaultApplicationName
+		case optSearchPath:
+			t.CharLimit = 256
+			t.Width = 40
+			t.Placeholder = "public"
+		}
+		options[i] = t
+	}
+
 	nameInput := textinput.New()
 	nameInput.Placeholder = "my-connection"
 	nameInput.CharLimit = 128
@@ -283,7 +334,9 @@ func newConnectionModel(cfg *config.Config) connectionModel {
 	return connectionModel{
 		inputs:    inputs,
 		uriInput:  uriInput,
+		options:   options,
 		nameInput: nameInput,
+		color:     -1,
 		mode:      modeURI,
 		phase:     phaseConnect,
 		cursor:    0,
@@ -295,6 +348,37 @@ func (m connectionModel) Init() tea.Cmd {
 	return textinput.Blink
 }
 
+// inputCount returns how many inputs the cursor can move between in the
+// current mode.
+func (m connectionModel) inputCount() int {
+	if m.mode == modeURI {
+		return 1 + len(m.options)
+	}
+	return len(m.inputs) + len(m.options)
+}
+
+// input returns the input at cursor position i in the current mode; the
+// optional inputs follow the URI or the individual fields.
+func (m *connectionModel) input(i int) *textinput.Model {
+	if m.mode == modeURI {
+		if i == 0 {
+			return &m.uriInput
+		}
+		return &m.options[i-1]
+	}
+	if i < len(m.inputs) {
+		return &m.inputs[i]
+	}
+	return &m.options[i-len(m.inputs)]
+}
+
+// moveCursor blurs the focused input and focuses the one at i.
+func (m *connectionModel) moveCursor(i int) {
+	m.input(m.cursor).Blur()
+	m.cursor = i
+	m.input(m.cursor).Focus()
+}
+
 func (m connectionModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
@@ -316,18 +400,14 @@ func (m connectionModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, nil
 			}
 			m.err = ""
+			m.input(m.cursor).Blur()
 			if m.mode == modeURI {
 				m.mode = modeFields
-				m.uriInput.Blur()
-				m.cursor = 0
-				m.inputs[0].Focus()
 			} else {
 				m.mode = modeURI
-				for i := range m.inputs {
-					m.inputs[i].Blur()
-				}
-				m.uriInput.Focus()
 			}
+			m.cursor = 0
+			m.input(0).Focus()
 			return m, textinput.Blink
 		case "enter":
 			if m.connecting {
@@ -337,41 +417,19 @@ func (m connectionModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, m.tryConnectURI()
 			}
 			// Fields mode
-			if m.cursor < len(m.inputs)-1 {
-				m.inputs[m.cursor].Blur()
-				m.cursor++
-				m.inputs[m.cursor].Focus()
+			if m.cursor < m.inputCount()-1 {
+				m.moveCursor(m.cursor + 1)
 				return m, textinput.Blink
 			}
 			return m, m.tryConnect()
-		case "shift+tab":
-			if m.mode == modeURI {
-				return m, nil
-			}
-			if m.cursor > 0 {
-				m.inputs[m.cursor].Blur()
-				m.cursor--
-				m.inputs[m.cursor].Focus()
-				return m, textinput.Blink
-			}
 		case "tab", "down":
-			if m.mode == modeURI {
-				return m, nil
-			}
-			if m.cursor < len(m.inputs)-1 {
-				m.inputs[m.cursor].Blur()
-				m.cursor++
-				m.inputs[m.cursor].Focus()
+			if m.cursor < m.inputCount()-1 {
+				m.moveCursor(m.cursor + 1)
 				return m, textinput.Blink
 			}
-		case "up":
-			if m.mode == modeURI {
-				return m, nil
-			}
+		case "shift+tab", "up":
 			if m.cursor > 0 {
-				m.inputs[m.cursor].Blur()
-				m.cursor--
-				m.inputs[m.cursor].Focus()
+				m.moveCursor(m.cursor - 1)
 				return m, textinput.Blink
 			}
 		}
@@ -386,24 +444,15 @@ func (m connectionModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.tables = msg.tables
 		m.databases = msg.databases
 		m.phase = phaseName
-		if m.mode == modeURI {
-			m.uriInput.Blur()
-		} else {
-			for i := range m.inputs {
-				m.inputs[i].Blur()
-			}
-		}
+		m.input(m.cursor).Blur()
 		m.nameInput.Focus()
 		return m, textinput.Blink
 	}
 
 	// Update the active input
 	var cmd tea.Cmd
-	if m.mode == modeURI {
-		m.uriInput, cmd = m.uriInput.Update(msg)
-	} else {
-		m.inputs[m.cursor], cmd = m.inputs[m.cursor].Update(msg)
-	}
+	input := m.input(m.cursor)
+	*input, cmd = input.Update(msg)
 	return m, cmd
 }
 
@@ -427,6 +476,12 @@ func (m connectionModel) updateNamePhase(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.savedConn.Password = m.inputs[fieldPassword].Value()
 			m.savedConn.Database = m.inputs[fieldDatabase].Value()
 		}
+		// Already validated by the successful connect.
+		m.savedConn.ConnectTimeout, _ = parseTimeout(m.options[optConnectTimeout].Value())
+		m.savedConn.StatementTimeout, _ = parseTimeout(m.options[optStatementTimeout].Value())
+		m.savedConn.ApplicationName = strings.TrimSpace(m.options[optApplicationName].Value())
+		m.savedConn.SearchPath = strings.TrimSpace(m.options[optSearchPath].Value())
+		m.savedConn.Color = m.colorName()
 		m.cfg.Add(m.savedConn)
 		m.cfg.Save()
 		m.done = true
@@ -434,6 +489,18 @@ func (m connectionModel) updateNamePhase(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "esc":
 		m.done = true
 		return m, tea.Quit
+	case "tab":
+		m.color++
+		if m.color == len(ui.ConnectionColors) {
+			m.color = -1
+		}
+		return m, nil
+	case "shift+tab":
+		m.color--
+		if m.color < -1 {
+			m.color = len(ui.ConnectionColors) - 1
+		}
+		return m, nil
 	}
 
 	var cmd tea.Cmd
@@ -441,6 +508,32 @@ func (m connectionModel) updateNamePhase(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+// colorName returns the connection color picked in the name phase, or "".
+func (m connectionModel) colorName() string {
+	if m.color < 0 {
+		return ""
+	}
+	return ui.ConnectionColors[m.color].Name
+}
+
+// colorChoices renders the connection colors with the picked one marked.
+func (m connectionModel) colorChoices() string {
+	none := ui.DimText.Render("none")
+	if m.color < 0 {
+		none = ui.AccentText.Bold(true).Render("[none]")
+	}
+	parts := []string{none}
+	for i, c := range ui.ConnectionColors {
+		style := lipgloss.NewStyle().Foreground(c.Bar)
+		if i == m.color {
+			parts = append(parts, style.Bold(true).Render("["+c.Name+"]"))
+		} else {
+			parts = append(parts, style.Render(c.Name))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
 func (m connectionModel) View() string {
 	titleStyle := lipgloss.NewStyle().
 		Foreground(ui.ColorAccent).
@@ -459,13 +552,17 @@ func (m connectionModel) View() string {
 		b.WriteString("\n")
 		b.WriteString("  " + m.nameInput.View())
 		b.WriteString("\n\n")
+		b.WriteString(ui.AccentText.Render("  Color"))
+		b.WriteString("\n")
+		b.WriteString("  " + m.colorChoices())
+		b.WriteString("\n\n")
 
 		if m.err != "" {
 			b.WriteString(ui.ErrorText.Render(fmt.Sprintf("  %s", m.err)))
 			b.WriteString("\n\n")
 		}
 
-		b.WriteString(ui.DimText.Render("  Enter to save | Esc to skip"))
+		b.WriteString(ui.DimText.Render("  Enter to save | Tab/Shift+Tab color (e.g. red for production) | Esc to skip"))
 		b.WriteString("\n")
 		return b.String()
 	}
@@ -485,23 +582,19 @@ func (m connectionModel) View() string {
 	b.WriteString(ui.DimText.Render("  Ctrl+U to switch mode"))
 	b.WriteString("\n\n")
 
-	if m.mode == modeURI {
-		b.WriteString(ui.AccentText.Render("  Connection URI"))
+	labels := append([]string{"Connection URI"}, optionLabels...)
+	if m.mode == modeFields {
+		labels = append(append([]string{}, fieldLabels...), optionLabels...)
+	}
+	for i, label := range labels {
+		if i == m.cursor {
+			b.WriteString(ui.AccentText.Render(fmt.Sprintf("  %s", label)))
+		} else {
+			b.WriteString(fmt.Sprintf("  %s", label))
+		}
 		b.WriteString("\n")
-		b.WriteString("  " + m.uriInput.View())
+		b.WriteString("  " + m.input(i).View())
 		b.WriteString("\n\n")
-	} else {
-		for i, input := range m.inputs {
-			label := fieldLabels[i]
-			if i == m.cursor {
-				b.WriteString(ui.AccentText.Render(fmt.Sprintf("  %s", label)))
-			} else {
-				b.WriteString(fmt.Sprintf("  %s", label))
-			}
-			b.WriteString("\n")
-			b.WriteString("  " + input.View())
-			b.WriteString("\n\n")
-		}
 	}
 
 	if m.err != "" {
@@ -512,7 +605,7 @@ func (m connectionModel) View() string {
 	if m.connecting {
 		b.WriteString(ui.DimText.Render("  Connecting..."))
 	} else if m.mode == modeURI {
-		b.WriteString(ui.DimText.Render("  Press Enter to connect | Ctrl+U for individual fields | Ctrl+C to quit"))
+		b.WriteString(ui.DimText.Render("  Press Enter to connect | Tab for options | Ctrl+U for individual fields | Ctrl+C to quit"))
 	} else {
 		b.WriteString(ui.DimText.Render("  Press Enter to connect | Tab between fields | Ctrl+U for URI mode | Ctrl+C to quit"))
 	}
@@ -521,6 +614,37 @@ func (m connectionModel) View() string {
 	return b.String()
 }
 
+// parseTimeout parses a timeout input in whole seconds; empty means the
+// default.
+func parseTimeout(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid timeout %q", s)
+	}
+	return n, nil
+}
+
+// connectOptions reads the optional inputs into db.Options.
+func (m connectionModel) connectOptions() (db.Options, error) {
+	connectTimeout, err := parseTimeout(m.options[optConnectTimeout].Value())
+	if err != nil {
+		return db.Options{}, fmt.Errorf("connect timeout: %w", err)
+	}
+	statementTimeout, err := parseTimeout(m.options[optStatementTimeout].Value())
+	if err != nil {
+		return db.Options{}, fmt.Errorf("statement timeout: %w", err)
+	}
+	opts := connOptions(connectTimeout, statementTimeout)
+	opts.ReadOnly = m.cfg.ReadOnlyFor(config.SavedConnection{})
+	opts.ApplicationName = strings.TrimSpace(m.options[optApplicationName].Value())
+	opts.SearchPath = strings.TrimSpace(m.options[optSearchPath].Value())
+	return opts, nil
+}
+
 func (m connectionModel) tryConnectURI() tea.Cmd {
 	uri := strings.TrimSpace(m.uriInput.Value())
 	if uri == "" {
@@ -528,9 +652,16 @@ func (m connectionModel) tryConnectURI() tea.Cmd {
 			return connectResultMsg{err: fmt.Errorf("URI cannot be empty")}
 		}
 	}
+	if err := db.ValidateURI(uri); err != nil {
+		return func() tea.Msg { return connectResultMsg{err: err} }
+	}
+	opts, optsErr := m.connectOptions()
 
 	return func() tea.Msg {
-		conn, err := db.ConnectURI(uri)
+		if optsErr != nil {
+			return connectResultMsg{err: optsErr}
+		}
+		conn, err := db.ConnectURI(uri, opts)
 		if err != nil {
 			return connectResultMsg{err: err}
 		}
@@ -560,6 +691,7 @@ func (m connectionModel) tryConnect() tea.Cmd {
 	user := m.inputs[fieldUser].Value()
 	password := m.inputs[fieldPassword].Value()
 	database := m.inputs[fieldDatabase].Value()
+	opts, optsErr := m.connectOptions()
 
 	// Defaults
 	if host == "" {
@@ -574,8 +706,11 @@ func (m connectionModel) tryConnect() tea.Cmd {
 		if _, err := strconv.Atoi(port); err != nil {
 			return connectResultMsg{err: fmt.Errorf("invalid port number")}
 		}
+		if optsErr != nil {
+			return connectResultMsg{err: optsErr}
+		}
 
-		conn, err := db.Connect(host, port, user, password, database)
+		conn, err := db.Connect(host, port, user, password, database, opts)
 		if err != nil {
 			return connectResultMsg{err: err}
 		}
@@ -600,14 +735,93 @@ func (m connectionModel) tryConnect() tea.Cmd {
 // main
 // ---------------------------------------------------------------------------
 
+// applyTheme activates the theme named in the config with any theme.json
+// overrides. A bad theme is reported and the default used instead.
+func applyTheme(cfg *config.Config) {
+	overrides, err := config.LoadThemeOverrides()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	theme, err := ui.ResolveTheme(cfg.Theme, overrides)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v (available: %s)\n", err, strings.Join(ui.ThemeNames(), ", "))
+	}
+	ui.ApplyTheme(theme)
+}
+
+// applyKeymap installs the bindings from keymap.json over the defaults. A bad
+// file is reported and the defaults kept.
+func applyKeymap() {
+	km, err := config.LoadKeymap()
+	if err == nil {
+		err = ui.SetKeymap(km)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
 func main() {
+	flags := parseFlags()
+	if done, err := runBundle(flags, os.Stdout); done {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
 	cfg, _ := config.Load()
+	cfg.ForceReadOnly = flags.readOnly
 
 	var database *db.DB
 	var tables []string
 	var databases []string
+	var color string
 
-	if len(cfg.Connections) > 0 {
+	if flags.direct() {
+		script, err := flags.script()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		format, err := export.ParseFormat(flags.format)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		d, err := connectDirect(cfg, flags)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Connection failed: %v\n", err)
+			os.Exit(1)
+		}
+		if flags.nonInteractive() {
+			err := runScript(d, script, format, os.Stdout, os.Stderr)
+			d.Close()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		if tables, err = d.ListTables(); err == nil {
+			databases, err = d.ListDatabases()
+		}
+		if err != nil {
+			d.Close()
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		database = d
+		if flags.name != "" {
+			color = cfg.ConnectionColor(flags.name)
+		}
+	}
+
+	// Only the TUI is styled; detecting the background queries the terminal.
+	applyTheme(cfg)
+	applyKeymap()
+
+	if database == nil && len(cfg.Connections) > 0 {
 		picker := newPickerModel(cfg)
 		p := tea.NewProgram(picker, tea.WithAltScreen())
 		result, err := p.Run()
@@ -629,6 +843,7 @@ func main() {
 			database = pm.db
 			tables = pm.tables
 			databases = pm.databases
+			color = pm.color
 		}
 	}
 
@@ -649,6 +864,7 @@ func main() {
 		database = cm.db
 		tables = cm.tables
 		databases = cm.databases
+		color = cm.colorName()
 	}
 
 	if database == nil {
@@ -658,9 +874,15 @@ func main() {
 	defer database.Close()
 
 	// Phase 2: Main TUI
-	appModel := app.NewModel(database, tables, databases)
-	appProgram := tea.NewProgram(appModel, tea.WithAltScreen())
-	if _, err := appProgram.Run(); err != nil {
+	appModel := app.NewModel(database, tables, databases, cfg)
+	appModel.SetConnectionColor(color)
+	appProgram := tea.NewProgram(appModel, tea.WithAltScreen(), tea.WithMouseCellMotion())
+	final, err := appProgram.Run()
+	if fm, ok := final.(app.Model); ok {
+		// Close the connections opened from inside the app as well.
+		defer fm.Close()
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}